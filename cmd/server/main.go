@@ -6,13 +6,17 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/handlers"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/i18n"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/monitoring"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/server"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/crypto"
 )
 
 func main() {
@@ -25,12 +29,20 @@ func main() {
 		ServiceName:    "auth-app",
 		ServiceVersion: "1.0.0",
 		Environment:    getEnv("ENVIRONMENT", "development"),
-		LogLevel:       slog.LevelInfo,
+		LogLevel:       parseLogLevel(getEnv("LOG_LEVEL", "INFO")),
 		LogFormat:      "json", // JSON logs are easier for Promtail to parse
+		LogMaxAge:      parseLogMaxAge(getEnv("LOG_MAX_AGE_DAYS", "14")),
 		OTLPEndpoint:   getEnv("OTEL_ENDPOINT", "localhost:4318"), // Jaeger endpoint
 		EnableMetrics:  true,
 		EnableTracing:  true,
 		EnableLogging:  true,
+		// Tracing is non-critical unless explicitly required
+		TracingRequired: getEnv("TRACING_REQUIRED", "false") == "true",
+		// Only short-lived commands push; the server is scraped on /metrics
+		PushGatewayURL: getEnv("PUSHGATEWAY_URL", ""),
+		// Drop or hash client identifiers where privacy rules require it
+		RequestLogFields:  getEnv("LOG_REQUEST_FIELDS", monitoring.DefaultRequestLogFields),
+		RequestLogHashKey: getEnv("LOG_HASH_KEY", ""),
 	})
 	if err != nil {
 		log.Fatalf("Failed to initialize monitoring: %v", err)
@@ -42,12 +54,6 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	go func() {
-		<-sigChan
-		monitor.Logger.Info("Received shutdown signal, starting graceful shutdown...")
-		cancel()
-	}()
-
 	db, err := database.NewConnection(cfg.Database)
 	if err != nil {
 		monitor.Logger.Error("Failed to connect to database", 
@@ -57,16 +63,52 @@ func main() {
 	}
 	defer db.Close()
 
+	if cfg.Database.RunMigrations {
+		if err := database.Migrate(db, cfg.Database.MigrationsDir); err != nil {
+			monitor.Logger.Error("Failed to run database migrations",
+				slog.String("error", err.Error()),
+			)
+			log.Fatalf("Failed to run database migrations: %v", err)
+		}
+		monitor.Logger.Info("Database migrations are up to date")
+	}
+
 	instrumentedDB := database.NewInstrumentedDB(db, monitor.Metrics)
+	if cfg.Database.LogQueries {
+		instrumentedDB.EnableQueryLogging(monitor.Logger)
+	}
+	instrumentedDB.EnableSlowQueryLog(monitor.Logger, cfg.Database.SlowQueryThreshold)
 
 	monitor.Logger.Info("Database connection established successfully",
 		slog.String("host", cfg.Database.Host),
 		slog.Int("port", cfg.Database.Port),
 	)
 
-	go updateBusinessMetrics(ctx, instrumentedDB, monitor)
+	go updateBusinessMetrics(ctx, instrumentedDB, monitor, cfg.Database.PoolWarnThreshold)
+
+	// Pretty-printed responses are a debugging aid and never enabled in production
+	handlers.SetPrettyJSON(cfg.Server.PrettyJSON && getEnv("ENVIRONMENT", "development") != "production")
+	handlers.SetJSONLimits(cfg.Server.MaxBodyBytes, cfg.Server.JSONMaxDepth)
+	crypto.SetPepper(cfg.Security.PasswordPepper)
+	hasher, err := crypto.NewHasher(cfg.Security.PasswordHash, cfg.Security.BcryptCost)
+	if err != nil {
+		log.Fatalf("Invalid password hashing settings: %v", err)
+	}
+	crypto.SetDefaultHasher(hasher)
+	if err := i18n.SetLanguages(cfg.Server.Languages); err != nil {
+		log.Fatalf("Invalid SUPPORTED_LANGUAGES: %v", err)
+	}
 
 	srv := server.NewWithMonitoring(cfg, instrumentedDB, monitor)
+
+	go func() {
+		<-sigChan
+		// Refuse new requests right away so the load balancer drains this
+		// instance while in-flight requests finish
+		srv.BeginShutdown()
+		monitor.Logger.Info("Received shutdown signal, starting graceful shutdown...")
+		cancel()
+	}()
 	
 	monitor.Logger.Info("Starting HTTP server",
 		slog.String("port", cfg.Server.Port),
@@ -83,13 +125,26 @@ func main() {
 	select {
 	case <-ctx.Done():
 		monitor.Logger.Info("Context cancelled, shutting down...")
+		if cfg.Server.ShutdownDrainDelay > 0 {
+			monitor.Logger.Info("Draining before exit",
+				slog.Duration("delay", cfg.Server.ShutdownDrainDelay),
+			)
+			time.Sleep(cfg.Server.ShutdownDrainDelay)
+		}
 	case err := <-serverErr:
 		monitor.Logger.Error("Server error", slog.String("error", err.Error()))
 	}
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer shutdownCancel()
 
+	// Let in-flight requests finish before their telemetry is flushed
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		monitor.Logger.Error("HTTP server shutdown did not complete",
+			slog.String("error", err.Error()),
+		)
+	}
+
 	if err := monitor.Shutdown(shutdownCtx); err != nil {
 		log.Printf("Error during monitoring shutdown: %v", err)
 	}
@@ -97,23 +152,23 @@ func main() {
 	monitor.Logger.Info("Application shutdown complete")
 }
 
-func updateBusinessMetrics(ctx context.Context, db database.DB, monitor *monitoring.Monitor) {
+func updateBusinessMetrics(ctx context.Context, db database.DB, monitor *monitoring.Monitor, poolWarnThreshold float64) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
-	updateMetrics(ctx, db, monitor)
+	updateMetrics(ctx, db, monitor, poolWarnThreshold)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			updateMetrics(ctx, db, monitor)
+			updateMetrics(ctx, db, monitor, poolWarnThreshold)
 		}
 	}
 }
 
-func updateMetrics(ctx context.Context, db database.DB, monitor *monitoring.Monitor) {
+func updateMetrics(ctx context.Context, db database.DB, monitor *monitoring.Monitor, poolWarnThreshold float64) {
 	defer monitor.TraceSpan(ctx, "update_business_metrics")()
 
 	var totalUsers int
@@ -141,6 +196,22 @@ func updateMetrics(ctx context.Context, db database.DB, monitor *monitoring.Moni
 	stats := db.Stats()
 	monitor.Metrics.DBConnectionsOpen.Set(float64(stats.OpenConnections))
 
+	// Utilization is only meaningful when the pool has an upper bound
+	if stats.MaxOpenConnections > 0 {
+		utilization := float64(stats.InUse) / float64(stats.MaxOpenConnections)
+		monitor.Metrics.DBPoolUtilization.Set(utilization)
+
+		if poolWarnThreshold > 0 && utilization >= poolWarnThreshold {
+			monitor.Logger.Warn("Database connection pool near saturation",
+				slog.Float64("utilization", utilization),
+				slog.Float64("threshold", poolWarnThreshold),
+				slog.Int("in_use", stats.InUse),
+				slog.Int("max_open", stats.MaxOpenConnections),
+				slog.Int64("wait_count", stats.WaitCount),
+			)
+		}
+	}
+
 	monitor.Logger.Info("Updated business metrics",
 		slog.Int("total_users", totalUsers),
 		slog.Int("active_users", activeUsers),
@@ -155,3 +226,22 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// parseLogMaxAge converts LOG_MAX_AGE_DAYS into a retention period; zero or
+// an invalid value keeps log files forever
+func parseLogMaxAge(days string) time.Duration {
+	n, err := strconv.Atoi(days)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// parseLogLevel converts a LOG_LEVEL name into a slog level, defaulting to info
+func parseLogLevel(name string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
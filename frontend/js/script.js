@@ -13,6 +13,14 @@ let authToken = null;
 function initializeApp() {
     showMessage('System initialized and ready for authentication', 'info');
     
+    // Google sign-in redirects back with our token in the URL fragment
+    const fragment = new URLSearchParams(window.location.hash.slice(1));
+    if (fragment.get('token')) {
+        history.replaceState(null, '', window.location.pathname + window.location.search);
+        completeOAuthLogin(fragment.get('token'));
+        return;
+    }
+    
     const storedToken = localStorage.getItem(TOKEN_KEY);
     const storedUser = localStorage.getItem(USER_KEY);
     
@@ -28,6 +36,37 @@ function initializeApp() {
     }
 }
 
+/**
+ * Start Google sign-in by redirecting to the backend
+ */
+function signInWithGoogle() {
+    window.location.href = `${API_BASE_URL}/auth/google/login`;
+}
+
+/**
+ * Finish an OAuth sign-in by loading the profile for the issued token
+ */
+async function completeOAuthLogin(token) {
+    authToken = token;
+    try {
+        const response = await fetchWithAuth('/profile');
+        if (!response.ok) {
+            throw new Error(`Profile request failed with status ${response.status}`);
+        }
+        currentUser = await response.json();
+        
+        localStorage.setItem(TOKEN_KEY, authToken);
+        localStorage.setItem(USER_KEY, JSON.stringify(currentUser));
+        
+        showUserDashboard();
+        showMessage(`Welcome, ${currentUser.name}!`, 'success');
+    } catch (error) {
+        console.error('OAuth login error:', error);
+        clearStoredAuth();
+        showMessage('Google sign-in failed - please try again', 'error');
+    }
+}
+
 /**
  * Validate that a stored session is still valid
  */
@@ -182,12 +221,45 @@ function showUserDashboard() {
  */
 async function fetchWithAuth(endpoint, options = {}) {
     const url = `${API_BASE_URL}${endpoint}`;
-    const authHeaders = {
-        'Authorization': `Bearer ${authToken}`,
-        'Content-Type': 'application/json',
-        ...options.headers
-    };
-    return fetch(url, { ...options, headers: authHeaders });
+    const send = () => fetch(url, {
+        ...options,
+        headers: {
+            'Authorization': `Bearer ${authToken}`,
+            'Content-Type': 'application/json',
+            ...options.headers
+        }
+    });
+
+    const response = await send();
+    if (response.status === 401 && await refreshExpiredToken(response)) {
+        return send();
+    }
+    return response;
+}
+
+// Refresh the token once when the server reports it expired but refreshable
+async function refreshExpiredToken(response) {
+    try {
+        const body = await response.clone().json();
+        if (!body.can_refresh) {
+            return false;
+        }
+
+        const refreshResponse = await fetch(`${API_BASE_URL}/refresh`, {
+            method: 'POST',
+            headers: { 'Authorization': `Bearer ${authToken}` }
+        });
+        if (!refreshResponse.ok) {
+            return false;
+        }
+
+        const data = await refreshResponse.json();
+        authToken = data.token;
+        localStorage.setItem(TOKEN_KEY, authToken);
+        return true;
+    } catch (error) {
+        return false;
+    }
 }
 
 async function loadProducts() {
@@ -199,8 +271,8 @@ async function loadProducts() {
     try {
         const response = await fetchWithAuth('/products');
         if (response.ok) {
-            const products = await response.json();
-            displayProducts(products);
+            const page = await response.json();
+            displayProducts(page.products);
             showMessage('Products loaded successfully', 'success');
         } else if (response.status === 401) {
             showMessage('Session expired - please sign in again', 'error');
@@ -282,6 +354,14 @@ function displayProducts(products) {
  * User management functions
  */
 function logout() {
+    // Revoke the token server-side; the local sign-out proceeds regardless
+    if (authToken) {
+        fetch(`${API_BASE_URL}/logout`, {
+            method: 'POST',
+            headers: { 'Authorization': `Bearer ${authToken}` }
+        }).catch(() => {});
+    }
+
     authToken = null;
     currentUser = null;
     clearStoredAuth();
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// APIKeyHeader carries service account tokens. It is deliberately separate
+// from the Authorization header used for user JWTs.
+const APIKeyHeader = "X-API-Key"
+
+// apiTokenPrefix marks API tokens so they are recognizable in logs and
+// secret scanners
+const apiTokenPrefix = "ak_"
+
+// APITokenLookup resolves a token hash to an active API token, returning
+// sql.ErrNoRows for unknown, revoked, or expired tokens
+type APITokenLookup interface {
+	GetActiveByHash(tokenHash string) (*models.APIToken, error)
+	TouchLastUsed(id int) error
+}
+
+// GenerateAPIToken returns a new random token, the hash to store for it, and
+// a short prefix that identifies it in listings
+func GenerateAPIToken() (token, tokenHash, prefix string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	token = apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashAPIToken(token), token[:len(apiTokenPrefix)+6], nil
+}
+
+// HashAPIToken hashes a token for storage and lookup. Tokens carry 256 bits
+// of randomness, so a fast hash is sufficient.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyMiddleware authenticates service accounts by API token. It never
+// looks at user JWTs, and requests it admits carry no user identity.
+type APIKeyMiddleware struct {
+	tokens APITokenLookup
+}
+
+// NewAPIKeyMiddleware creates API token middleware backed by tokens
+func NewAPIKeyMiddleware(tokens APITokenLookup) *APIKeyMiddleware {
+	return &APIKeyMiddleware{tokens: tokens}
+}
+
+// RequireScope admits requests whose X-API-Key token is active and has at
+// least one of the given scopes. The token's organization is stored in the
+// context so tenant-scoped handlers work unchanged.
+func (m *APIKeyMiddleware) RequireScope(scopes ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			raw := strings.TrimSpace(r.Header.Get(APIKeyHeader))
+			if raw == "" {
+				http.Error(w, "API key required", http.StatusUnauthorized)
+				return
+			}
+			if !strings.HasPrefix(raw, apiTokenPrefix) || len(raw) > 128 {
+				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+
+			token, err := m.tokens.GetActiveByHash(HashAPIToken(raw))
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					return
+				}
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			if !slices.ContainsFunc(scopes, func(scope string) bool { return slices.Contains(token.Scopes, scope) }) {
+				http.Error(w, "API key lacks the required scope", http.StatusForbidden)
+				return
+			}
+
+			// Usage tracking is best-effort and must not fail the request
+			_ = m.tokens.TouchLastUsed(token.ID)
+
+			next(w, r.WithContext(withAPIToken(r.Context(), token)))
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrAuthorizerUnavailable is returned when an authorizer cannot resolve a
+// user's access; RequireAuth then fails closed with 503
+var ErrAuthorizerUnavailable = errors.New("authorization service unavailable")
+
+// Access is what a user is currently allowed to do
+type Access struct {
+	Roles       []string
+	Permissions []string
+}
+
+// Authorizer resolves the access of an authenticated user. RequireAuth
+// replaces the roles and permissions carried in the token with the
+// authorizer's answer.
+type Authorizer interface {
+	Access(ctx context.Context, claims *Claims) (Access, error)
+}
+
+// RoleSource looks up a user's roles and the permissions they grant, as the
+// user repository does
+type RoleSource interface {
+	GetRoles(userID int) ([]string, error)
+	GetPermissions(userID int) ([]string, error)
+}
+
+// DBAuthorizer reads roles and permissions from our own database, so role
+// changes take effect on the next request rather than the next login
+type DBAuthorizer struct {
+	source RoleSource
+}
+
+// NewDBAuthorizer creates an authorizer backed by source
+func NewDBAuthorizer(source RoleSource) *DBAuthorizer {
+	return &DBAuthorizer{source: source}
+}
+
+// Access returns the user's roles and permissions from the database
+func (a *DBAuthorizer) Access(ctx context.Context, claims *Claims) (Access, error) {
+	roles, err := a.source.GetRoles(claims.UserID)
+	if err != nil {
+		return Access{}, fmt.Errorf("%w: %w", ErrAuthorizerUnavailable, err)
+	}
+	permissions, err := a.source.GetPermissions(claims.UserID)
+	if err != nil {
+		return Access{}, fmt.Errorf("%w: %w", ErrAuthorizerUnavailable, err)
+	}
+	return Access{Roles: roles, Permissions: permissions}, nil
+}
+
+// HTTPAuthorizer asks an external authorization service for a user's access.
+// It sends GET <url>?user_id=<id>&org_id=<org> and expects a JSON body of
+// the form {"roles": ["user", ...], "permissions": ["products:read", ...]}.
+// Answers are cached per organization and user for the cache TTL so the
+// service is not called on every request.
+type HTTPAuthorizer struct {
+	url      string
+	client   *http.Client
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[accessKey]cachedAccess
+}
+
+// accessKey identifies a cached answer. User IDs are only unique within the
+// organization the service was asked about, so both are part of the key.
+type accessKey struct {
+	orgID  int
+	userID int
+}
+
+type cachedAccess struct {
+	access  Access
+	expires time.Time
+}
+
+// maxAuthorizerResponse bounds the body read from the authorization service
+const maxAuthorizerResponse = 64 << 10
+
+// NewHTTPAuthorizer creates an authorizer that calls serviceURL, giving up
+// after timeout and caching answers for cacheTTL (zero disables caching)
+func NewHTTPAuthorizer(serviceURL string, timeout, cacheTTL time.Duration) *HTTPAuthorizer {
+	return &HTTPAuthorizer{
+		url:      serviceURL,
+		client:   &http.Client{Timeout: timeout},
+		cacheTTL: cacheTTL,
+		cache:    make(map[accessKey]cachedAccess),
+	}
+}
+
+// Access returns the user's access from the cache or the external service
+func (a *HTTPAuthorizer) Access(ctx context.Context, claims *Claims) (Access, error) {
+	now := time.Now()
+	key := accessKey{orgID: claims.OrgID, userID: claims.UserID}
+	a.mu.Lock()
+	entry, ok := a.cache[key]
+	a.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.access, nil
+	}
+
+	access, err := a.fetch(ctx, claims)
+	if err != nil {
+		return Access{}, fmt.Errorf("%w: %w", ErrAuthorizerUnavailable, err)
+	}
+
+	if a.cacheTTL > 0 {
+		a.mu.Lock()
+		a.cache[key] = cachedAccess{access: access, expires: now.Add(a.cacheTTL)}
+		for cachedKey, cached := range a.cache {
+			if now.After(cached.expires) {
+				delete(a.cache, cachedKey)
+			}
+		}
+		a.mu.Unlock()
+	}
+	return access, nil
+}
+
+// fetch calls the authorization service for one user
+func (a *HTTPAuthorizer) fetch(ctx context.Context, claims *Claims) (Access, error) {
+	query := url.Values{}
+	query.Set("user_id", strconv.Itoa(claims.UserID))
+	query.Set("org_id", strconv.Itoa(claims.OrgID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.url+"?"+query.Encode(), nil)
+	if err != nil {
+		return Access{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return Access{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Access{}, fmt.Errorf("authorization service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Roles       []string `json:"roles"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(nil, resp.Body, maxAuthorizerResponse)).Decode(&body); err != nil {
+		return Access{}, fmt.Errorf("failed to decode authorization response: %w", err)
+	}
+	return Access{Roles: body.Roles, Permissions: body.Permissions}, nil
+}
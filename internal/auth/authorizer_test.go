@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// stubAuthorizer answers every lookup with access, or err when set
+type stubAuthorizer struct {
+	access Access
+	err    error
+}
+
+func (a stubAuthorizer) Access(ctx context.Context, claims *Claims) (Access, error) {
+	return a.access, a.err
+}
+
+// stubRoleSource stands in for the user repository
+type stubRoleSource struct {
+	roles       []string
+	permissions []string
+	err         error
+}
+
+func (s stubRoleSource) GetRoles(userID int) ([]string, error) {
+	return s.roles, s.err
+}
+
+func (s stubRoleSource) GetPermissions(userID int) ([]string, error) {
+	return s.permissions, s.err
+}
+
+func TestRequireAuthAuthorizer(t *testing.T) {
+	tests := []struct {
+		name       string
+		authorizer Authorizer
+		wantStatus int
+		wantRoles  []string
+	}{
+		{name: "token roles without authorizer", wantStatus: http.StatusOK, wantRoles: []string{"user"}},
+		{name: "external roles replace token roles", authorizer: stubAuthorizer{access: Access{Roles: []string{"admin"}}},
+			wantStatus: http.StatusOK, wantRoles: []string{"admin"}},
+		{name: "database roles replace token roles", authorizer: NewDBAuthorizer(stubRoleSource{roles: []string{"manager"}}),
+			wantStatus: http.StatusOK, wantRoles: []string{"manager"}},
+		{name: "unavailable authorizer fails closed", authorizer: stubAuthorizer{err: ErrAuthorizerUnavailable},
+			wantStatus: http.StatusServiceUnavailable},
+		{name: "failing role source fails closed", authorizer: NewDBAuthorizer(stubRoleSource{err: errors.New("connection refused")}),
+			wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtService := NewJWTService(testSecret, 0, 0)
+			token, err := jwtService.GenerateToken(&models.User{ID: 1, Email: "user@example.com", Roles: []string{"user"}})
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+			m := NewMiddleware(jwtService)
+			m.SetAuthorizer(tt.authorizer)
+
+			var gotRoles []string
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			m.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+				gotRoles, _ = GetUserRolesFromContext(r.Context())
+			})(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !slices.Equal(gotRoles, tt.wantRoles) {
+				t.Errorf("roles = %v, want %v", gotRoles, tt.wantRoles)
+			}
+		})
+	}
+}
+
+func TestRequirePermissionUsesCurrentAccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		wantStatus  int
+	}{
+		{name: "permission still granted", permissions: []string{"users:read"}, wantStatus: http.StatusOK},
+		{name: "permission revoked since login", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtService := NewJWTService(testSecret, 0, 0)
+			token, err := jwtService.GenerateToken(&models.User{
+				ID: 1, Email: "admin@example.com", Roles: []string{"admin"}, Permissions: []string{"users:read"},
+			})
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+			m := NewMiddleware(jwtService)
+			m.SetAuthorizer(NewDBAuthorizer(stubRoleSource{roles: []string{"user"}, permissions: tt.permissions}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			m.RequirePermission("users:read")(func(w http.ResponseWriter, r *http.Request) {})(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// stubAuthzService starts a fake external authorization service answering
+// with handler and counting the calls it receives
+func stubAuthzService(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestHTTPAuthorizer(t *testing.T) {
+	claims := &Claims{UserID: 7, OrgID: 3}
+
+	t.Run("queries the service for the user", func(t *testing.T) {
+		srv, _ := stubAuthzService(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("user_id") != "7" || r.URL.Query().Get("org_id") != "3" {
+				http.Error(w, "unknown user", http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"roles":["user","manager"],"permissions":["products:read"]}`))
+		})
+
+		access, err := NewHTTPAuthorizer(srv.URL, time.Second, 0).Access(context.Background(), claims)
+		if err != nil {
+			t.Fatalf("Access() error = %v", err)
+		}
+		if want := []string{"user", "manager"}; !slices.Equal(access.Roles, want) {
+			t.Errorf("Access().Roles = %v, want %v", access.Roles, want)
+		}
+		if want := []string{"products:read"}; !slices.Equal(access.Permissions, want) {
+			t.Errorf("Access().Permissions = %v, want %v", access.Permissions, want)
+		}
+	})
+
+	t.Run("caches answers per organization", func(t *testing.T) {
+		srv, calls := stubAuthzService(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("org_id") == "3" {
+				w.Write([]byte(`{"roles":["admin"]}`))
+				return
+			}
+			w.Write([]byte(`{"roles":["user"]}`))
+		})
+		a := NewHTTPAuthorizer(srv.URL, time.Second, time.Minute)
+
+		for _, tt := range []struct {
+			claims *Claims
+			want   []string
+		}{
+			{claims: &Claims{UserID: 7, OrgID: 3}, want: []string{"admin"}},
+			{claims: &Claims{UserID: 7, OrgID: 4}, want: []string{"user"}},
+			{claims: &Claims{UserID: 7, OrgID: 3}, want: []string{"admin"}},
+		} {
+			access, err := a.Access(context.Background(), tt.claims)
+			if err != nil {
+				t.Fatalf("Access() error = %v", err)
+			}
+			if !slices.Equal(access.Roles, tt.want) {
+				t.Errorf("Access(org %d).Roles = %v, want %v", tt.claims.OrgID, access.Roles, tt.want)
+			}
+		}
+		if got := calls.Load(); got != 2 {
+			t.Errorf("service calls = %d, want 2", got)
+		}
+	})
+
+	t.Run("caches answers", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			cacheTTL  time.Duration
+			wantCalls int32
+		}{
+			{name: "cached", cacheTTL: time.Minute, wantCalls: 1},
+			{name: "uncached", wantCalls: 3},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				srv, calls := stubAuthzService(t, func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"roles":["user"]}`))
+				})
+				a := NewHTTPAuthorizer(srv.URL, time.Second, tt.cacheTTL)
+				for range 3 {
+					if _, err := a.Access(context.Background(), claims); err != nil {
+						t.Fatalf("Access() error = %v", err)
+					}
+				}
+				if got := calls.Load(); got != tt.wantCalls {
+					t.Errorf("service calls = %d, want %d", got, tt.wantCalls)
+				}
+			})
+		}
+	})
+
+	t.Run("reports failures as unavailable", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			handler http.HandlerFunc
+		}{
+			{name: "error status", handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "boom", http.StatusInternalServerError)
+			}},
+			{name: "malformed body", handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`roles: user`))
+			}},
+			{name: "timeout", handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				srv, _ := stubAuthzService(t, tt.handler)
+				a := NewHTTPAuthorizer(srv.URL, 50*time.Millisecond, time.Minute)
+				if _, err := a.Access(context.Background(), claims); !errors.Is(err, ErrAuthorizerUnavailable) {
+					t.Errorf("Access() error = %v, want %v", err, ErrAuthorizerUnavailable)
+				}
+			})
+		}
+	})
+}
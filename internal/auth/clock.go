@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// issuanceClock hands out non-decreasing issuance times so a backward jump
+// of the system clock (e.g. a flaky NTP correction) cannot produce a token
+// that appears older than one already issued
+type issuanceClock struct {
+	last    atomic.Int64 // Unix nanoseconds of the latest issuance
+	clamped atomic.Bool  // Set while the wall clock is behind last
+	logger  *slog.Logger
+}
+
+// now returns the current time, or the last issuance time if the clock has
+// moved backwards since then. A warning is logged once per rollback.
+func (c *issuanceClock) now() time.Time {
+	now := time.Now()
+	for {
+		last := c.last.Load()
+		if now.UnixNano() >= last {
+			if c.last.CompareAndSwap(last, now.UnixNano()) {
+				c.clamped.Store(false)
+				return now
+			}
+			continue
+		}
+
+		if !c.clamped.Swap(true) && c.logger != nil {
+			c.logger.Warn("System clock moved backwards, holding token issuance time",
+				slog.Duration("rollback", time.Duration(last-now.UnixNano())),
+				slog.Time("last_issued_at", time.Unix(0, last)),
+			)
+		}
+		return time.Unix(0, last)
+	}
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// ContextKey is the type of every request context key in the application.
+//
+// All context keys are declared in this file and are unexported, so values
+// can only be stored and read through the accessors below. Raw string keys
+// must never be passed to context.WithValue: another package using the same
+// string would silently collide with ours. The one exception is the
+// request ID, which is set before authentication by the monitoring package
+// (which cannot import this one) and read with monitoring.RequestIDFromContext.
+type ContextKey string
+
+const (
+	userIDKey    ContextKey = "user_id"
+	userEmailKey ContextKey = "user_email"
+	userRolesKey ContextKey = "user_roles"
+	effRolesKey  ContextKey = "effective_roles"
+	userPermsKey ContextKey = "user_permissions"
+	orgIDKey     ContextKey = "org_id"
+	claimsKey    ContextKey = "claims"
+	apiTokenKey  ContextKey = "api_token"
+	featuresKey  ContextKey = "features"
+	clientIPKey  ContextKey = "client_ip"
+)
+
+// withClaims stores the authenticated user described by validated claims
+func withClaims(ctx context.Context, claims *Claims) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, claims.UserID)
+	ctx = context.WithValue(ctx, userEmailKey, claims.Email)
+	ctx = context.WithValue(ctx, userRolesKey, claims.Roles)
+	ctx = context.WithValue(ctx, userPermsKey, claims.Permissions)
+
+	// Tokens issued before organizations existed belong to the default org
+	orgID := claims.OrgID
+	if orgID == 0 {
+		orgID = models.DefaultOrgID
+	}
+	ctx = context.WithValue(ctx, orgIDKey, orgID)
+
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// withEffectiveRoles stores the user's roles expanded by the role hierarchy,
+// which HasAnyRole and HasAllRoles check against
+func withEffectiveRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, effRolesKey, roles)
+}
+
+// withAPIToken stores the service account token that authenticated the
+// request. Only the organization is shared with the user keys, so
+// tenant-scoped handlers work while user lookups find no user.
+func withAPIToken(ctx context.Context, token *models.APIToken) context.Context {
+	ctx = context.WithValue(ctx, orgIDKey, token.OrgID)
+	return context.WithValue(ctx, apiTokenKey, token)
+}
+
+// WithFeatures stores the request's feature context (see GetFeaturesFromContext)
+func WithFeatures(ctx context.Context, features map[string]string) context.Context {
+	return context.WithValue(ctx, featuresKey, features)
+}
+
+// WithClientIP stores the client address the server resolved for the request
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// GetUserIDFromContext extracts the user ID from the request context
+func GetUserIDFromContext(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(userIDKey).(int)
+	return userID, ok
+}
+
+// GetUserEmailFromContext extracts the user email from the request context
+func GetUserEmailFromContext(ctx context.Context) (string, bool) {
+	email, ok := ctx.Value(userEmailKey).(string)
+	return email, ok
+}
+
+// GetUserRolesFromContext extracts the user roles from the request context
+func GetUserRolesFromContext(ctx context.Context) ([]string, bool) {
+	roles, ok := ctx.Value(userRolesKey).([]string)
+	return roles, ok
+}
+
+// effectiveRolesFromContext returns the roles stored by withEffectiveRoles,
+// falling back to the user's own roles
+func effectiveRolesFromContext(ctx context.Context) ([]string, bool) {
+	if roles, ok := ctx.Value(effRolesKey).([]string); ok {
+		return roles, true
+	}
+	return GetUserRolesFromContext(ctx)
+}
+
+// GetUserPermissionsFromContext extracts the user permissions from the request context
+func GetUserPermissionsFromContext(ctx context.Context) ([]string, bool) {
+	permissions, ok := ctx.Value(userPermsKey).([]string)
+	return permissions, ok
+}
+
+// GetOrgFromContext extracts the user's organization ID from the request context
+func GetOrgFromContext(ctx context.Context) (int, bool) {
+	orgID, ok := ctx.Value(orgIDKey).(int)
+	return orgID, ok
+}
+
+// GetClaimsFromContext extracts the full validated token claims, including
+// any custom metadata, from the request context
+func GetClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok
+}
+
+// GetAPITokenFromContext extracts the API token that authenticated a
+// service account request
+func GetAPITokenFromContext(ctx context.Context) (*models.APIToken, bool) {
+	token, ok := ctx.Value(apiTokenKey).(*models.APIToken)
+	return token, ok
+}
+
+// GetFeaturesFromContext extracts the allowlisted X-Feature-* values sent
+// with the request, keyed by lowercase feature name
+func GetFeaturesFromContext(ctx context.Context) (map[string]string, bool) {
+	features, ok := ctx.Value(featuresKey).(map[string]string)
+	return features, ok
+}
+
+// GetClientIPFromContext extracts the client address resolved by the server,
+// which honors X-Forwarded-For only from trusted proxies
+func GetClientIPFromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPKey).(string)
+	return ip, ok
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+func TestClaimsContextRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  *Claims
+		wantOrg int
+	}{
+		{
+			name: "organization member",
+			claims: &Claims{UserID: 7, OrgID: 3, Email: "user@example.com",
+				Roles: []string{"user", "manager"}, Permissions: []string{"products:read"}},
+			wantOrg: 3,
+		},
+		{
+			name:    "token without organization",
+			claims:  &Claims{UserID: 8, Email: "legacy@example.com", Roles: []string{"user"}},
+			wantOrg: models.DefaultOrgID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := withClaims(context.Background(), tt.claims)
+
+			if got, ok := GetUserIDFromContext(ctx); !ok || got != tt.claims.UserID {
+				t.Errorf("GetUserIDFromContext() = %v, %v, want %v", got, ok, tt.claims.UserID)
+			}
+			if got, ok := GetUserEmailFromContext(ctx); !ok || got != tt.claims.Email {
+				t.Errorf("GetUserEmailFromContext() = %q, %v, want %q", got, ok, tt.claims.Email)
+			}
+			if got, ok := GetUserRolesFromContext(ctx); !ok || !slices.Equal(got, tt.claims.Roles) {
+				t.Errorf("GetUserRolesFromContext() = %v, %v, want %v", got, ok, tt.claims.Roles)
+			}
+			if got, ok := GetUserPermissionsFromContext(ctx); !ok || !slices.Equal(got, tt.claims.Permissions) {
+				t.Errorf("GetUserPermissionsFromContext() = %v, %v, want %v", got, ok, tt.claims.Permissions)
+			}
+			if got, ok := GetOrgFromContext(ctx); !ok || got != tt.wantOrg {
+				t.Errorf("GetOrgFromContext() = %v, %v, want %v", got, ok, tt.wantOrg)
+			}
+			if got, ok := GetClaimsFromContext(ctx); !ok || got != tt.claims {
+				t.Errorf("GetClaimsFromContext() = %p, %v, want %p", got, ok, tt.claims)
+			}
+			if _, ok := GetAPITokenFromContext(ctx); ok {
+				t.Error("GetAPITokenFromContext() found a token in a user context")
+			}
+		})
+	}
+}
+
+func TestAPITokenContextRoundTrip(t *testing.T) {
+	token := &models.APIToken{ID: 1, OrgID: 4, Name: "exporter"}
+	ctx := withAPIToken(context.Background(), token)
+
+	if got, ok := GetAPITokenFromContext(ctx); !ok || got != token {
+		t.Errorf("GetAPITokenFromContext() = %p, %v, want %p", got, ok, token)
+	}
+	if got, ok := GetOrgFromContext(ctx); !ok || got != token.OrgID {
+		t.Errorf("GetOrgFromContext() = %v, %v, want %v", got, ok, token.OrgID)
+	}
+	if _, ok := GetUserIDFromContext(ctx); ok {
+		t.Error("GetUserIDFromContext() found a user in a service account context")
+	}
+}
+
+func TestFeaturesContextRoundTrip(t *testing.T) {
+	features := map[string]string{"beta": "on"}
+	ctx := WithFeatures(context.Background(), features)
+
+	got, ok := GetFeaturesFromContext(ctx)
+	if !ok || got["beta"] != "on" {
+		t.Errorf("GetFeaturesFromContext() = %v, %v, want %v", got, ok, features)
+	}
+}
+
+func TestEmptyContext(t *testing.T) {
+	ctx := context.Background()
+
+	if _, ok := GetUserIDFromContext(ctx); ok {
+		t.Error("GetUserIDFromContext() reported a user")
+	}
+	if _, ok := GetOrgFromContext(ctx); ok {
+		t.Error("GetOrgFromContext() reported an organization")
+	}
+	if _, ok := GetClaimsFromContext(ctx); ok {
+		t.Error("GetClaimsFromContext() reported claims")
+	}
+	// A raw string key equal to ours must not be read back
+	ctx = context.WithValue(ctx, "user_id", 7)
+	if _, ok := GetUserIDFromContext(ctx); ok {
+		t.Error("GetUserIDFromContext() read a raw string key")
+	}
+}
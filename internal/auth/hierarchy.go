@@ -0,0 +1,58 @@
+package auth
+
+import "slices"
+
+// SetRoleHierarchy lets higher roles satisfy the lower roles they outrank in
+// RequireRole, RequireAnyRole, HasAnyRole and HasAllRoles. hierarchy maps each role to the roles
+// directly beneath it, e.g. {"admin": {"manager"}, "manager": {"user"}}, and
+// is applied transitively, so admin also satisfies user. Without a hierarchy
+// roles must match exactly.
+func (m *Middleware) SetRoleHierarchy(hierarchy map[string][]string) {
+	if len(hierarchy) == 0 {
+		m.impliedRoles = nil
+		return
+	}
+
+	m.impliedRoles = make(map[string][]string, len(hierarchy))
+	for role := range hierarchy {
+		m.impliedRoles[role] = lowerRoles(hierarchy, role)
+	}
+}
+
+// lowerRoles returns every role outranked by role, directly or through
+// intermediate roles. Cycles are tolerated: each role is visited once.
+func lowerRoles(hierarchy map[string][]string, role string) []string {
+	seen := map[string]bool{role: true}
+	var lower []string
+
+	pending := slices.Clone(hierarchy[role])
+	for len(pending) > 0 {
+		next := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		lower = append(lower, next)
+		pending = append(pending, hierarchy[next]...)
+	}
+	return lower
+}
+
+// effectiveRoles returns roles together with every role they outrank, in
+// order and without duplicates
+func (m *Middleware) effectiveRoles(roles []string) []string {
+	if len(m.impliedRoles) == 0 {
+		return roles
+	}
+
+	effective := slices.Clone(roles)
+	for _, role := range roles {
+		for _, implied := range m.impliedRoles[role] {
+			if !slices.Contains(effective, implied) {
+				effective = append(effective, implied)
+			}
+		}
+	}
+	return effective
+}
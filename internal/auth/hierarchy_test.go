@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// authenticatedContext returns the request context RequireAuth builds for a
+// user with roles under the given hierarchy
+func authenticatedContext(t *testing.T, hierarchy map[string][]string, roles ...string) context.Context {
+	t.Helper()
+
+	jwtService := NewJWTService(testSecret, 0, 0)
+	token, err := jwtService.GenerateToken(&models.User{ID: 1, Email: "user@example.com", Roles: roles})
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	m := NewMiddleware(jwtService)
+	m.SetRoleHierarchy(hierarchy)
+
+	var ctx context.Context
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	m.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})(httptest.NewRecorder(), req)
+	if ctx == nil {
+		t.Fatal("RequireAuth rejected the token")
+	}
+	return ctx
+}
+
+func TestRoleChecksApplyHierarchy(t *testing.T) {
+	hierarchy := map[string][]string{"admin": {"manager"}, "manager": {"user"}}
+
+	tests := []struct {
+		name      string
+		hierarchy map[string][]string
+		roles     []string
+		check     func(context.Context, ...string) bool
+		want      []string
+		ok        bool
+	}{
+		{name: "any: exact role", hierarchy: hierarchy, roles: []string{"user"}, check: HasAnyRole, want: []string{"user"}, ok: true},
+		{name: "any: outranked role", hierarchy: hierarchy, roles: []string{"admin"}, check: HasAnyRole, want: []string{"user"}, ok: true},
+		{name: "any: higher role", hierarchy: hierarchy, roles: []string{"manager"}, check: HasAnyRole, want: []string{"admin"}, ok: false},
+		{name: "any: no hierarchy", roles: []string{"admin"}, check: HasAnyRole, want: []string{"user"}, ok: false},
+		{name: "all: outranked roles", hierarchy: hierarchy, roles: []string{"admin"}, check: HasAllRoles, want: []string{"manager", "user"}, ok: true},
+		{name: "all: one missing", hierarchy: hierarchy, roles: []string{"manager"}, check: HasAllRoles, want: []string{"admin", "user"}, ok: false},
+		{name: "all: no hierarchy", roles: []string{"admin"}, check: HasAllRoles, want: []string{"admin", "user"}, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := authenticatedContext(t, tt.hierarchy, tt.roles...)
+			if got := tt.check(ctx, tt.want...); got != tt.ok {
+				t.Errorf("check(%v) with roles %v = %v, want %v", tt.want, tt.roles, got, tt.ok)
+			}
+		})
+	}
+}
@@ -1,50 +1,321 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrTokenExpired reports a token whose signature is valid but whose
+// expiry has passed; such tokens can still be refreshed within the window
+var ErrTokenExpired = jwt.ErrTokenExpired
+
+// ErrTokenMalformed reports a token that is not a well-formed JWT
+var ErrTokenMalformed = jwt.ErrTokenMalformed
+
+// ErrTokenSignatureInvalid reports a token whose signature does not verify
+var ErrTokenSignatureInvalid = jwt.ErrTokenSignatureInvalid
+
+// ErrTokenInvalid reports a token rejected for any other reason, such as a
+// missing or unexpected claim
+var ErrTokenInvalid = errors.New("invalid token")
+
+// ErrTokenWrongIssuer reports a correctly signed token whose issuer or
+// audience does not match this service, which usually means two services
+// share a secret or were configured inconsistently rather than tampering
+var ErrTokenWrongIssuer = errors.New("token issuer or audience mismatch")
+
+// Default issuer and audience, used when NewJWTService is not given others
+const (
+	DefaultIssuer   = "auth-app"
+	DefaultAudience = "auth-app"
+)
+
+// Default token lifetimes, used when NewJWTService is given zero durations
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
 )
 
 // Claims represents the JWT token claims
 type Claims struct {
 	UserID int      `json:"user_id"`
+	OrgID  int      `json:"org_id"`
 	Email  string   `json:"email"`
 	Roles  []string `json:"roles"`
+	// Permissions are those granted by Roles when the token was issued
+	Permissions []string `json:"perms,omitempty"`
+	// Metadata carries a small, bounded set of custom claims for integrations
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// TokenVersion must match the user's current version for the token to be accepted
+	TokenVersion int `json:"tv"`
+	// PasswordExpired restricts the token to changing the password
+	PasswordExpired bool `json:"pwd_expired,omitempty"`
+	// Fingerprint binds the token to the client it was issued to; see ClientFingerprint
+	Fingerprint string `json:"fpt,omitempty"`
+	// Type marks purpose tokens such as TokenTypeVerify; access tokens have none
+	Type string `json:"typ,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Limits on custom token metadata, keeping tokens well under header size limits
+const (
+	MaxMetadataEntries  = 16
+	MaxMetadataKeyLen   = 64
+	MaxMetadataValueLen = 256
+	MaxMetadataSize     = 1024 // Combined bytes of all keys and values
+)
+
+// ErrMetadataTooLarge is returned when token metadata exceeds the size limits
+var ErrMetadataTooLarge = errors.New("token metadata exceeds size limit")
+
+// TokenOption customizes the claims of a token created by GenerateToken
+type TokenOption func(*Claims) error
+
+// WithMetadata attaches custom string claims to the token. Metadata that
+// exceeds the size limits is rejected with ErrMetadataTooLarge.
+func WithMetadata(metadata map[string]string) TokenOption {
+	return func(c *Claims) error {
+		if err := validateMetadata(metadata); err != nil {
+			return err
+		}
+		c.Metadata = maps.Clone(metadata)
+		return nil
+	}
+}
+
+// WithPasswordExpired marks the token as issued to a user whose password has
+// expired; RequireAuth then only admits it to the password change routes
+func WithPasswordExpired() TokenOption {
+	return func(c *Claims) error {
+		c.PasswordExpired = true
+		return nil
+	}
+}
+
+// validateMetadata checks metadata against the entry, key, value, and total size limits
+func validateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataEntries {
+		return fmt.Errorf("%w: %d entries exceeds maximum of %d", ErrMetadataTooLarge, len(metadata), MaxMetadataEntries)
+	}
+
+	total := 0
+	for key, value := range metadata {
+		if key == "" {
+			return fmt.Errorf("token metadata keys cannot be empty")
+		}
+		if len(key) > MaxMetadataKeyLen {
+			return fmt.Errorf("%w: key %q exceeds maximum length of %d", ErrMetadataTooLarge, key, MaxMetadataKeyLen)
+		}
+		if len(value) > MaxMetadataValueLen {
+			return fmt.Errorf("%w: value for %q exceeds maximum length of %d", ErrMetadataTooLarge, key, MaxMetadataValueLen)
+		}
+		total += len(key) + len(value)
+	}
+
+	if total > MaxMetadataSize {
+		return fmt.Errorf("%w: %d bytes exceeds maximum of %d", ErrMetadataTooLarge, total, MaxMetadataSize)
+	}
+	return nil
+}
+
 // JWTService handles JWT token operations
 type JWTService struct {
 	secret []byte
+	// accessTTL is how long an issued token is valid
+	accessTTL time.Duration
+	// refreshTTL bounds how long after issuance a token may be refreshed
+	refreshTTL time.Duration
+	// leeway tolerates clock skew when checking exp and nbf
+	leeway time.Duration
+	// issuer and audience are set on issued tokens and required on validation
+	issuer   string
+	audience string
+	// issuance is set when monotonic issuance is enabled
+	issuance *issuanceClock
+	// revoked is consulted by ValidateToken when set
+	revoked TokenStore
+	// generations and validations count issued and validated tokens; nil disables counting
+	generations prometheus.Counter
+	validations *prometheus.CounterVec
 }
 
-// NewJWTService creates a new JWT service with the provided secret
-func NewJWTService(secret string) *JWTService {
+// NewJWTService creates a new JWT service with the provided secret. Tokens
+// are valid for accessTTL and may be refreshed until refreshTTL after they
+// were issued; zero durations select the defaults.
+func NewJWTService(secret string, accessTTL, refreshTTL time.Duration) *JWTService {
+	if accessTTL <= 0 {
+		accessTTL = DefaultAccessTokenTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = DefaultRefreshTokenTTL
+	}
 	return &JWTService{
-		secret: []byte(secret),
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		issuer:     DefaultIssuer,
+		audience:   DefaultAudience,
+	}
+}
+
+// SetIssuer sets the iss and aud claims of issued tokens; tokens that do
+// not carry both fail validation with ErrTokenWrongIssuer. Empty values keep
+// the current setting.
+func (j *JWTService) SetIssuer(issuer, audience string) {
+	if issuer != "" {
+		j.issuer = issuer
+	}
+	if audience != "" {
+		j.audience = audience
+	}
+}
+
+// EnableMonotonicIssuance guarantees that no token is issued with an iat
+// earlier than the last one, logging a warning when the clock rolls back
+func (j *JWTService) EnableMonotonicIssuance(logger *slog.Logger) {
+	j.issuance = &issuanceClock{logger: logger}
+}
+
+// AccessTTL returns how long issued tokens are valid
+func (j *JWTService) AccessTTL() time.Duration {
+	return j.accessTTL
+}
+
+// SetLeeway tolerates clock skew between nodes: exp and nbf are checked
+// with up to leeway of slack. Zero, the default, checks them exactly.
+func (j *JWTService) SetLeeway(leeway time.Duration) {
+	j.leeway = leeway
+}
+
+// SetTokenStore enables revocation: tokens whose jti is revoked in store
+// fail validation
+func (j *JWTService) SetTokenStore(store TokenStore) {
+	j.revoked = store
+}
+
+// SetMetrics counts every issued token in generations and every
+// validation in validations, labelled by ValidationResult
+func (j *JWTService) SetMetrics(generations prometheus.Counter, validations *prometheus.CounterVec) {
+	j.generations = generations
+	j.validations = validations
+}
+
+// recordGeneration counts an issued token
+func (j *JWTService) recordGeneration() {
+	if j.generations != nil {
+		j.generations.Inc()
+	}
+}
+
+// recordValidation counts a validation by its result
+func (j *JWTService) recordValidation(err error) {
+	if j.validations != nil {
+		j.validations.WithLabelValues(ValidationResult(err)).Inc()
+	}
+}
+
+// ValidationResult classifies a ValidateToken error for metrics and logs:
+// "valid", "expired", "malformed", "bad_signature", "wrong_issuer",
+// "revoked" or "invalid"
+func ValidationResult(err error) string {
+	switch {
+	case err == nil:
+		return "valid"
+	case errors.Is(err, ErrTokenRevoked):
+		return "revoked"
+	case errors.Is(err, ErrTokenWrongIssuer):
+		return "wrong_issuer"
+	case onlyExpired(err):
+		return "expired"
+	case errors.Is(err, ErrTokenMalformed):
+		return "malformed"
+	case errors.Is(err, ErrTokenSignatureInvalid):
+		return "bad_signature"
+	default:
+		return "invalid"
+	}
+}
+
+// TokenStore returns the store revoked tokens are recorded in, or nil if
+// revocation is not enabled
+func (j *JWTService) TokenStore() TokenStore {
+	return j.revoked
+}
+
+// RevocationDeadline returns the last time the token described by claims
+// could still be accepted: its expiry plus the leeway, or for access tokens
+// the end of the window in which ValidateRefreshable accepts them, whichever
+// is later. A revocation must be kept at least this long, or the token could
+// be refreshed into a fresh one once the entry is dropped.
+func (j *JWTService) RevocationDeadline(claims *Claims) time.Time {
+	var deadline time.Time
+	if claims.ExpiresAt != nil {
+		deadline = claims.ExpiresAt.Add(j.leeway)
+	}
+	if claims.Type == "" && claims.IssuedAt != nil {
+		if refreshable := claims.IssuedAt.Add(j.refreshTTL); refreshable.After(deadline) {
+			deadline = refreshable
+		}
+	}
+	return deadline
+}
+
+// isRevoked reports whether the token's jti has been revoked
+func (j *JWTService) isRevoked(claims *Claims) bool {
+	return j.revoked != nil && j.revoked.IsRevoked(claims.ID)
+}
+
+// now returns the issuance time for a new token
+func (j *JWTService) now() time.Time {
+	if j.issuance == nil {
+		return time.Now()
 	}
+	return j.issuance.now()
 }
 
-// GenerateToken creates a new JWT token for the given user
-func (j *JWTService) GenerateToken(user *models.User) (string, error) {
+// GenerateToken creates a new JWT token for the given user, applying any options to its claims
+func (j *JWTService) GenerateToken(user *models.User, opts ...TokenOption) (string, error) {
+	now := j.now()
+
+	jti, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
 	// Create the token claims
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Roles:  user.Roles,
+		UserID:       user.ID,
+		OrgID:        user.OrgID,
+		Email:        user.Email,
+		Roles:        user.Roles,
+		Permissions:  user.Permissions,
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "goapp",
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    j.issuer,
+			Audience:  jwt.ClaimStrings{j.audience},
 			Subject:   fmt.Sprintf("user_%d", user.ID),
+			ID:        jti,
 		},
 	}
 
+	for _, opt := range opts {
+		if err := opt(claims); err != nil {
+			return "", err
+		}
+	}
+
 	// Create the token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
@@ -54,68 +325,214 @@ func (j *JWTService) GenerateToken(user *models.User) (string, error) {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
 
+	j.recordGeneration()
 	return tokenString, nil
 }
 
-// ValidateToken parses and validates a JWT token
+// ValidateToken parses and validates a JWT token. Errors can be told apart
+// with errors.Is: ErrTokenExpired, ErrTokenMalformed,
+// ErrTokenSignatureInvalid, ErrTokenWrongIssuer, ErrTokenRevoked, or
+// ErrTokenInvalid for anything else. A revoked token reports
+// ErrTokenRevoked even once it has also expired.
 func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := j.validateToken(tokenString)
+	j.recordValidation(err)
+	return claims, err
+}
+
+// validateToken implements ValidateToken
+func (j *JWTService) validateToken(tokenString string) (*Claims, error) {
 	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return j.secret, nil
-	})
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, j.keyFunc, j.parserOptions()...)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse token: %w", err)
+		// The signature is verified before expiry, so the claims of a token
+		// that only expired are authentic. Report revocation first: a
+		// revoked token must not be answered with an invitation to refresh.
+		if onlyExpired(err) && j.isRevoked(claims) {
+			return nil, ErrTokenRevoked
+		}
+		return nil, j.parseError("failed to parse token", err)
 	}
 
 	// Check if token is valid
 	if !token.Valid {
-		return nil, fmt.Errorf("invalid token")
+		return nil, ErrTokenInvalid
 	}
 
-	// Extract claims
-	claims, ok := token.Claims.(*Claims)
-	if !ok {
-		return nil, fmt.Errorf("invalid token claims")
+	if err := requireAccessToken(claims); err != nil {
+		return nil, err
 	}
 
-	// Additional validation can be added here
-	// For example, checking if the user still exists and is active
+	if j.isRevoked(claims) {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// parserOptions are the claim checks applied to every validated token
+func (j *JWTService) parserOptions() []jwt.ParserOption {
+	return []jwt.ParserOption{
+		jwt.WithLeeway(j.leeway),
+		jwt.WithIssuer(j.issuer),
+		jwt.WithAudience(j.audience),
+	}
+}
+
+// parseError wraps a parse failure, marking issuer and audience mismatches
+// with ErrTokenWrongIssuer so they can be told apart from bad signatures
+func (j *JWTService) parseError(msg string, err error) error {
+	if errors.Is(err, jwt.ErrTokenInvalidIssuer) || errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		return fmt.Errorf("%s: %w: %w", msg, ErrTokenWrongIssuer, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// onlyExpired reports whether expiry is the sole reason a token failed
+// validation. Issuer and audience failures are joined with it by the parser.
+func onlyExpired(err error) bool {
+	return errors.Is(err, jwt.ErrTokenExpired) &&
+		!errors.Is(err, jwt.ErrTokenInvalidIssuer) &&
+		!errors.Is(err, jwt.ErrTokenInvalidAudience)
+}
+
+// keyFunc verifies the signing method and supplies the HMAC secret
+func (j *JWTService) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return j.secret, nil
+}
+
+// Inspect decodes a token for diagnostics, returning its claims and the list
+// of checks it fails (bad signature, expiry, not-before) instead of stopping
+// at the first failure. It only errors on structurally malformed tokens.
+// Inspect must never be used to authenticate a request; use ValidateToken.
+func (j *JWTService) Inspect(tokenString string) (*Claims, []string, error) {
+	claims := &Claims{}
+	var failures []string
+
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	_, err := parser.ParseWithClaims(tokenString, claims, j.keyFunc)
+	switch {
+	case err == nil:
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return nil, nil, fmt.Errorf("failed to parse token: %w", err)
+	case errors.Is(err, jwt.ErrTokenUnverifiable):
+		failures = append(failures, "unexpected signing method")
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		failures = append(failures, "invalid signature")
+	default:
+		failures = append(failures, err.Error())
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt == nil {
+		failures = append(failures, "missing expiration")
+	} else if now.After(claims.ExpiresAt.Add(j.leeway)) {
+		failures = append(failures, fmt.Sprintf("expired at %s", claims.ExpiresAt.Format(time.RFC3339)))
+	}
+	if claims.NotBefore != nil && now.Before(claims.NotBefore.Add(-j.leeway)) {
+		failures = append(failures, fmt.Sprintf("not valid before %s", claims.NotBefore.Format(time.RFC3339)))
+	}
+	if claims.Issuer != j.issuer {
+		failures = append(failures, fmt.Sprintf("unexpected issuer %q", claims.Issuer))
+	}
+	if !slices.Contains(claims.Audience, j.audience) {
+		failures = append(failures, fmt.Sprintf("audience does not include %q", j.audience))
+	}
+	if claims.IssuedAt != nil && now.Before(claims.IssuedAt.Time) {
+		failures = append(failures, fmt.Sprintf("issued in the future at %s", claims.IssuedAt.Format(time.RFC3339)))
+	}
+
+	return claims, failures, nil
+}
+
+// InspectToken decodes a token's claims without verifying its signature,
+// expiry, issuer, or anything else, so support can read the user and roles
+// of an expired or foreign token. It only errors on structurally malformed
+// tokens. The claims are attacker-controlled: never base an authentication
+// or authorization decision on them; use ValidateToken.
+func (j *JWTService) InspectToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	if _, _, err := parser.ParseUnverified(tokenString, claims); err != nil {
+		return nil, fmt.Errorf("failed to decode token: %w", err)
+	}
+	return claims, nil
+}
+
+// ValidateRefreshable checks that a token may be refreshed: it must carry a
+// valid signature and be within the refresh window, but may have expired
+func (j *JWTService) ValidateRefreshable(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, j.keyFunc, j.parserOptions()...)
+	if err != nil && !onlyExpired(err) {
+		return nil, j.parseError("cannot refresh invalid token", err)
+	}
+	if err := requireAccessToken(claims); err != nil {
+		return nil, err
+	}
+
+	// Check if token is not too old to refresh
+	if claims.IssuedAt == nil || time.Since(claims.IssuedAt.Time) > j.refreshTTL {
+		return nil, fmt.Errorf("token too old to refresh")
+	}
+
+	if j.isRevoked(claims) {
+		return nil, ErrTokenRevoked
+	}
 
 	return claims, nil
 }
 
 // RefreshToken creates a new token with extended expiration (optional feature)
 func (j *JWTService) RefreshToken(oldToken string) (string, error) {
-	claims, err := j.ValidateToken(oldToken)
+	claims, err := j.ValidateRefreshable(oldToken)
 	if err != nil {
-		return "", fmt.Errorf("cannot refresh invalid token: %w", err)
+		return "", err
 	}
 
-	// Check if token is not too old to refresh (e.g., within last 7 days)
-	if time.Since(claims.IssuedAt.Time) > 7*24*time.Hour {
-		return "", fmt.Errorf("token too old to refresh")
+	jti, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
 	}
 
+	now := j.now()
+
 	// Create new claims with extended expiration
 	newClaims := &Claims{
 		UserID: claims.UserID,
+		OrgID:  claims.OrgID,
 		Email:  claims.Email,
 		Roles:  claims.Roles,
+		// Permissions are carried over like roles until the next login
+		Permissions: claims.Permissions,
+		// Metadata was validated when the original token was generated
+		Metadata:        claims.Metadata,
+		TokenVersion:    claims.TokenVersion,
+		PasswordExpired: claims.PasswordExpired,
+		Fingerprint:     claims.Fingerprint,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "auth-app",
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    j.issuer,
+			Audience:  jwt.ClaimStrings{j.audience},
 			Subject:   claims.Subject,
+			ID:        jti,
 		},
 	}
 
 	// Create and sign new token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims)
-	return token.SignedString(j.secret)
+	tokenString, err := token.SignedString(j.secret)
+	if err != nil {
+		return "", err
+	}
+
+	j.recordGeneration()
+	return tokenString, nil
 }
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// testSecret signs every token issued in auth tests
+const testSecret = "auth-test-secret-at-least-32-bytes"
+
+// signedToken returns an access token for user 1 with the given validity
+// window, bypassing GenerateToken so it can already be expired or not yet
+// valid
+func signedToken(t *testing.T, notBefore, expiresAt time.Time) string {
+	t.Helper()
+
+	claims := &Claims{
+		UserID: 1,
+		Email:  "user@example.com",
+		Roles:  []string{"user"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    DefaultIssuer,
+			Audience:  jwt.ClaimStrings{DefaultAudience},
+			IssuedAt:  jwt.NewNumericDate(notBefore),
+			NotBefore: jwt.NewNumericDate(notBefore),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return token
+}
+
+func TestValidateTokenLeeway(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		leeway    time.Duration
+		notBefore time.Time
+		expiresAt time.Time
+		want      string // ValidationResult of the error
+	}{
+		{name: "expired within leeway", leeway: 5 * time.Second, notBefore: now.Add(-time.Hour), expiresAt: now.Add(-2 * time.Second), want: "valid"},
+		{name: "expired beyond leeway", leeway: 5 * time.Second, notBefore: now.Add(-time.Hour), expiresAt: now.Add(-10 * time.Second), want: "expired"},
+		{name: "expired without leeway", notBefore: now.Add(-time.Hour), expiresAt: now.Add(-2 * time.Second), want: "expired"},
+		{name: "not yet valid within leeway", leeway: 5 * time.Second, notBefore: now.Add(2 * time.Second), expiresAt: now.Add(time.Hour), want: "valid"},
+		{name: "not yet valid beyond leeway", leeway: 5 * time.Second, notBefore: now.Add(10 * time.Second), expiresAt: now.Add(time.Hour), want: "invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewJWTService(testSecret, 0, 0)
+			j.SetLeeway(tt.leeway)
+
+			_, err := j.ValidateToken(signedToken(t, tt.notBefore, tt.expiresAt))
+			if got := ValidationResult(err); got != tt.want {
+				t.Errorf("ValidateToken() = %q (%v), want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTokenRevokedBeforeExpired(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		revoked   bool
+		want      string // ValidationResult of the error
+	}{
+		{name: "revoked", expiresAt: now.Add(time.Hour), revoked: true, want: "revoked"},
+		{name: "expired", expiresAt: now.Add(-time.Minute), want: "expired"},
+		{name: "revoked and expired", expiresAt: now.Add(-time.Minute), revoked: true, want: "revoked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &Claims{
+				UserID: 1,
+				RegisteredClaims: jwt.RegisteredClaims{
+					ID:        "token-1",
+					Issuer:    DefaultIssuer,
+					Audience:  jwt.ClaimStrings{DefaultAudience},
+					IssuedAt:  jwt.NewNumericDate(now.Add(-time.Hour)),
+					ExpiresAt: jwt.NewNumericDate(tt.expiresAt),
+				},
+			}
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+			if err != nil {
+				t.Fatalf("SignedString() error = %v", err)
+			}
+
+			j := NewJWTService(testSecret, 0, 0)
+			store := NewMemoryTokenStore()
+			j.SetTokenStore(store)
+			if tt.revoked {
+				store.Revoke(claims.ID, j.RevocationDeadline(claims))
+			}
+
+			_, err = j.ValidateToken(token)
+			if got := ValidationResult(err); got != tt.want {
+				t.Errorf("ValidateToken() = %q (%v), want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectToken(t *testing.T) {
+	now := time.Now()
+	expired := signedToken(t, now.Add(-2*time.Hour), now.Add(-time.Hour))
+
+	tests := []struct {
+		name      string
+		secret    string
+		token     string
+		wantEmail string
+		wantErr   bool
+	}{
+		{name: "expired token", secret: testSecret, token: expired, wantEmail: "user@example.com"},
+		{name: "foreign signature", secret: "another-secret-at-least-32-bytes-long", token: expired, wantEmail: "user@example.com"},
+		{name: "malformed token", secret: testSecret, token: "not.a.jwt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := NewJWTService(tt.secret, 0, 0).InspectToken(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InspectToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && claims.Email != tt.wantEmail {
+				t.Errorf("InspectToken() email = %q, want %q", claims.Email, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestJWTServiceMetrics(t *testing.T) {
+	generations := prometheus.NewCounter(prometheus.CounterOpts{Name: "auth_token_generations_total"})
+	validations := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "auth_token_validations_total"}, []string{"result"})
+	j := NewJWTService(testSecret, 0, 0)
+	j.SetMetrics(generations, validations)
+
+	user := &models.User{ID: 1, Email: "user@example.com", Roles: []string{"user"}}
+	token, err := j.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if _, err := j.GenerateToken(user); err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if got := testutil.ToFloat64(generations); got != 2 {
+		t.Errorf("generations = %v, want 2", got)
+	}
+
+	forged, err := NewJWTService("another-secret-at-least-32-bytes!!", 0, 0).GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	now := time.Now()
+	for _, tokenString := range []string{
+		token,
+		token,
+		signedToken(t, now.Add(-time.Hour), now.Add(-time.Minute)),
+		"not-a-jwt",
+		forged,
+	} {
+		j.ValidateToken(tokenString)
+	}
+
+	want := map[string]float64{"valid": 2, "expired": 1, "malformed": 1, "bad_signature": 1, "invalid": 0}
+	for result, count := range want {
+		if got := testutil.ToFloat64(validations.WithLabelValues(result)); got != count {
+			t.Errorf("validations{result=%q} = %v, want %v", result, got, count)
+		}
+	}
+}
@@ -2,33 +2,144 @@ package auth
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-// ContextKey is a custom type for context keys to avoid collisions
-type ContextKey string
+// DefaultMaxTokenSize is the default upper bound on a bearer token's length in bytes
+const DefaultMaxTokenSize = 8192
 
+// Causes of a rejected Authorization header, used as log fields and metric labels
 const (
-	// UserIDKey is the context key for user ID
-	UserIDKey ContextKey = "user_id"
-	// UserEmailKey is the context key for user email
-	UserEmailKey ContextKey = "user_email"
-	// UserRolesKey is the context key for user roles
-	UserRolesKey ContextKey = "user_roles"
+	HeaderErrorMissing      = "missing"
+	HeaderErrorTooLarge     = "too_large"
+	HeaderErrorWrongScheme  = "wrong_scheme"
+	HeaderErrorEmptyToken   = "empty_token"
+	HeaderErrorParseFailure = "parse_failure"
+	HeaderErrorWrongIssuer  = "wrong_issuer"
 )
 
+// ErrPasswordExpired is returned for tokens restricted to changing an expired password
+var ErrPasswordExpired = errors.New("password has expired")
+
+// ErrTokenRevoked is returned for tokens issued before the user's token version was bumped
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// TokenVersionSource looks up the current token version of an active user,
+// returning sql.ErrNoRows when the user no longer exists or is inactive
+type TokenVersionSource interface {
+	GetTokenVersion(userID int) (int, error)
+}
+
 // Middleware provides authentication and authorization middleware
 type Middleware struct {
-	jwtService *JWTService
+	jwtService    *JWTService
+	maxTokenSize  int
+	tokenVersions TokenVersionSource
+	// passwordChangeRoutes are the only paths admitting tokens whose password expired
+	passwordChangeRoutes []string
+	// logger and headerErrors report rejected Authorization headers; both are optional
+	logger       *slog.Logger
+	headerErrors *prometheus.CounterVec
+	// authorizer resolves each request's roles and permissions; nil trusts
+	// those carried in the token
+	authorizer Authorizer
+	// bindFingerprint rejects bound tokens presented by another client
+	bindFingerprint bool
+	bindingFailures prometheus.Counter
+	// impliedRoles maps each role to the lower roles it satisfies; see SetRoleHierarchy
+	impliedRoles map[string][]string
 }
 
 // NewMiddleware creates a new authentication middleware
 func NewMiddleware(jwtService *JWTService) *Middleware {
 	return &Middleware{
-		jwtService: jwtService,
+		jwtService:   jwtService,
+		maxTokenSize: DefaultMaxTokenSize,
+	}
+}
+
+// SetMaxTokenSize sets the longest bearer token the middleware will attempt to parse
+func (m *Middleware) SetMaxTokenSize(size int) {
+	if size > 0 {
+		m.maxTokenSize = size
+	}
+}
+
+// SetTokenVersionSource enables token version checks, so bumping a user's
+// version immediately invalidates every token issued to them
+func (m *Middleware) SetTokenVersionSource(source TokenVersionSource) {
+	m.tokenVersions = source
+}
+
+// SetAuthorizer resolves the roles and permissions of every authenticated
+// request through a, replacing those carried in the token
+func (m *Middleware) SetAuthorizer(a Authorizer) {
+	m.authorizer = a
+}
+
+// SetHeaderErrorReporting logs rejected Authorization headers and counts
+// them in headerErrors by cause. Clients still get the same generic response
+// whatever the cause, so this is where misconfigured integrations show up.
+func (m *Middleware) SetHeaderErrorReporting(logger *slog.Logger, headerErrors *prometheus.CounterVec) {
+	m.logger = logger
+	m.headerErrors = headerErrors
+}
+
+// reportHeaderError records why an Authorization header was rejected
+func (m *Middleware) reportHeaderError(r *http.Request, cause string, err error) {
+	if m.headerErrors != nil {
+		m.headerErrors.WithLabelValues(cause).Inc()
+	}
+	if m.logger == nil {
+		return
+	}
+
+	attrs := []any{
+		slog.String("cause", cause),
+		slog.String("path", r.URL.Path),
+		slog.String("user_agent", r.UserAgent()),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	m.logger.Info("Rejected Authorization header", attrs...)
+}
+
+// SetPasswordChangeRoutes lists the paths a user with an expired password may
+// still call, such as the password change and profile endpoints. Every other
+// route rejects their token with 403 until the password is changed.
+func (m *Middleware) SetPasswordChangeRoutes(paths ...string) {
+	m.passwordChangeRoutes = paths
+}
+
+// CheckTokenVersion returns ErrTokenRevoked if the token's version is stale
+// or its user is no longer active. It is a no-op without a version source.
+func (m *Middleware) CheckTokenVersion(claims *Claims) error {
+	if m.tokenVersions == nil {
+		return nil
+	}
+
+	version, err := m.tokenVersions.GetTokenVersion(claims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTokenRevoked
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check token version: %w", err)
+	}
+	if claims.TokenVersion != version {
+		return ErrTokenRevoked
 	}
+	return nil
 }
 
 // RequireAuth ensures the request has a valid JWT token
@@ -37,121 +148,304 @@ func (m *Middleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 		// Extract token from Authorization header
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
+			m.reportHeaderError(r, HeaderErrorMissing, nil)
 			http.Error(w, "Authorization header required", http.StatusUnauthorized)
 			return
 		}
 
+		// Reject oversized tokens before doing any parsing work
+		if len(authHeader) > len("Bearer ")+m.maxTokenSize {
+			m.reportHeaderError(r, HeaderErrorTooLarge, nil)
+			http.Error(w, "Authorization header too large", http.StatusRequestHeaderFieldsTooLarge)
+			return
+		}
+
 		// Parse Bearer token
 		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		if len(parts) != 2 || parts[0] != "Bearer" || parts[1] == "" {
+			m.reportHeaderError(r, headerFormatError(parts), nil)
 			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
 			return
 		}
 
 		tokenString := parts[1]
 
-		// Validate the token
+		// Validate the token. Expiry is normal client behaviour, not a malformed header.
 		claims, err := m.jwtService.ValidateToken(tokenString)
+		if errors.Is(err, ErrTokenRevoked) {
+			http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+			return
+		}
 		if err != nil {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			switch ValidationResult(err) {
+			case "expired":
+				// Not counted as a header error; the client should refresh
+			case "wrong_issuer":
+				m.reportHeaderError(r, HeaderErrorWrongIssuer, err)
+			default:
+				m.reportHeaderError(r, HeaderErrorParseFailure, err)
+			}
+			writeTokenError(w, err)
+			return
+		}
+
+		if err := m.CheckTokenVersion(claims); err != nil {
+			if errors.Is(err, ErrTokenRevoked) {
+				http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if err := m.CheckFingerprint(r, claims); err != nil {
+			writeBindingError(w)
+			return
+		}
+
+		if claims.PasswordExpired && !slices.Contains(m.passwordChangeRoutes, r.URL.Path) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   "password_expired",
+				"message": "Your password has expired and must be changed",
+			})
+			return
+		}
+
+		if err := m.authorize(r, claims); err != nil {
+			if m.logger != nil {
+				m.logger.Error("Failed to resolve access",
+					slog.Int("user_id", claims.UserID),
+					slog.String("error", err.Error()),
+				)
+			}
+			http.Error(w, "Authorization service unavailable", http.StatusServiceUnavailable)
 			return
 		}
 
 		// Add user information to request context
-		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
-		ctx = context.WithValue(ctx, UserEmailKey, claims.Email)
-		ctx = context.WithValue(ctx, UserRolesKey, claims.Roles)
+		ctx := withClaims(r.Context(), claims)
+		ctx = withEffectiveRoles(ctx, m.effectiveRoles(claims.Roles))
 
 		// Call next handler with updated context
 		next(w, r.WithContext(ctx))
 	}
 }
 
+// OptionalAuth populates the request context like RequireAuth when a valid
+// token is presented, but never rejects the request: a missing or invalid
+// token simply leaves it anonymous. Handlers branch on GetUserIDFromContext,
+// which reports false unless the token passed every check RequireAuth makes.
+func (m *Middleware) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := m.optionalClaims(r)
+		if !ok {
+			next(w, r)
+			return
+		}
+		ctx := withClaims(r.Context(), claims)
+		next(w, r.WithContext(withEffectiveRoles(ctx, m.effectiveRoles(claims.Roles))))
+	}
+}
+
+// optionalClaims returns the claims of the request's bearer token, reporting
+// false if there is no token or it would be rejected by RequireAuth
+func (m *Middleware) optionalClaims(r *http.Request) (*Claims, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" || len(authHeader) > len("Bearer ")+m.maxTokenSize {
+		return nil, false
+	}
+
+	tokenString, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok || tokenString == "" || strings.Contains(tokenString, " ") {
+		return nil, false
+	}
+
+	claims, err := m.jwtService.ValidateToken(tokenString)
+	if err != nil {
+		return nil, false
+	}
+	if m.CheckTokenVersion(claims) != nil || m.CheckFingerprint(r, claims) != nil {
+		return nil, false
+	}
+	// A token restricted to changing the password personalizes nothing
+	if claims.PasswordExpired {
+		return nil, false
+	}
+
+	if m.authorize(r, claims) != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// authorize replaces the roles and permissions in claims with the user's
+// current ones, so a demotion takes effect before the token expires
+func (m *Middleware) authorize(r *http.Request, claims *Claims) error {
+	if m.authorizer == nil {
+		return nil
+	}
+	access, err := m.authorizer.Access(r.Context(), claims)
+	if err != nil {
+		return err
+	}
+	claims.Roles = access.Roles
+	claims.Permissions = access.Permissions
+	return nil
+}
+
+// headerFormatError classifies an Authorization header that is not exactly
+// "Bearer <token>", given its space-separated parts
+func headerFormatError(parts []string) string {
+	switch {
+	case parts[0] != "Bearer":
+		return HeaderErrorWrongScheme
+	case strings.TrimSpace(strings.Join(parts[1:], "")) == "":
+		return HeaderErrorEmptyToken
+	default:
+		return HeaderErrorParseFailure
+	}
+}
+
+// writeTokenError responds 401 to a rejected token, telling the client
+// why (see ValidationResult) and whether calling /refresh can recover
+// instead of a full re-login
+func writeTokenError(w http.ResponseWriter, err error) {
+	result := ValidationResult(err)
+	body := map[string]interface{}{"error": "invalid_token", "reason": result, "can_refresh": false}
+	challenge := `Bearer error="invalid_token"`
+	if result == "expired" {
+		body = map[string]interface{}{"error": "token_expired", "can_refresh": true}
+		challenge = `Bearer error="invalid_token", error_description="expired"`
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeBindingError responds 401 to a token presented by a client other
+// than the one it was bound to. Refreshing cannot help; the user must log
+// in again from this client.
+func writeBindingError(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="client mismatch"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":       "token_binding_mismatch",
+		"message":     "This token was issued to a different client; please sign in again",
+		"can_refresh": false,
+	})
+}
+
 // RequireRole ensures the user has a specific role
 func (m *Middleware) RequireRole(role string) func(http.HandlerFunc) http.HandlerFunc {
+	return m.RequireAnyRole(role)
+}
+
+// RequireAnyRole ensures the user has at least one of the specified roles
+func (m *Middleware) RequireAnyRole(allowedRoles ...string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return m.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
-			// Get user roles from context
-			rolesInterface := r.Context().Value(UserRolesKey)
-			roles, ok := rolesInterface.([]string)
-			if !ok {
-				http.Error(w, "Unable to verify user roles", http.StatusInternalServerError)
-				return
-			}
-
-			// Check if user has the required role
-			hasRole := false
-			for _, userRole := range roles {
-				if userRole == role {
-					hasRole = true
-					break
-				}
-			}
-
-			if !hasRole {
+			if !HasAnyRole(r.Context(), allowedRoles...) {
 				http.Error(w, "Insufficient permissions", http.StatusForbidden)
 				return
 			}
 
-			// User has required role, proceed
+			// User has at least one allowed role, proceed
 			next(w, r)
 		})
 	}
 }
 
-// RequireAnyRole ensures the user has at least one of the specified roles
-func (m *Middleware) RequireAnyRole(allowedRoles ...string) func(http.HandlerFunc) http.HandlerFunc {
+// RequirePermission ensures the user has been granted a specific permission.
+// With an authorizer set, the check uses the user's current permissions
+// rather than those granted when the token was issued.
+func (m *Middleware) RequirePermission(permission string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return m.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
-			// Get user roles from context
-			rolesInterface := r.Context().Value(UserRolesKey)
-			roles, ok := rolesInterface.([]string)
-			if !ok {
-				http.Error(w, "Unable to verify user roles", http.StatusInternalServerError)
+			if !HasPermission(r.Context(), permission) {
+				http.Error(w, "Insufficient permissions", http.StatusForbidden)
 				return
 			}
 
-			// Check if user has any of the allowed roles
-			hasRole := false
-			for _, userRole := range roles {
-				for _, allowedRole := range allowedRoles {
-					if userRole == allowedRole {
-						hasRole = true
-						break
-					}
-				}
-				if hasRole {
-					break
-				}
-			}
+			next(w, r)
+		})
+	}
+}
+
+// RequireSameOrg ensures any organization named by the request matches the
+// user's organization. The target organization is read from the X-Org-ID
+// header or the org_id query parameter; requests that name no organization
+// proceed and are expected to be scoped with GetOrgFromContext.
+func (m *Middleware) RequireSameOrg(next http.HandlerFunc) http.HandlerFunc {
+	return m.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		orgID, ok := GetOrgFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Unable to verify user organization", http.StatusInternalServerError)
+			return
+		}
+
+		requested := r.Header.Get("X-Org-ID")
+		if requested == "" {
+			requested = r.URL.Query().Get("org_id")
+		}
 
-			if !hasRole {
+		if requested != "" {
+			requestedID, err := strconv.Atoi(requested)
+			if err != nil {
+				http.Error(w, "Invalid organization ID", http.StatusBadRequest)
+				return
+			}
+			if requestedID != orgID {
 				http.Error(w, "Insufficient permissions", http.StatusForbidden)
 				return
 			}
+		}
 
-			// User has at least one allowed role, proceed
-			next(w, r)
-		})
-	}
+		next(w, r)
+	})
 }
 
-// GetUserIDFromContext extracts the user ID from the request context
-func GetUserIDFromContext(ctx context.Context) (int, bool) {
-	userID, ok := ctx.Value(UserIDKey).(int)
-	return userID, ok
+// HasAnyRole reports whether the authenticated user has, or outranks, at
+// least one of the given roles
+func HasAnyRole(ctx context.Context, roles ...string) bool {
+	userRoles, ok := effectiveRolesFromContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, role := range roles {
+		if slices.Contains(userRoles, role) {
+			return true
+		}
+	}
+	return false
 }
 
-// GetUserEmailFromContext extracts the user email from the request context
-func GetUserEmailFromContext(ctx context.Context) (string, bool) {
-	email, ok := ctx.Value(UserEmailKey).(string)
-	return email, ok
+// HasAllRoles reports whether the authenticated user has, or outranks, every
+// one of the given roles
+func HasAllRoles(ctx context.Context, roles ...string) bool {
+	userRoles, ok := effectiveRolesFromContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, role := range roles {
+		if !slices.Contains(userRoles, role) {
+			return false
+		}
+	}
+	return true
 }
 
-// GetUserRolesFromContext extracts the user roles from the request context
-func GetUserRolesFromContext(ctx context.Context) ([]string, bool) {
-	roles, ok := ctx.Value(UserRolesKey).([]string)
-	return roles, ok
+// HasPermission reports whether the authenticated user has the given permission
+func HasPermission(ctx context.Context, permission string) bool {
+	permissions, ok := GetUserPermissionsFromContext(ctx)
+	if !ok {
+		return false
+	}
+	return slices.Contains(permissions, permission)
 }
 
 // Legacy header-based approach for backward compatibility
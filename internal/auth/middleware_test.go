@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// serveAuth runs RequireAuth on a request with the given Authorization
+// header (omitted when empty) and returns the response
+func serveAuth(m *Middleware, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	m.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})(rec, req)
+	return rec
+}
+
+func TestRequireAuthTokenSize(t *testing.T) {
+	const maxSize = 64
+
+	tests := []struct {
+		name       string
+		tokenSize  int
+		wantStatus int
+	}{
+		// A garbage token within the limit is parsed, and fails as invalid
+		{name: "at limit", tokenSize: maxSize, wantStatus: http.StatusUnauthorized},
+		{name: "one byte over", tokenSize: maxSize + 1, wantStatus: http.StatusRequestHeaderFieldsTooLarge},
+		{name: "far over", tokenSize: 1 << 20, wantStatus: http.StatusRequestHeaderFieldsTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMiddleware(NewJWTService(testSecret, 0, 0))
+			m.SetMaxTokenSize(maxSize)
+
+			rec := serveAuth(m, "Bearer "+strings.Repeat("a", tt.tokenSize))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRequireAuthHeaderErrors(t *testing.T) {
+	otherIssuer := NewJWTService(testSecret, 0, 0)
+	otherIssuer.SetIssuer("other-app", "other-api")
+	foreignToken, err := otherIssuer.GenerateToken(&models.User{ID: 1, Email: "user@example.com", Roles: []string{"user"}})
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	const invalidFormat = "Invalid authorization header format"
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantCause     string
+		wantBody      string
+	}{
+		{name: "missing", wantCause: HeaderErrorMissing, wantBody: "Authorization header required"},
+		{name: "basic scheme", authorization: "Basic dXNlcjpwYXNz", wantCause: HeaderErrorWrongScheme, wantBody: invalidFormat},
+		{name: "lowercase scheme", authorization: "bearer abc.def.ghi", wantCause: HeaderErrorWrongScheme, wantBody: invalidFormat},
+		{name: "scheme only", authorization: "Bearer", wantCause: HeaderErrorEmptyToken, wantBody: invalidFormat},
+		{name: "empty token", authorization: "Bearer ", wantCause: HeaderErrorEmptyToken, wantBody: invalidFormat},
+		{name: "extra parts", authorization: "Bearer abc def", wantCause: HeaderErrorParseFailure, wantBody: invalidFormat},
+		{name: "unparseable token", authorization: "Bearer not-a-jwt", wantCause: HeaderErrorParseFailure, wantBody: "invalid_token"},
+		{name: "foreign issuer", authorization: "Bearer " + foreignToken, wantCause: HeaderErrorWrongIssuer, wantBody: "invalid_token"},
+	}
+
+	causes := []string{
+		HeaderErrorMissing, HeaderErrorTooLarge, HeaderErrorWrongScheme,
+		HeaderErrorEmptyToken, HeaderErrorParseFailure, HeaderErrorWrongIssuer,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			headerErrors := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "auth_header_errors_total"}, []string{"cause"})
+			m := NewMiddleware(NewJWTService(testSecret, 0, 0))
+			m.SetHeaderErrorReporting(slog.New(slog.NewJSONHandler(&logs, nil)), headerErrors)
+
+			rec := serveAuth(m, tt.authorization)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+			if body := rec.Body.String(); !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.wantBody)
+			}
+
+			for _, cause := range causes {
+				want := 0.0
+				if cause == tt.wantCause {
+					want = 1
+				}
+				if got := testutil.ToFloat64(headerErrors.WithLabelValues(cause)); got != want {
+					t.Errorf("auth_header_errors_total{cause=%q} = %v, want %v", cause, got, want)
+				}
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+				t.Fatalf("log entry %q: %v", logs.String(), err)
+			}
+			if entry["cause"] != tt.wantCause {
+				t.Errorf("logged cause = %v, want %q", entry["cause"], tt.wantCause)
+			}
+		})
+	}
+}
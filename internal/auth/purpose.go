@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Types of purpose tokens: short-lived, single-purpose tokens that prove
+// control of an email address. They are never accepted as access tokens.
+const (
+	TokenTypeVerify = "verify"
+	TokenTypeReset  = "reset"
+)
+
+// ErrWrongTokenType is returned when a token of one type is presented where
+// another is required
+var ErrWrongTokenType = errors.New("wrong token type")
+
+// GeneratePurposeToken issues a token of the given type for user, valid for
+// ttl. It carries the user's ID, email and token version, so consumers can
+// reject it once the email changes or the user's sessions are revoked.
+func (j *JWTService) GeneratePurposeToken(user *models.User, tokenType string, ttl time.Duration) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		UserID: user.ID,
+		OrgID:  user.OrgID,
+		Email:  user.Email,
+		Type:   tokenType,
+		// Bumped when sessions are revoked, e.g. by a reset, so used and older
+		// reset tokens stop working
+		TokenVersion: user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    j.issuer,
+			Audience:  jwt.ClaimStrings{j.audience},
+			Subject:   fmt.Sprintf("user_%d", user.ID),
+			ID:        jti,
+		},
+	}
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return tokenString, nil
+}
+
+// ConsumePurposeToken validates a token of the given type and, when a token
+// store is configured, revokes it so it cannot be used again
+func (j *JWTService) ConsumePurposeToken(tokenString, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, j.keyFunc, j.parserOptions()...); err != nil {
+		return nil, j.parseError("failed to parse token", err)
+	}
+	if claims.Type != tokenType {
+		return nil, ErrWrongTokenType
+	}
+	if j.isRevoked(claims) {
+		return nil, ErrTokenRevoked
+	}
+
+	if j.revoked != nil {
+		j.revoked.Revoke(claims.ID, j.RevocationDeadline(claims))
+	}
+	return claims, nil
+}
+
+// requireAccessToken rejects purpose tokens where an access token is expected
+func requireAccessToken(claims *Claims) error {
+	if claims.Type != "" {
+		return fmt.Errorf("%w: %w: %s token", ErrTokenInvalid, ErrWrongTokenType, claims.Type)
+	}
+	return nil
+}
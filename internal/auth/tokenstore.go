@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TokenStore records revoked tokens by their jti claim. Entries only need to
+// be kept until the token would have expired anyway.
+type TokenStore interface {
+	Revoke(jti string, exp time.Time)
+	IsRevoked(jti string) bool
+}
+
+// revokedSweepInterval bounds how often MemoryTokenStore evicts expired entries
+const revokedSweepInterval = time.Minute
+
+// MemoryTokenStore is an in-process TokenStore. Revocations are lost on
+// restart and not shared between instances.
+type MemoryTokenStore struct {
+	mu        sync.Mutex
+	revoked   map[string]time.Time // jti to the token's expiry
+	nextSweep time.Time
+}
+
+// NewMemoryTokenStore creates an empty in-memory token store
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{revoked: make(map[string]time.Time)}
+}
+
+// Revoke denies the token with the given jti until exp. Tokens already past
+// exp are rejected by their expiry, so they are not stored.
+func (s *MemoryTokenStore) Revoke(jti string, exp time.Time) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	if jti == "" || !exp.After(now) {
+		return
+	}
+	s.revoked[jti] = exp
+}
+
+// IsRevoked reports whether the token with the given jti was revoked and
+// has not yet expired
+func (s *MemoryTokenStore) IsRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	exp, ok := s.revoked[jti]
+	return ok && exp.After(now)
+}
+
+// sweep evicts entries past their expiry, at most once per
+// revokedSweepInterval. The caller must hold s.mu.
+func (s *MemoryTokenStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for jti, exp := range s.revoked {
+		if !exp.After(now) {
+			delete(s.revoked, jti)
+		}
+	}
+	s.nextSweep = now.Add(revokedSweepInterval)
+}
+
+// newTokenID returns a random jti for a new token
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -2,36 +2,184 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	JWT      JWTConfig
+	Database      DatabaseConfig
+	Server        ServerConfig
+	JWT           JWTConfig
+	Security      SecurityConfig
+	CORS          CORSConfig
+	PasswordReset PasswordResetConfig
+	Frontend      FrontendConfig
+	Authz         AuthzConfig
+	OAuth         OAuthConfig
+	Products      ProductConfig
+	Webhooks      WebhookConfig
+	RateLimit     RateLimitConfig
 }
 
 // DatabaseConfig holds database connection settings
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
+	Host               string
+	Port               int
+	User               string
+	Password           string
+	DBName             string
+	SSLMode            string        // libpq sslmode; one of SSLModes
+	SSLRootCert        string        // CA certificate file for verifying the server; empty uses the system roots
+	LogQueries         bool          // Log each query at debug level; keep disabled in production
+	SlowQueryThreshold time.Duration // Warn about queries slower than this (0 disables)
+	PoolWarnThreshold  float64       // Warn when in-use connections exceed this fraction of the pool (0 disables)
+	MaxOpenConns       int           // Upper bound on open connections in the pool
+	MaxIdleConns       int           // Idle connections kept for reuse; at most MaxOpenConns
+	ConnMaxLifetime    time.Duration // How long a connection may be reused before it is closed (0 keeps it forever)
+	ConnMaxIdleTime    time.Duration // How long a connection may sit idle before it is closed (0 keeps it forever)
+	RunMigrations      bool          // Apply pending schema migrations on startup
+	MigrationsDir      string        // Read migrations from this directory instead of the embedded ones
 }
 
+// SSLModes are the sslmode values Postgres accepts
+var SSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
 // ServerConfig holds HTTP server settings
 type ServerConfig struct {
-	Port string
+	Port           string
+	MaxHeaderBytes int      // Upper bound on the total size of request headers
+	PrettyJSON     bool     // Honour ?pretty=true on JSON responses (ignored in production)
+	MaxBodyBytes   int64    // Upper bound on the size of a JSON request body
+	JSONMaxDepth   int      // Maximum nesting of objects and arrays in a JSON request body
+	Languages      []string // Languages error messages may be localized to, besides English
+	MaxInFlight    int      // Requests served concurrently before shedding with 503; zero disables the limit
+	FeatureHeaders []string // Feature names accepted as X-Feature-<name> headers for logging and tracing
+
+	ShutdownDrainDelay time.Duration // How long to keep answering 503 after a shutdown signal before exiting
+	ShutdownTimeout    time.Duration // How long in-flight requests get to finish once the server stops accepting connections
+	StatsCacheTTL      time.Duration // How long admin dashboard stats are served from cache; zero disables caching
+	DebugBodyRoutes    []string      // Route patterns whose redacted request and response bodies are logged at debug level
 }
 
 // JWTConfig holds JWT-related settings
 type JWTConfig struct {
-	Secret string
+	Secret       string
+	MaxTokenSize int  // Tokens longer than this are rejected before parsing
+	MonotonicIAT bool // Clamp iat so it never moves backwards across issued tokens
+
+	AccessTokenTTL  time.Duration // How long an issued token is valid
+	RefreshTokenTTL time.Duration // How long after issuance a token may still be refreshed
+	Leeway          time.Duration // Clock skew tolerated when checking exp and nbf
+	Issuer          string        // iss claim set on issued tokens and required on validation
+	Audience        string        // aud claim set on issued tokens and required on validation
+	BindFingerprint bool          // Bind tokens to the client's User-Agent and X-Client-Fingerprint
+	VerifyTokenTTL  time.Duration // How long an email verification token is valid
+}
+
+// SecurityConfig holds transport security settings
+type SecurityConfig struct {
+	EnforceHTTPS   bool          // Reject or redirect plaintext requests (leave off for local development)
+	RedirectHTTP   bool          // Redirect safe requests to HTTPS instead of rejecting them with 400
+	TrustedProxies []string      // IPs or CIDRs of proxies whose X-Forwarded-* headers are honoured
+	BootstrapToken string        // One-time secret for creating the first admin; empty disables bootstrap
+	PasswordPepper string        // Server-side secret HMACed into passwords before bcrypt; empty disables it
+	PasswordMaxAge time.Duration // Passwords older than this must be changed after login; zero disables expiry
+	PasswordHash   string        // Algorithm for new password hashes: "bcrypt" or "argon2id"
+	BcryptCost     int           // bcrypt work factor for new hashes (4-31); 12 or more in production
+}
+
+// CORSConfig holds cross-origin resource sharing settings
+type CORSConfig struct {
+	AllowedOrigins   []string      // Exact origins allowed to call the API; "*" allows any
+	AllowCredentials bool          // Send Access-Control-Allow-Credentials for cookie-based clients
+	UtilityOrigins   []string      // Origins allowed to read utility endpoints such as /metrics; empty sends no CORS headers
+	AllowedMethods   []string      // Methods allowed on cross-origin requests and announced in preflight responses
+	AllowedHeaders   []string      // Request headers cross-origin clients may send
+	MaxAge           time.Duration // How long browsers may cache a preflight response
+}
+
+// PasswordResetConfig holds password reset settings
+type PasswordResetConfig struct {
+	MaxPerEmail    int           // Reset emails sent per address per window (0 disables the limit)
+	MaxPerIP       int           // Reset requests honoured per client IP per window (0 disables the limit)
+	ThrottleWindow time.Duration // Window over which the limits apply
+	TokenTTL       time.Duration // How long a reset token is valid
 }
 
+// RateLimitConfig holds per-IP rate limits for the login and register endpoints
+type RateLimitConfig struct {
+	AuthPerMinute     int  // Requests per client IP per minute (0 disables the limit)
+	AuthBurst         int  // Requests a client IP may make at once before the per-minute rate applies
+	TrustForwardedFor bool // Count requests from trusted proxies against the client in X-Forwarded-For
+}
+
+// FrontendConfig holds settings for the bundled static frontend
+type FrontendConfig struct {
+	CSPEnabled bool   // Send a Content-Security-Policy header with HTML pages
+	CSPPolicy  string // Policy to send; "{nonce}" is replaced per response in nonce mode
+	CSPNonce   bool   // Add a per-response nonce to <script> tags
+
+	AssetMaxAge       time.Duration // Cache lifetime of /css/ and /js/ files
+	FingerprintMaxAge time.Duration // Cache lifetime of assets with a content hash in their name
+	AssetETags        bool          // Send content-hash ETags with assets so revalidation is cheap
+
+	LoginRedirect string // Local path form logins are redirected to with the token cookie; empty answers forms with JSON
+}
+
+// AuthzConfig holds authorization policy settings
+type AuthzConfig struct {
+	RouteRoles    map[string][]string // Route pattern to roles, overriding the defaults in code
+	RoleHierarchy map[string][]string // Role to the lower roles it satisfies; empty requires exact matches
+
+	Provider       string        // Where request roles come from: AuthzProviderDB or AuthzProviderHTTP
+	ServiceURL     string        // External authorization service queried by the http provider
+	ServiceTimeout time.Duration // How long to wait for the authorization service
+	CacheTTL       time.Duration // How long the http provider caches a user's roles
+}
+
+// Role providers selectable with AUTHZ_PROVIDER
+const (
+	AuthzProviderDB   = "db"
+	AuthzProviderHTTP = "http"
+)
+
+// OAuthConfig holds external identity provider settings
+type OAuthConfig struct {
+	GoogleEnabled      bool // Register the Google sign-in routes
+	GoogleClientID     string
+	GoogleClientSecret string
+	GoogleRedirectURL  string // Must match the redirect URI registered with Google
+	SuccessRedirect    string // Frontend URL that receives the token in its fragment
+}
+
+// ProductConfig holds product domain settings
+type ProductConfig struct {
+	ImageDir      string         // Directory product images are stored in
+	MaxImageBytes int64          // Largest accepted product image upload
+	RoleLimits    map[string]int // Most active products a user with the role may own; unlisted roles are unlimited
+}
+
+// WebhookConfig holds settings for inbound provider callbacks
+type WebhookConfig struct {
+	Secrets      map[string]string // Provider name to the secret its callbacks are signed with
+	ReplayWindow time.Duration     // How far a callback's timestamp may be from now before it is rejected
+}
+
+// DefaultCSPPolicy suits the bundled frontend, which uses inline event handlers
+// and styles, Google Fonts, and calls the API on localhost:8080
+const DefaultCSPPolicy = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline' https://fonts.googleapis.com; " +
+	"font-src 'self' https://fonts.gstatic.com; " +
+	"img-src 'self' data:; " +
+	"connect-src 'self' http://localhost:8080; " +
+	"object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
 // Load reads configuration from environment variables
 func Load() (*Config, error) {
 	dbPort, err := strconv.Atoi(getEnv("DB_PORT", "5432"))
@@ -39,19 +187,385 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid DB_PORT: %v", err)
 	}
 
+	maxHeaderBytes, err := strconv.Atoi(getEnv("SERVER_MAX_HEADER_BYTES", "32768"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SERVER_MAX_HEADER_BYTES: %v", err)
+	}
+
+	maxTokenSize, err := strconv.Atoi(getEnv("JWT_MAX_TOKEN_SIZE", "8192"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_MAX_TOKEN_SIZE: %v", err)
+	}
+
+	prettyJSON, err := strconv.ParseBool(getEnv("JSON_PRETTY", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON_PRETTY: %v", err)
+	}
+
+	maxBodyBytes, err := strconv.ParseInt(getEnv("MAX_REQUEST_BODY_BYTES", "1048576"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAX_REQUEST_BODY_BYTES: %v", err)
+	}
+
+	maxInFlight, err := strconv.Atoi(getEnv("MAX_IN_FLIGHT_REQUESTS", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAX_IN_FLIGHT_REQUESTS: %v", err)
+	}
+
+	shutdownDrainDelay, err := time.ParseDuration(getEnv("SHUTDOWN_DRAIN_DELAY", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHUTDOWN_DRAIN_DELAY: %v", err)
+	}
+
+	shutdownTimeout, err := time.ParseDuration(getEnv("SHUTDOWN_TIMEOUT", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHUTDOWN_TIMEOUT: %v", err)
+	}
+
+	statsCacheTTL, err := time.ParseDuration(getEnv("ADMIN_STATS_CACHE_TTL", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ADMIN_STATS_CACHE_TTL: %v", err)
+	}
+
+	jsonMaxDepth, err := strconv.Atoi(getEnv("JSON_MAX_DEPTH", "32"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON_MAX_DEPTH: %v", err)
+	}
+
+	monotonicIAT, err := strconv.ParseBool(getEnv("JWT_MONOTONIC_IAT", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_MONOTONIC_IAT: %v", err)
+	}
+
+	accessTTL, err := time.ParseDuration(getEnv("JWT_ACCESS_TTL", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_ACCESS_TTL: %v", err)
+	}
+
+	refreshTTL, err := time.ParseDuration(getEnv("JWT_REFRESH_TTL", "168h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_REFRESH_TTL: %v", err)
+	}
+
+	bindFingerprint, err := strconv.ParseBool(getEnv("JWT_BIND_FINGERPRINT", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_BIND_FINGERPRINT: %v", err)
+	}
+
+	leeway, err := time.ParseDuration(getEnv("JWT_LEEWAY", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_LEEWAY: %v", err)
+	}
+
+	verifyTokenTTL, err := time.ParseDuration(getEnv("EMAIL_VERIFICATION_TTL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid EMAIL_VERIFICATION_TTL: %v", err)
+	}
+
+	enforceHTTPS, err := strconv.ParseBool(getEnv("ENFORCE_HTTPS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ENFORCE_HTTPS: %v", err)
+	}
+
+	redirectHTTP, err := strconv.ParseBool(getEnv("HTTPS_REDIRECT", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTPS_REDIRECT: %v", err)
+	}
+
+	allowCredentials, err := strconv.ParseBool(getEnv("CORS_ALLOW_CREDENTIALS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CORS_ALLOW_CREDENTIALS: %v", err)
+	}
+
+	allowedOrigins := getEnvList("CORS_ALLOWED_ORIGINS")
+	if len(allowedOrigins) == 0 {
+		allowedOrigins = []string{"*"}
+	}
+
+	allowedMethods := getEnvList("CORS_ALLOWED_METHODS")
+	if len(allowedMethods) == 0 {
+		allowedMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	}
+	for i, method := range allowedMethods {
+		allowedMethods[i] = strings.ToUpper(method)
+	}
+
+	allowedHeaders := getEnvList("CORS_ALLOWED_HEADERS")
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = []string{"Content-Type", "Authorization"}
+	}
+
+	corsMaxAge, err := time.ParseDuration(getEnv("CORS_MAX_AGE", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CORS_MAX_AGE: %v", err)
+	}
+
+	resetMaxPerEmail, err := strconv.Atoi(getEnv("RESET_MAX_PER_EMAIL", "3"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESET_MAX_PER_EMAIL: %v", err)
+	}
+
+	resetMaxPerIP, err := strconv.Atoi(getEnv("RESET_MAX_PER_IP", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESET_MAX_PER_IP: %v", err)
+	}
+
+	resetWindow, err := time.ParseDuration(getEnv("RESET_THROTTLE_WINDOW", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESET_THROTTLE_WINDOW: %v", err)
+	}
+
+	resetTokenTTL, err := time.ParseDuration(getEnv("RESET_TOKEN_TTL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESET_TOKEN_TTL: %v", err)
+	}
+
+	authRatePerMinute, err := strconv.Atoi(getEnv("AUTH_RATE_LIMIT_PER_MINUTE", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTH_RATE_LIMIT_PER_MINUTE: %v", err)
+	}
+
+	authRateBurst, err := strconv.Atoi(getEnv("AUTH_RATE_LIMIT_BURST", "5"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTH_RATE_LIMIT_BURST: %v", err)
+	}
+
+	trustForwardedFor, err := strconv.ParseBool(getEnv("RATE_LIMIT_TRUST_FORWARDED_FOR", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_TRUST_FORWARDED_FOR: %v", err)
+	}
+
+	logQueries, err := strconv.ParseBool(getEnv("DB_LOG_QUERIES", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_LOG_QUERIES: %v", err)
+	}
+
+	slowQueryThreshold, err := time.ParseDuration(getEnv("DB_SLOW_QUERY_THRESHOLD", "2s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_SLOW_QUERY_THRESHOLD: %v", err)
+	}
+
+	poolWarnThreshold, err := strconv.ParseFloat(getEnv("DB_POOL_WARN_THRESHOLD", "0.8"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_POOL_WARN_THRESHOLD: %v", err)
+	}
+
+	maxOpenConns, err := strconv.Atoi(getEnv("DB_MAX_OPEN_CONNS", "25"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_MAX_OPEN_CONNS: %v", err)
+	}
+
+	maxIdleConns, err := strconv.Atoi(getEnv("DB_MAX_IDLE_CONNS", "25"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_MAX_IDLE_CONNS: %v", err)
+	}
+
+	connMaxLifetime, err := time.ParseDuration(getEnv("DB_CONN_MAX_LIFETIME", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_CONN_MAX_LIFETIME: %v", err)
+	}
+
+	connMaxIdleTime, err := time.ParseDuration(getEnv("DB_CONN_MAX_IDLE_TIME", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_CONN_MAX_IDLE_TIME: %v", err)
+	}
+
+	runMigrations, err := strconv.ParseBool(getEnv("RUN_MIGRATIONS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RUN_MIGRATIONS: %v", err)
+	}
+
+	cspEnabled, err := strconv.ParseBool(getEnv("CSP_ENABLED", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSP_ENABLED: %v", err)
+	}
+
+	cspNonce, err := strconv.ParseBool(getEnv("CSP_NONCE", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSP_NONCE: %v", err)
+	}
+
+	passwordMaxAge, err := time.ParseDuration(getEnv("PASSWORD_MAX_AGE", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PASSWORD_MAX_AGE: %v", err)
+	}
+
+	bcryptCost, err := strconv.Atoi(getEnv("BCRYPT_COST", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BCRYPT_COST: %v", err)
+	}
+
+	assetMaxAge, err := time.ParseDuration(getEnv("STATIC_MAX_AGE", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STATIC_MAX_AGE: %v", err)
+	}
+
+	fingerprintMaxAge, err := time.ParseDuration(getEnv("STATIC_FINGERPRINT_MAX_AGE", "8760h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STATIC_FINGERPRINT_MAX_AGE: %v", err)
+	}
+
+	assetETags, err := strconv.ParseBool(getEnv("STATIC_ETAG", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STATIC_ETAG: %v", err)
+	}
+
+	routeRoles, err := parseRouteRoles(getEnvList("ROUTE_ROLES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ROUTE_ROLES: %v", err)
+	}
+
+	roleHierarchy, err := parseRoleHierarchy(getEnvList("ROLE_HIERARCHY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ROLE_HIERARCHY: %v", err)
+	}
+
+	authzTimeout, err := time.ParseDuration(getEnv("AUTHZ_SERVICE_TIMEOUT", "2s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTHZ_SERVICE_TIMEOUT: %v", err)
+	}
+
+	authzCacheTTL, err := time.ParseDuration(getEnv("AUTHZ_CACHE_TTL", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTHZ_CACHE_TTL: %v", err)
+	}
+
+	googleEnabled, err := strconv.ParseBool(getEnv("OAUTH_GOOGLE_ENABLED", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAUTH_GOOGLE_ENABLED: %v", err)
+	}
+
+	productLimits, err := parseRoleLimits(getEnvList("PRODUCT_LIMITS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PRODUCT_LIMITS: %v", err)
+	}
+
+	webhookSecrets, err := parseWebhookSecrets(getEnvList("WEBHOOK_SECRETS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_SECRETS: %v", err)
+	}
+
+	webhookReplayWindow, err := time.ParseDuration(getEnv("WEBHOOK_REPLAY_WINDOW", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_REPLAY_WINDOW: %v", err)
+	}
+
+	maxImageBytes, err := strconv.ParseInt(getEnv("PRODUCT_IMAGE_MAX_BYTES", "5242880"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PRODUCT_IMAGE_MAX_BYTES: %v", err)
+	}
+
 	cfg := &Config{
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     dbPort,
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "auth_app"),
+			Host:               getEnv("DB_HOST", "localhost"),
+			Port:               dbPort,
+			User:               getEnv("DB_USER", "postgres"),
+			Password:           getEnv("DB_PASSWORD", ""),
+			DBName:             getEnv("DB_NAME", "auth_app"),
+			SSLMode:            getEnv("DB_SSLMODE", "disable"),
+			SSLRootCert:        getEnv("DB_SSLROOTCERT", ""),
+			LogQueries:         logQueries,
+			SlowQueryThreshold: slowQueryThreshold,
+			PoolWarnThreshold:  poolWarnThreshold,
+			MaxOpenConns:       maxOpenConns,
+			MaxIdleConns:       maxIdleConns,
+			ConnMaxLifetime:    connMaxLifetime,
+			ConnMaxIdleTime:    connMaxIdleTime,
+			RunMigrations:      runMigrations,
+			MigrationsDir:      getEnv("MIGRATIONS_DIR", ""),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port:           getEnv("SERVER_PORT", "8080"),
+			MaxHeaderBytes: maxHeaderBytes,
+			PrettyJSON:     prettyJSON,
+			MaxBodyBytes:   maxBodyBytes,
+			JSONMaxDepth:   jsonMaxDepth,
+			Languages:      getEnvList("SUPPORTED_LANGUAGES"),
+			MaxInFlight:    maxInFlight,
+			FeatureHeaders: getEnvList("FEATURE_HEADERS"),
+
+			ShutdownDrainDelay: shutdownDrainDelay,
+			ShutdownTimeout:    shutdownTimeout,
+			StatsCacheTTL:      statsCacheTTL,
+			DebugBodyRoutes:    getEnvList("DEBUG_BODY_ROUTES"),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", ""),
+			Secret:       getEnv("JWT_SECRET", ""),
+			MaxTokenSize: maxTokenSize,
+			MonotonicIAT: monotonicIAT,
+
+			AccessTokenTTL:  accessTTL,
+			RefreshTokenTTL: refreshTTL,
+			Leeway:          leeway,
+			Issuer:          getEnv("JWT_ISSUER", "auth-app"),
+			Audience:        getEnv("JWT_AUDIENCE", "auth-app"),
+			BindFingerprint: bindFingerprint,
+			VerifyTokenTTL:  verifyTokenTTL,
+		},
+		Security: SecurityConfig{
+			EnforceHTTPS:   enforceHTTPS,
+			RedirectHTTP:   redirectHTTP,
+			TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+			BootstrapToken: getEnv("BOOTSTRAP_TOKEN", ""),
+			PasswordPepper: getEnv("PASSWORD_PEPPER", ""),
+			PasswordMaxAge: passwordMaxAge,
+			PasswordHash:   getEnv("PASSWORD_HASH_ALGORITHM", "bcrypt"),
+			BcryptCost:     bcryptCost,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   allowedOrigins,
+			AllowCredentials: allowCredentials,
+			UtilityOrigins:   getEnvList("CORS_UTILITY_ORIGINS"),
+			AllowedMethods:   allowedMethods,
+			AllowedHeaders:   allowedHeaders,
+			MaxAge:           corsMaxAge,
+		},
+		PasswordReset: PasswordResetConfig{
+			MaxPerEmail:    resetMaxPerEmail,
+			MaxPerIP:       resetMaxPerIP,
+			ThrottleWindow: resetWindow,
+			TokenTTL:       resetTokenTTL,
+		},
+		RateLimit: RateLimitConfig{
+			AuthPerMinute:     authRatePerMinute,
+			AuthBurst:         authRateBurst,
+			TrustForwardedFor: trustForwardedFor,
+		},
+		Frontend: FrontendConfig{
+			CSPEnabled: cspEnabled,
+			CSPPolicy:  getEnv("CSP_POLICY", DefaultCSPPolicy),
+			CSPNonce:   cspNonce,
+
+			AssetMaxAge:       assetMaxAge,
+			FingerprintMaxAge: fingerprintMaxAge,
+			AssetETags:        assetETags,
+
+			LoginRedirect: getEnv("LOGIN_FORM_REDIRECT", ""),
+		},
+		Authz: AuthzConfig{
+			RouteRoles:    routeRoles,
+			RoleHierarchy: roleHierarchy,
+
+			Provider:       getEnv("AUTHZ_PROVIDER", AuthzProviderDB),
+			ServiceURL:     getEnv("AUTHZ_SERVICE_URL", ""),
+			ServiceTimeout: authzTimeout,
+			CacheTTL:       authzCacheTTL,
+		},
+		OAuth: OAuthConfig{
+			GoogleEnabled:      googleEnabled,
+			GoogleClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
+			GoogleClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
+			GoogleRedirectURL:  getEnv("GOOGLE_REDIRECT_URL", "http://localhost:8080/auth/google/callback"),
+			SuccessRedirect:    getEnv("OAUTH_SUCCESS_REDIRECT", "/"),
+		},
+		Products: ProductConfig{
+			ImageDir:      getEnv("PRODUCT_IMAGE_DIR", "uploads/products"),
+			MaxImageBytes: maxImageBytes,
+			RoleLimits:    productLimits,
+		},
+		Webhooks: WebhookConfig{
+			Secrets:      webhookSecrets,
+			ReplayWindow: webhookReplayWindow,
 		},
 	}
 
@@ -71,9 +585,242 @@ func (c *Config) Validate() error {
 	if c.JWT.Secret == "" {
 		return fmt.Errorf("JWT_SECRET is required")
 	}
+	if c.JWT.MaxTokenSize <= 0 {
+		return fmt.Errorf("JWT_MAX_TOKEN_SIZE must be positive")
+	}
+	if c.Server.MaxHeaderBytes < c.JWT.MaxTokenSize {
+		return fmt.Errorf("SERVER_MAX_HEADER_BYTES must be at least JWT_MAX_TOKEN_SIZE")
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		return fmt.Errorf("MAX_REQUEST_BODY_BYTES must be positive")
+	}
+	if c.Server.JSONMaxDepth <= 0 {
+		return fmt.Errorf("JSON_MAX_DEPTH must be positive")
+	}
+	if c.Server.ShutdownDrainDelay < 0 {
+		return fmt.Errorf("SHUTDOWN_DRAIN_DELAY cannot be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		return fmt.Errorf("SHUTDOWN_TIMEOUT must be positive")
+	}
+	if c.Server.StatsCacheTTL < 0 {
+		return fmt.Errorf("ADMIN_STATS_CACHE_TTL cannot be negative")
+	}
+	if c.Server.MaxInFlight < 0 {
+		return fmt.Errorf("MAX_IN_FLIGHT_REQUESTS cannot be negative")
+	}
+	if c.Products.MaxImageBytes <= 0 {
+		return fmt.Errorf("PRODUCT_IMAGE_MAX_BYTES must be positive")
+	}
+	if c.CORS.AllowCredentials {
+		for _, origin := range c.CORS.AllowedOrigins {
+			if origin == "*" {
+				return fmt.Errorf("CORS_ALLOWED_ORIGINS cannot contain \"*\" when CORS_ALLOW_CREDENTIALS is enabled")
+			}
+		}
+	}
+	if c.Security.BcryptCost < 4 || c.Security.BcryptCost > 31 {
+		return fmt.Errorf("BCRYPT_COST must be between 4 and 31")
+	}
+	if c.CORS.MaxAge < 0 {
+		return fmt.Errorf("CORS_MAX_AGE cannot be negative")
+	}
+	if c.Database.PoolWarnThreshold < 0 || c.Database.PoolWarnThreshold > 1 {
+		return fmt.Errorf("DB_POOL_WARN_THRESHOLD must be between 0 and 1")
+	}
+	if !slices.Contains(SSLModes, c.Database.SSLMode) {
+		return fmt.Errorf("DB_SSLMODE must be one of %s", strings.Join(SSLModes, ", "))
+	}
+	if c.Database.SSLRootCert != "" && c.Database.SSLMode == "disable" {
+		return fmt.Errorf("DB_SSLROOTCERT requires DB_SSLMODE other than disable")
+	}
+	if c.Database.MaxOpenConns <= 0 {
+		return fmt.Errorf("DB_MAX_OPEN_CONNS must be positive")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		return fmt.Errorf("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS")
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		return fmt.Errorf("DB_CONN_MAX_LIFETIME cannot be negative")
+	}
+	if c.Database.ConnMaxIdleTime < 0 {
+		return fmt.Errorf("DB_CONN_MAX_IDLE_TIME cannot be negative")
+	}
+	if c.PasswordReset.ThrottleWindow <= 0 {
+		return fmt.Errorf("RESET_THROTTLE_WINDOW must be positive")
+	}
+	if c.PasswordReset.TokenTTL <= 0 {
+		return fmt.Errorf("RESET_TOKEN_TTL must be positive")
+	}
+	if c.RateLimit.AuthPerMinute < 0 {
+		return fmt.Errorf("AUTH_RATE_LIMIT_PER_MINUTE cannot be negative")
+	}
+	if c.RateLimit.AuthPerMinute > 0 && c.RateLimit.AuthBurst < 1 {
+		return fmt.Errorf("AUTH_RATE_LIMIT_BURST must be at least 1")
+	}
+	if c.RateLimit.TrustForwardedFor && len(c.Security.TrustedProxies) == 0 {
+		return fmt.Errorf("RATE_LIMIT_TRUST_FORWARDED_FOR requires TRUSTED_PROXIES")
+	}
+	if c.OAuth.GoogleEnabled && (c.OAuth.GoogleClientID == "" || c.OAuth.GoogleClientSecret == "") {
+		return fmt.Errorf("GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET are required when OAUTH_GOOGLE_ENABLED is set")
+	}
+	if c.Security.PasswordPepper != "" && len(c.Security.PasswordPepper) < 32 {
+		return fmt.Errorf("PASSWORD_PEPPER must be at least 32 characters")
+	}
+	if c.Security.BootstrapToken != "" && len(c.Security.BootstrapToken) < 32 {
+		return fmt.Errorf("BOOTSTRAP_TOKEN must be at least 32 characters")
+	}
+	for _, proxy := range c.Security.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("TRUSTED_PROXIES contains invalid IP or CIDR %q", proxy)
+		}
+	}
+	if c.Frontend.AssetMaxAge < 0 || c.Frontend.FingerprintMaxAge < 0 {
+		return fmt.Errorf("STATIC_MAX_AGE and STATIC_FINGERPRINT_MAX_AGE cannot be negative")
+	}
+
+	if c.Security.PasswordMaxAge < 0 {
+		return fmt.Errorf("PASSWORD_MAX_AGE cannot be negative")
+	}
+
+	if c.JWT.AccessTokenTTL <= 0 || c.JWT.RefreshTokenTTL <= 0 {
+		return fmt.Errorf("JWT_ACCESS_TTL and JWT_REFRESH_TTL must be positive")
+	}
+	if c.JWT.RefreshTokenTTL < c.JWT.AccessTokenTTL {
+		return fmt.Errorf("JWT_REFRESH_TTL cannot be shorter than JWT_ACCESS_TTL")
+	}
+	if c.JWT.Leeway < 0 || c.JWT.Leeway > time.Minute {
+		return fmt.Errorf("JWT_LEEWAY must be between 0s and 1m")
+	}
+	if c.JWT.VerifyTokenTTL <= 0 {
+		return fmt.Errorf("EMAIL_VERIFICATION_TTL must be positive")
+	}
+
+	if c.Webhooks.ReplayWindow <= 0 {
+		return fmt.Errorf("WEBHOOK_REPLAY_WINDOW must be positive")
+	}
+
+	// Only local paths, so the login endpoint cannot become an open redirect
+	if r := c.Frontend.LoginRedirect; r != "" && (!strings.HasPrefix(r, "/") || strings.HasPrefix(r, "//")) {
+		return fmt.Errorf("LOGIN_FORM_REDIRECT must be a local path starting with /")
+	}
+
+	switch c.Authz.Provider {
+	case AuthzProviderDB:
+	case AuthzProviderHTTP:
+		if c.Authz.ServiceURL == "" {
+			return fmt.Errorf("AUTHZ_SERVICE_URL is required when AUTHZ_PROVIDER is http")
+		}
+		if c.Authz.ServiceTimeout <= 0 {
+			return fmt.Errorf("AUTHZ_SERVICE_TIMEOUT must be positive")
+		}
+		if c.Authz.CacheTTL < 0 {
+			return fmt.Errorf("AUTHZ_CACHE_TTL cannot be negative")
+		}
+	default:
+		return fmt.Errorf("AUTHZ_PROVIDER must be %q or %q", AuthzProviderDB, AuthzProviderHTTP)
+	}
+
 	return nil
 }
 
+// parseRouteRoles parses entries of the form "/route=role1|role2"
+func parseRouteRoles(entries []string) (map[string][]string, error) {
+	routeRoles := make(map[string][]string)
+	for _, entry := range entries {
+		route, roleList, ok := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !ok || !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("entry %q must have the form /route=role1|role2", entry)
+		}
+		if _, dup := routeRoles[route]; dup {
+			return nil, fmt.Errorf("route %q is listed more than once", route)
+		}
+
+		var roles []string
+		for _, role := range strings.Split(roleList, "|") {
+			if role = strings.TrimSpace(role); role != "" {
+				roles = append(roles, role)
+			}
+		}
+		if len(roles) == 0 {
+			return nil, fmt.Errorf("route %q has no roles", route)
+		}
+		routeRoles[route] = roles
+	}
+	return routeRoles, nil
+}
+
+// parseRoleHierarchy parses entries of the form "role=lower1|lower2"
+func parseRoleHierarchy(entries []string) (map[string][]string, error) {
+	hierarchy := make(map[string][]string)
+	for _, entry := range entries {
+		role, lowerList, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("entry %q must have the form role=lower1|lower2", entry)
+		}
+		if _, dup := hierarchy[role]; dup {
+			return nil, fmt.Errorf("role %q is listed more than once", role)
+		}
+
+		var lower []string
+		for _, r := range strings.Split(lowerList, "|") {
+			if r = strings.TrimSpace(r); r == "" {
+				continue
+			}
+			if r == role {
+				return nil, fmt.Errorf("role %q cannot outrank itself", role)
+			}
+			lower = append(lower, r)
+		}
+		if len(lower) == 0 {
+			return nil, fmt.Errorf("role %q has no lower roles", role)
+		}
+		hierarchy[role] = lower
+	}
+	return hierarchy, nil
+}
+
+// parseRoleLimits parses role=limit entries, e.g. user=50
+func parseRoleLimits(entries []string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, entry := range entries {
+		role, value, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("entry %q must have the form role=limit", entry)
+		}
+		if _, dup := limits[role]; dup {
+			return nil, fmt.Errorf("role %q is listed more than once", role)
+		}
+
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("role %q must have a non-negative integer limit", role)
+		}
+		limits[role] = limit
+	}
+	return limits, nil
+}
+
+// parseWebhookSecrets parses provider=secret entries, e.g. mailer=s3cr3t
+func parseWebhookSecrets(entries []string) (map[string]string, error) {
+	secrets := make(map[string]string)
+	for _, entry := range entries {
+		provider, secret, ok := strings.Cut(entry, "=")
+		provider = strings.TrimSpace(provider)
+		if !ok || provider == "" || strings.TrimSpace(secret) == "" {
+			return nil, fmt.Errorf("entry for %q must have the form provider=secret", provider)
+		}
+		if _, dup := secrets[provider]; dup {
+			return nil, fmt.Errorf("provider %q is listed more than once", provider)
+		}
+		secrets[provider] = strings.TrimSpace(secret)
+	}
+	return secrets, nil
+}
+
 // getEnv retrieves environment variable with default fallback
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -81,3 +828,15 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList retrieves a comma-separated environment variable as a slice,
+// dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -15,9 +15,12 @@ import (
 func NewConnection(cfg config.DatabaseConfig) (*sql.DB, error) {
 	// Construct the connection string
 	connStr := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName,
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
 	)
+	if cfg.SSLRootCert != "" {
+		connStr += " sslrootcert=" + cfg.SSLRootCert
+	}
 
 	// Open connection
 	db, err := sql.Open("pgx", connStr)
@@ -25,10 +28,11 @@ func NewConnection(cfg config.DatabaseConfig) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
 
-	// Configure connection pool for production use
-	db.SetMaxOpenConns(25)                 // Maximum number of open connections
-	db.SetMaxIdleConns(25)                 // Maximum number of idle connections
-	db.SetConnMaxLifetime(5 * time.Minute) // Maximum amount of time a connection may be reused
+	// Configure the connection pool; see DatabaseConfig for the defaults
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
@@ -40,7 +44,7 @@ func NewConnection(cfg config.DatabaseConfig) (*sql.DB, error) {
 }
 
 // HealthCheck verifies database connectivity
-func HealthCheck(db *sql.DB) error {
+func HealthCheck(db DB) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 	
@@ -0,0 +1,19 @@
+package database
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ConstraintViolation reports the name of the constraint err violated, for
+// integrity constraint violations (SQLSTATE class 23) such as unique, check,
+// and foreign key violations
+func ConstraintViolation(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || !strings.HasPrefix(pgErr.Code, "23") || pgErr.ConstraintName == "" {
+		return "", false
+	}
+	return pgErr.ConstraintName, true
+}
@@ -0,0 +1,44 @@
+// Package dbtest provides a mocked database for tests of code that takes a
+// database.DB.
+package dbtest
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/monitoring"
+)
+
+// New returns an instrumented database backed by sqlmock and the mock to set
+// expectations on. Queries are matched by regular expression, and the test
+// fails if any expectation is left unmet when it ends.
+func New(t testing.TB) (*database.InstrumentedDB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet database expectations: %v", err)
+		}
+		db.Close()
+	})
+
+	return database.NewInstrumentedDB(db, Metrics(t)), mock
+}
+
+// Metrics returns metrics registered in the default registry. Every call
+// shares the same collectors, so tests may call it freely.
+func Metrics(t testing.TB) *monitoring.Metrics {
+	t.Helper()
+
+	monitor, err := monitoring.NewMonitor(monitoring.Config{EnableMetrics: true})
+	if err != nil {
+		t.Fatalf("failed to create monitor: %v", err)
+	}
+	return monitor.Metrics
+}
@@ -3,14 +3,21 @@ package database
 import (
 	"context"
 	"database/sql"
+	"log/slog"
 	"time"
 
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/monitoring"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 type InstrumentedDB struct {
 	*sql.DB
 	metrics *monitoring.Metrics
+	logger  *slog.Logger
+
+	slowLogger    *slog.Logger
+	slowThreshold time.Duration
 }
 
 func NewInstrumentedDB(db *sql.DB, metrics *monitoring.Metrics) *InstrumentedDB {
@@ -20,17 +27,33 @@ func NewInstrumentedDB(db *sql.DB, metrics *monitoring.Metrics) *InstrumentedDB
 	}
 }
 
-func (idb *InstrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	start := time.Now()
-	defer func() {
-		duration := time.Since(start)
-		idb.metrics.DBQueryDuration.WithLabelValues("query_row").Observe(duration.Seconds())
-		idb.metrics.DBQueriesTotal.WithLabelValues("query_row", "success").Inc()
-	}()
-	return idb.DB.QueryRowContext(ctx, query, args...)
+// EnableQueryLogging logs every query, its redacted arguments, and its
+// duration at debug level. It is meant for development and costs nothing
+// when the logger's level is above debug.
+func (idb *InstrumentedDB) EnableQueryLogging(logger *slog.Logger) {
+	idb.logger = logger
+}
+
+// EnableSlowQueryLog logs a warning for every query that takes longer than
+// threshold, so slow call sites can be found from the logs. Only the query
+// text is logged, never its arguments. A non-positive threshold disables it.
+func (idb *InstrumentedDB) EnableSlowQueryLog(logger *slog.Logger, threshold time.Duration) {
+	if threshold <= 0 {
+		return
+	}
+	idb.slowLogger = logger
+	idb.slowThreshold = threshold
+}
+
+// QueryRowContext runs a single-row query. Its status is recorded when the
+// returned Row is scanned, since that is when any error surfaces.
+func (idb *InstrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	return idb.queryRow(ctx, "query_row", query, args, func() *sql.Row {
+		return idb.DB.QueryRowContext(ctx, query, args...)
+	})
 }
 
-func (idb *InstrumentedDB) QueryRow(query string, args ...interface{}) *sql.Row {
+func (idb *InstrumentedDB) QueryRow(query string, args ...interface{}) *Row {
 	return idb.QueryRowContext(context.Background(), query, args...)
 }
 
@@ -50,6 +73,8 @@ func (idb *InstrumentedDB) QueryContext(ctx context.Context, query string, args
 		status = "error"
 	}
 	idb.metrics.DBQueriesTotal.WithLabelValues("query", status).Inc()
+	idb.logQuery(ctx, "query", query, args, duration, err)
+	idb.logSlowQuery(ctx, "query", query, duration, err)
 	
 	return rows, err
 }
@@ -70,6 +95,54 @@ func (idb *InstrumentedDB) ExecContext(ctx context.Context, query string, args .
 		status = "error"
 	}
 	idb.metrics.DBQueriesTotal.WithLabelValues("exec", status).Inc()
+	idb.logQuery(ctx, "exec", query, args, duration, err)
+	idb.logSlowQuery(ctx, "exec", query, duration, err)
 	
 	return result, err
 }
+
+// RecordRetry counts a retry of a transient failure for the given operation
+func (idb *InstrumentedDB) RecordRetry(operation string) {
+	idb.metrics.DBRetriesTotal.WithLabelValues(operation).Inc()
+}
+
+// logSlowQuery warns about a query that exceeded the slow query threshold
+func (idb *InstrumentedDB) logSlowQuery(ctx context.Context, operation, query string, duration time.Duration, err error) {
+	if idb.slowLogger == nil || duration < idb.slowThreshold {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", operation),
+		slog.String("query", compactQuery(query)),
+		slog.Duration("duration", duration),
+		slog.Duration("threshold", idb.slowThreshold),
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		attrs = append(attrs, slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	idb.slowLogger.LogAttrs(ctx, slog.LevelWarn, "Slow database query", attrs...)
+}
+
+// logQuery writes a debug log line for a query when query logging is enabled
+func (idb *InstrumentedDB) logQuery(ctx context.Context, operation, query string, args []interface{}, duration time.Duration, err error) {
+	if idb.logger == nil || !idb.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", operation),
+		slog.String("query", compactQuery(query)),
+		slog.Any("args", redactArgs(query, args)),
+		slog.Duration("duration", duration),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	idb.logger.LogAttrs(ctx, slog.LevelDebug, "Database query", attrs...)
+}
@@ -7,17 +7,19 @@ import (
 
 // DB defines the interface for database operations
 type DB interface {
-	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
-	QueryRow(query string, args ...interface{}) *sql.Row
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row
+	QueryRow(query string, args ...interface{}) *Row
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
-	Begin() (*sql.Tx, error)
+	Begin() (*Tx, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error)
 	Stats() sql.DBStats
 	Ping() error
+	PingContext(ctx context.Context) error
 	Close() error
 }
 
-// Ensure *sql.DB implements our DB interface at compile time
-var _ DB = (*sql.DB)(nil)
+// Ensure InstrumentedDB implements our DB interface at compile time
+var _ DB = (*InstrumentedDB)(nil)
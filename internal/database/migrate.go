@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// embeddedMigrations holds the schema shipped with the binary
+//
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// migrationLockID is the advisory lock key held while migrating, so
+// instances starting together apply each migration once
+const migrationLockID = 7340291
+
+// ErrUnversionedSchema is returned when the database already has the
+// application's tables but no schema_migrations history, e.g. one
+// bootstrapped by docker-entrypoint-initdb.d. Re-running the initial
+// migration would drop those tables, so nothing is applied; record the
+// migrations the database already has in schema_migrations first.
+var ErrUnversionedSchema = errors.New("database has tables but no migration history")
+
+// Migrate applies, in file name order, every .sql migration not yet recorded
+// in the schema_migrations table. An empty dir uses the migrations embedded
+// in the binary; otherwise the files are read from dir on disk. Each
+// migration runs in its own transaction together with its version record,
+// so a failure leaves the database at the last fully applied migration.
+func Migrate(db *sql.DB, dir string) error {
+	ctx := context.Background()
+
+	var migrations fs.FS = embeddedMigrations
+	root := "migrations"
+	if dir != "" {
+		migrations = os.DirFS(dir)
+		root = "."
+	}
+	files, err := fs.Glob(migrations, path.Join(root, "*.sql"))
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	sort.Strings(files)
+
+	// Advisory locks belong to a session, so hold one connection throughout
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		version := strings.TrimSuffix(path.Base(file), ".sql")
+		if applied[version] {
+			continue
+		}
+
+		script, err := fs.ReadFile(migrations, file)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", version, err)
+		}
+		if err := applyMigration(ctx, conn, version, string(script)); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// appliedMigrations creates the schema_migrations table if needed and
+// returns the versions it records
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[string]bool, error) {
+	var tracked, populated bool
+	err := conn.QueryRowContext(ctx, `
+		SELECT to_regclass('schema_migrations') IS NOT NULL,
+		       to_regclass('users') IS NOT NULL`).Scan(&tracked, &populated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	if !tracked && populated {
+		return nil, ErrUnversionedSchema
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration runs one migration script and records its version atomically
+func applyMigration(ctx context.Context, conn *sql.Conn, version, script string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Scripts hold several statements, which only run without arguments
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
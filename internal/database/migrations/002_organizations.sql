@@ -0,0 +1,30 @@
+-- Migration: 002_organizations.sql
+-- Description: Organizations for tenant scoping of users and products
+-- Created: 2026-10-17
+
+-- Create organizations table for multi-tenancy
+CREATE TABLE organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Insert the default organization so single-tenant deployments keep working
+INSERT INTO organizations (id, name) VALUES (1, 'default');
+SELECT setval('organizations_id_seq', (SELECT MAX(id) FROM organizations));
+
+-- Scope users and products to an organization (existing rows join the default org)
+ALTER TABLE users ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE products ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+
+-- Create indexes for tenant-scoped queries
+CREATE INDEX idx_users_org_id ON users(org_id);
+CREATE INDEX idx_products_org_id ON products(org_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE organizations IS 'Tenants that own users and products';
+COMMENT ON COLUMN users.org_id IS 'Organization the user belongs to';
+COMMENT ON COLUMN products.org_id IS 'Organization the product belongs to';
+
+-- Migration completed successfully
+SELECT 'Migration 002_organizations.sql completed successfully' as result;
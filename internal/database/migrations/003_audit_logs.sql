@@ -0,0 +1,30 @@
+-- Migration: 003_audit_logs.sql
+-- Description: Audit trail of security-relevant actions
+-- Created: 2026-10-17
+
+-- Create audit_logs table for investigations
+CREATE TABLE audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    actor_email VARCHAR(255),
+    action VARCHAR(100) NOT NULL,
+    target VARCHAR(255),
+    details JSONB,
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for the filters exposed by GET /admin/audit
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at DESC);
+CREATE INDEX idx_audit_logs_actor_id ON audit_logs(actor_id, created_at DESC);
+CREATE INDEX idx_audit_logs_actor_email ON audit_logs(actor_email, created_at DESC);
+CREATE INDEX idx_audit_logs_action ON audit_logs(action, created_at DESC);
+CREATE INDEX idx_audit_logs_target ON audit_logs(target, created_at DESC);
+
+-- Add comments for documentation
+COMMENT ON TABLE audit_logs IS 'Append-only record of security-relevant actions';
+COMMENT ON COLUMN audit_logs.actor_email IS 'Email of the actor at the time of the action';
+COMMENT ON COLUMN audit_logs.target IS 'Resource acted upon, e.g. user:42';
+
+-- Migration completed successfully
+SELECT 'Migration 003_audit_logs.sql completed successfully' as result;
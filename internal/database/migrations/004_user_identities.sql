@@ -0,0 +1,25 @@
+-- Migration: 004_user_identities.sql
+-- Description: External identity provider links for OAuth sign-in
+-- Created: 2026-10-17
+
+-- Create user_identities table linking provider accounts to users
+CREATE TABLE user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_login TIMESTAMP WITH TIME ZONE,
+    UNIQUE (provider, subject)
+);
+
+-- Create index for looking up a user's linked identities
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE user_identities IS 'Accounts at external identity providers linked to users';
+COMMENT ON COLUMN user_identities.subject IS 'Stable user identifier issued by the provider (OIDC sub claim)';
+
+-- Migration completed successfully
+SELECT 'Migration 004_user_identities.sql completed successfully' as result;
@@ -0,0 +1,35 @@
+-- Migration: 005_product_categories.sql
+-- Description: Product categories for browsing and filtering
+-- Created: 2026-10-17
+
+-- Create categories table holding the allowed category values
+CREATE TABLE categories (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Insert default categories
+INSERT INTO categories (name, description) VALUES
+('computers', 'Laptops, desktops and components'),
+('peripherals', 'Keyboards, mice, webcams and other accessories'),
+('displays', 'Monitors and projectors'),
+('other', 'Anything that does not fit another category');
+
+-- Categorize products; uncategorized products keep NULL
+ALTER TABLE products ADD COLUMN category VARCHAR(50) REFERENCES categories(name) ON UPDATE CASCADE;
+
+-- Backfill the sample products
+UPDATE products SET category = 'computers' WHERE name = 'Laptop';
+UPDATE products SET category = 'peripherals' WHERE name IN ('Wireless Mouse', 'Mechanical Keyboard', 'Webcam');
+UPDATE products SET category = 'displays' WHERE name = 'Monitor';
+
+-- Create index for category filtering within an organization
+CREATE INDEX idx_products_org_category ON products(org_id, category);
+
+-- Add comments for documentation
+COMMENT ON TABLE categories IS 'Allowed product categories';
+COMMENT ON COLUMN products.category IS 'Optional product category';
+
+-- Migration completed successfully
+SELECT 'Migration 005_product_categories.sql completed successfully' as result;
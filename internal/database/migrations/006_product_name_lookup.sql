@@ -0,0 +1,9 @@
+-- Migration: 006_product_name_lookup.sql
+-- Description: Index supporting the optional per-user product name uniqueness check
+-- Created: 2026-10-17
+
+-- Create index for case-insensitive name lookups within a user's products
+CREATE INDEX idx_products_user_lower_name ON products(user_id, LOWER(name)) WHERE is_active = true;
+
+-- Migration completed successfully
+SELECT 'Migration 006_product_name_lookup.sql completed successfully' as result;
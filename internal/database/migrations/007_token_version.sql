@@ -0,0 +1,12 @@
+-- Migration: 007_token_version.sql
+-- Description: Per-user token version for revoking every issued token at once
+-- Created: 2026-10-17
+
+-- Tokens carry the version they were issued with; bumping it invalidates them all
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+
+-- Add comments for documentation
+COMMENT ON COLUMN users.token_version IS 'Incremented to invalidate all previously issued tokens';
+
+-- Migration completed successfully
+SELECT 'Migration 007_token_version.sql completed successfully' as result;
@@ -0,0 +1,14 @@
+-- Migration: 008_product_images.sql
+-- Description: Optional image for each product
+-- Created: 2026-10-17
+
+-- image_key locates the file in storage; image_url is what clients fetch
+ALTER TABLE products ADD COLUMN image_key VARCHAR(255);
+ALTER TABLE products ADD COLUMN image_url VARCHAR(500);
+
+-- Add comments for documentation
+COMMENT ON COLUMN products.image_key IS 'Storage key of the uploaded product image';
+COMMENT ON COLUMN products.image_url IS 'URL clients use to fetch the product image';
+
+-- Migration completed successfully
+SELECT 'Migration 008_product_images.sql completed successfully' as result;
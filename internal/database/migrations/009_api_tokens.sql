@@ -0,0 +1,29 @@
+-- Migration: 009_api_tokens.sql
+-- Description: Long-lived, scoped API tokens for service-to-service access
+-- Created: 2026-10-17
+
+-- Create api_tokens table; only a SHA-256 hash of each token is stored
+CREATE TABLE api_tokens (
+    id SERIAL PRIMARY KEY,
+    org_id INTEGER NOT NULL REFERENCES organizations(id),
+    name VARCHAR(100) NOT NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    token_prefix VARCHAR(16) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create index for listing an organization's tokens
+CREATE INDEX idx_api_tokens_org_id ON api_tokens(org_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE api_tokens IS 'Scoped API tokens for machine-to-machine access (X-API-Key)';
+COMMENT ON COLUMN api_tokens.token_hash IS 'Hex SHA-256 of the token; the token itself is never stored';
+COMMENT ON COLUMN api_tokens.token_prefix IS 'Leading characters of the token, for identifying it in listings';
+
+-- Migration completed successfully
+SELECT 'Migration 009_api_tokens.sql completed successfully' as result;
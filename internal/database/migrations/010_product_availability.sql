@@ -0,0 +1,18 @@
+-- Migration: 010_product_availability.sql
+-- Description: Optional publish window for products
+-- Created: 2026-10-17
+
+-- A product is visible to other users only from available_from (inclusive)
+-- until available_until (exclusive); NULL leaves that side of the window open
+ALTER TABLE products ADD COLUMN available_from TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN available_until TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE products ADD CONSTRAINT products_availability_check
+    CHECK (available_from IS NULL OR available_until IS NULL OR available_until > available_from);
+
+-- Add comments for documentation
+COMMENT ON COLUMN products.available_from IS 'Start of the publish window; NULL means already published';
+COMMENT ON COLUMN products.available_until IS 'End of the publish window; NULL means never expires';
+
+-- Migration completed successfully
+SELECT 'Migration 010_product_availability.sql completed successfully' as result;
@@ -0,0 +1,40 @@
+-- Migration: 011_events.sql
+-- Description: Append-only change feed of user and product mutations
+-- Created: 2026-10-17
+
+-- Rows are written in the same transaction as the mutation they describe, so
+-- the feed never contains uncommitted changes. Sequence values alone are not
+-- assigned in commit order, so writers serialize on an advisory lock held
+-- until commit (see recordEvent); ids then only become visible in order and
+-- a consumer resuming after an id never skips a later-committing smaller one.
+-- Consumers page through it by id (the primary key index serves the cursor).
+CREATE TABLE IF NOT EXISTS events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_entity ON events(entity_type, entity_id);
+
+-- Enforce append-only: events may be inserted but never changed or removed
+CREATE OR REPLACE FUNCTION events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'events is append-only';
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER events_no_update_delete
+    BEFORE UPDATE OR DELETE ON events
+    FOR EACH ROW
+    EXECUTE FUNCTION events_append_only();
+
+-- Add comments for documentation
+COMMENT ON TABLE events IS 'Append-only change feed of user and product mutations';
+COMMENT ON COLUMN events.payload IS 'State of the entity after the mutation';
+
+-- Migration completed successfully
+SELECT 'Migration 011_events.sql completed successfully' as result;
@@ -0,0 +1,15 @@
+-- Migration: 012_password_changed_at.sql
+-- Description: Track when each password was last set, for password expiry
+-- Created: 2026-10-17
+
+-- NULL means unknown (accounts without a local password); such passwords never expire
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- Existing passwords count from when the account was created
+UPDATE users SET password_changed_at = created_at;
+
+-- Add comments for documentation
+COMMENT ON COLUMN users.password_changed_at IS 'When the password was last set; drives PASSWORD_MAX_AGE expiry';
+
+-- Migration completed successfully
+SELECT 'Migration 012_password_changed_at.sql completed successfully' as result;
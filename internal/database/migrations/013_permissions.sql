@@ -0,0 +1,51 @@
+-- Migration: 013_permissions.sql
+-- Description: Fine-grained permissions granted to roles
+-- Created: 2026-10-17
+
+-- Create permissions table
+CREATE TABLE permissions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create role_permissions junction table
+CREATE TABLE role_permissions (
+    role_id INTEGER REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INTEGER REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+-- Create index for looking up the roles that grant a permission
+CREATE INDEX idx_role_permissions_permission_id ON role_permissions(permission_id);
+
+-- Insert default permissions
+INSERT INTO permissions (name, description) VALUES
+('users:read', 'View user accounts'),
+('users:write', 'Create, update and deactivate user accounts'),
+('products:read', 'View products'),
+('products:write', 'Create, update and delete products'),
+('audit:read', 'View the audit log');
+
+-- Grant permissions to the default roles
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'admin';
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+    ON p.name IN ('products:read', 'products:write')
+WHERE r.name = 'user';
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+    ON p.name IN ('users:read', 'products:read', 'products:write')
+WHERE r.name = 'moderator';
+
+-- Add comments for documentation
+COMMENT ON TABLE permissions IS 'Named permissions checked by RequirePermission';
+COMMENT ON TABLE role_permissions IS 'Many-to-many mapping between roles and permissions';
+
+-- Migration completed successfully
+SELECT 'Migration 013_permissions.sql completed successfully' as result;
@@ -0,0 +1,22 @@
+-- Migration: 014_event_org.sql
+-- Description: Scope the change feed to organizations
+-- Created: 2026-10-17
+
+-- Existing events join the default organization until backfilled below
+ALTER TABLE events ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+
+-- Backfill from the entities that still exist. The append-only trigger is
+-- suspended for this one-off correction only.
+ALTER TABLE events DISABLE TRIGGER events_no_update_delete;
+UPDATE events e SET org_id = u.org_id FROM users u WHERE e.entity_type = 'user' AND e.entity_id = u.id;
+UPDATE events e SET org_id = p.org_id FROM products p WHERE e.entity_type = 'product' AND e.entity_id = p.id;
+ALTER TABLE events ENABLE TRIGGER events_no_update_delete;
+
+-- Create index for the per-organization cursor
+CREATE INDEX idx_events_org_id ON events(org_id, id);
+
+-- Add comments for documentation
+COMMENT ON COLUMN events.org_id IS 'Organization of the entity; the feed only shows an admin their own';
+
+-- Migration completed successfully
+SELECT 'Migration 014_event_org.sql completed successfully' as result;
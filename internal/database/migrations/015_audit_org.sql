@@ -0,0 +1,18 @@
+-- Migration: 015_audit_org.sql
+-- Description: Scope the audit trail to organizations
+-- Created: 2026-10-17
+
+-- Existing entries join the default organization until backfilled below
+ALTER TABLE audit_logs ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+
+-- Backfill from actors that still exist
+UPDATE audit_logs a SET org_id = u.org_id FROM users u WHERE a.actor_id = u.id;
+
+-- Create index for the per-organization view exposed by GET /admin/audit
+CREATE INDEX idx_audit_logs_org_id ON audit_logs(org_id, created_at DESC);
+
+-- Add comments for documentation
+COMMENT ON COLUMN audit_logs.org_id IS 'Organization the action happened in; admins only see their own';
+
+-- Migration completed successfully
+SELECT 'Migration 015_audit_org.sql completed successfully' as result;
@@ -0,0 +1,20 @@
+-- Migration: 016_product_name_unique.sql
+-- Description: Enforce unique product names per owner in the database
+-- Created: 2026-10-17
+
+-- Rename all but the oldest of any active products sharing an owner and a
+-- name (case-insensitive), so the unique index below can be built
+UPDATE products p SET name = LEFT(p.name, 87) || ' (' || p.id || ')', updated_at = NOW()
+FROM products older
+WHERE older.user_id = p.user_id AND LOWER(older.name) = LOWER(p.name)
+  AND older.is_active = true AND p.is_active = true AND older.id < p.id;
+
+-- Replace the lookup index used by the old pre-insert check with a unique one
+DROP INDEX idx_products_user_lower_name;
+CREATE UNIQUE INDEX idx_products_user_name_unique ON products(user_id, LOWER(name)) WHERE is_active = true;
+
+-- Add comments for documentation
+COMMENT ON INDEX idx_products_user_name_unique IS 'An owner cannot have two active products with the same name';
+
+-- Migration completed successfully
+SELECT 'Migration 016_product_name_unique.sql completed successfully' as result;
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// redactedValue replaces sensitive query arguments in logs
+const redactedValue = "[REDACTED]"
+
+var (
+	// comparisonParam matches "column = $n" style bindings in SET and WHERE clauses
+	comparisonParam = regexp.MustCompile(`(?i)([a-z_][a-z0-9_.]*)\s*(?:=|<>|!=)\s*\$(\d+)`)
+	// insertColumns matches the column and value lists of an INSERT statement
+	insertColumns = regexp.MustCompile(`(?is)insert\s+into\s+[a-z0-9_.]+\s*\(([^)]*)\)\s*values\s*\(([^)]*)\)`)
+	// whitespace collapses the indentation of multi-line queries for logging
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// sensitiveColumn reports whether values bound to the column must never be logged
+func sensitiveColumn(column string) bool {
+	column = strings.ToLower(column)
+	for _, marker := range []string{"password", "hash", "token", "secret"} {
+		if strings.Contains(column, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactArgs returns a copy of args with values bound to sensitive columns
+// replaced. Anything that looks like a password hash is redacted regardless
+// of where it is bound.
+func redactArgs(query string, args []interface{}) []interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+
+	sensitive := make(map[int]bool)
+	for _, match := range comparisonParam.FindAllStringSubmatch(query, -1) {
+		if sensitiveColumn(match[1]) {
+			if n, err := strconv.Atoi(match[2]); err == nil {
+				sensitive[n] = true
+			}
+		}
+	}
+	if match := insertColumns.FindStringSubmatch(query); match != nil {
+		columns := strings.Split(match[1], ",")
+		values := strings.Split(match[2], ",")
+		for i := 0; i < len(columns) && i < len(values); i++ {
+			value := strings.TrimSpace(values[i])
+			if !sensitiveColumn(strings.TrimSpace(columns[i])) || !strings.HasPrefix(value, "$") {
+				continue
+			}
+			if n, err := strconv.Atoi(value[1:]); err == nil {
+				sensitive[n] = true
+			}
+		}
+	}
+
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if s, ok := arg.(string); sensitive[i+1] || (ok && looksLikeHash(s)) {
+			redacted[i] = redactedValue
+			continue
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
+
+// looksLikeHash reports whether s is an encoded bcrypt or argon2 hash
+func looksLikeHash(s string) bool {
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") ||
+		strings.HasPrefix(s, "$2y$") || strings.HasPrefix(s, "$argon2")
+}
+
+// compactQuery collapses whitespace so multi-line queries log on one line
+func compactQuery(query string) string {
+	return strings.TrimSpace(whitespace.ReplaceAllString(query, " "))
+}
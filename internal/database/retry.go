@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Retry settings for transient database errors
+const (
+	retryMaxAttempts = 3
+	retryBaseDelay   = 50 * time.Millisecond
+	retryMaxDelay    = time.Second
+)
+
+// retryRecorder is implemented by databases that record retries in metrics
+type retryRecorder interface {
+	RecordRetry(operation string)
+}
+
+// Retry runs fn and retries it with exponential backoff while it fails with a
+// transient error, up to a fixed number of attempts. Only wrap reads and
+// idempotent writes: a retried non-idempotent write may be applied twice.
+func Retry(ctx context.Context, db DB, operation string, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || !IsTransient(err) || attempt >= retryMaxAttempts {
+			return err
+		}
+
+		if recorder, ok := db.(retryRecorder); ok {
+			recorder.RecordRetry(operation)
+		}
+
+		// Full jitter keeps concurrent retries from hitting the database in lockstep
+		delay := min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+		timer := time.NewTimer(rand.N(delay) + 1)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// IsTransient reports whether err is a database error that is likely to
+// succeed on retry, such as a serialization failure or a dropped connection.
+// Constraint violations and other data errors are never transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "40001", // serialization_failure
+			"40P01", // deadlock_detected
+			"57P01", // admin_shutdown
+			"57P03": // cannot_connect_now
+			return true
+		}
+		// Class 08 covers connection exceptions
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		pgconn.SafeToRetry(err) ||
+		pgconn.Timeout(err)
+}
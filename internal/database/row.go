@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Row is the result of a single-row query on an InstrumentedDB or Tx.
+// sql.Row defers any query error until Scan, so the query's duration is
+// observed when it runs but its status is only counted and logged once Scan
+// reports the outcome. sql.ErrNoRows is counted as a success: the query
+// worked and simply matched nothing.
+type Row struct {
+	*sql.Row
+	ctx       context.Context
+	idb       *InstrumentedDB
+	operation string
+	query     string
+	args      []interface{}
+	duration  time.Duration
+	recorded  bool
+}
+
+// queryRow runs a single-row query through run and wraps its result
+func (idb *InstrumentedDB) queryRow(ctx context.Context, operation, query string, args []interface{}, run func() *sql.Row) *Row {
+	start := time.Now()
+	row := run()
+	duration := time.Since(start)
+	idb.metrics.DBQueryDuration.WithLabelValues(operation).Observe(duration.Seconds())
+
+	return &Row{
+		Row:       row,
+		ctx:       ctx,
+		idb:       idb,
+		operation: operation,
+		query:     query,
+		args:      args,
+		duration:  duration,
+	}
+}
+
+// Scan copies the row's columns into dest and records the query's outcome
+func (r *Row) Scan(dest ...any) error {
+	err := r.Row.Scan(dest...)
+	r.record(err)
+	return err
+}
+
+// Err reports the query's error without scanning and records its outcome
+func (r *Row) Err() error {
+	err := r.Row.Err()
+	r.record(err)
+	return err
+}
+
+// record counts and logs the query once, however often the row is read
+func (r *Row) record(err error) {
+	if r.recorded {
+		return
+	}
+	r.recorded = true
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	r.idb.metrics.DBQueriesTotal.WithLabelValues(r.operation, status).Inc()
+	r.idb.logQuery(r.ctx, r.operation, r.query, r.args, r.duration, err)
+	r.idb.logSlowQuery(r.ctx, r.operation, r.query, r.duration, err)
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Tx is a transaction begun on an InstrumentedDB. Statements run through it
+// record the same query metrics and logs as the database itself, labelled
+// with a "tx_" operation prefix, and its commit or rollback is counted.
+type Tx struct {
+	*sql.Tx
+	idb *InstrumentedDB
+}
+
+// Begin starts an instrumented transaction
+func (idb *InstrumentedDB) Begin() (*Tx, error) {
+	return idb.BeginTx(context.Background(), nil)
+}
+
+// BeginTx starts an instrumented transaction with the given options
+func (idb *InstrumentedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	start := time.Now()
+	tx, err := idb.DB.BeginTx(ctx, opts)
+	idb.record(ctx, "begin", "BEGIN", nil, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, idb: idb}, nil
+}
+
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := tx.Tx.ExecContext(ctx, query, args...)
+	tx.idb.record(ctx, "tx_exec", query, args, time.Since(start), err)
+	return result, err
+}
+
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	tx.idb.record(ctx, "tx_query", query, args, time.Since(start), err)
+	return rows, err
+}
+
+func (tx *Tx) QueryRow(query string, args ...interface{}) *Row {
+	return tx.QueryRowContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	return tx.idb.queryRow(ctx, "tx_query_row", query, args, func() *sql.Row {
+		return tx.Tx.QueryRowContext(ctx, query, args...)
+	})
+}
+
+// Commit commits the transaction and counts the outcome
+func (tx *Tx) Commit() error {
+	err := tx.Tx.Commit()
+	tx.idb.recordTxEnd("commit", err)
+	return err
+}
+
+// Rollback aborts the transaction and counts the outcome. Rolling back a
+// transaction that already ended is how deferred cleanup after a commit
+// looks, so it is not counted.
+func (tx *Tx) Rollback() error {
+	err := tx.Tx.Rollback()
+	if !errors.Is(err, sql.ErrTxDone) {
+		tx.idb.recordTxEnd("rollback", err)
+	}
+	return err
+}
+
+// record observes one statement in the query metrics and logs
+func (idb *InstrumentedDB) record(ctx context.Context, operation, query string, args []interface{}, duration time.Duration, err error) {
+	idb.metrics.DBQueryDuration.WithLabelValues(operation).Observe(duration.Seconds())
+
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	idb.metrics.DBQueriesTotal.WithLabelValues(operation, status).Inc()
+	idb.logQuery(ctx, operation, query, args, duration, err)
+	idb.logSlowQuery(ctx, operation, query, duration, err)
+}
+
+// recordTxEnd counts a transaction ending with a commit or rollback
+func (idb *InstrumentedDB) recordTxEnd(outcome string, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	idb.metrics.DBTransactionsTotal.WithLabelValues(outcome, status).Inc()
+}
@@ -1,30 +1,65 @@
 package handlers
 
 import (
-	"database/sql"
-	"encoding/json"
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 	"log/slog"
 
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/crypto"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/httputil"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
 )
 
+// MaxUserImportBatch bounds the number of users accepted by one import request
+const MaxUserImportBatch = 100
+
 // AdminHandler handles admin-only HTTP requests
 type AdminHandler struct {
 	db database.DB
+	userRepo *models.UserRepository
+	auditRepo *models.AuditRepository
 	logger *slog.Logger
+	// secureRequest reports whether a request arrived over HTTPS
+	secureRequest func(*http.Request) bool
+	// statsCache serves dashboard stats for a short TTL; nil disables caching
+	statsCache *statsCache
 }
 
 // NewAdminHandler creates a new admin handler
 func NewAdminHandler(db database.DB, logger *slog.Logger) *AdminHandler {
 	return &AdminHandler{
 		db: db,
+		userRepo: models.NewUserRepository(db),
+		auditRepo: models.NewAuditRepository(db),
 		logger: logger,
+		secureRequest: func(r *http.Request) bool { return r.TLS != nil },
 	}
 }
 
-// GetAdminData returns admin-only information
+// SetSecureRequestCheck replaces how the handler decides a request arrived
+// over HTTPS, e.g. to trust a TLS-terminating proxy
+func (h *AdminHandler) SetSecureRequestCheck(secure func(*http.Request) bool) {
+	h.secureRequest = secure
+}
+
+// SetStatsCacheTTL caches the dashboard stats computed by GetAdminData and
+// GetSystemStats for ttl; zero disables caching
+func (h *AdminHandler) SetStatsCacheTTL(ttl time.Duration) {
+	h.statsCache = newStatsCache(ttl)
+}
+
+// GetAdminData returns admin-only information. When ROUTE_ROLES opens the
+// route to other roles, only users who have or outrank admin see the
+// system-wide stats.
 func (h *AdminHandler) GetAdminData(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -39,176 +74,420 @@ func (h *AdminHandler) GetAdminData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userRoles, _ := auth.GetUserRolesFromContext(r.Context())
+	orgID, ok := auth.GetOrgFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
 
 	// Prepare admin response
 	response := map[string]interface{}{
 		"message":     "This is admin-only content!",
 		"user":        userEmail,
 		"roles":       userRoles,
-		"admin_info": map[string]interface{}{
-			"total_users":    h.getTotalUsers(),
-			"total_products": h.getTotalProducts(),
-			"system_status":  "operational",
-		},
+	}
+	if auth.HasAnyRole(r.Context(), "admin") {
+		response["admin_info"] = h.cachedStats(w, r, fmt.Sprintf("admin_info:%d", orgID), func() map[string]interface{} {
+			return map[string]interface{}{
+				"total_users":    h.getTotalUsers(orgID),
+				"total_products": h.getTotalProducts(orgID),
+				"system_status":  "operational",
+			}
+		})
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "GetAdminData"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	writeJSON(w, r, h.logger, "GetAdminData", http.StatusOK, response)
+}
+
+// GetSystemStats returns the user and product statistics of the caller's
+// organization (admin only)
+func (h *AdminHandler) GetSystemStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+
+	orgID, ok := auth.GetOrgFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	stats := h.cachedStats(w, r, fmt.Sprintf("system_stats:%d", orgID), func() map[string]interface{} {
+		return map[string]interface{}{
+			"users": map[string]interface{}{
+				"total":         h.getTotalUsers(orgID),
+				"active":        h.getActiveUsers(orgID),
+				"verified":      h.getVerifiedUsers(orgID),
+				"recent_logins": h.getRecentLogins(orgID),
+			},
+			"products": map[string]interface{}{
+				"total":  h.getTotalProducts(orgID),
+				"active": h.getActiveProducts(orgID),
+			},
+			"system": map[string]interface{}{
+				"database_status": h.checkDatabaseHealth(),
+				"uptime":         "N/A", // Would be calculated in a real system
+			},
+		}
+	})
+
+	writeJSON(w, r, h.logger, "GetSystemStats", http.StatusOK, stats)
 }
 
-// GetSystemStats returns system statistics (admin only)
-func (h *AdminHandler) GetSystemStats(w http.ResponseWriter, r *http.Request) {
+// GetAllUsers returns the users of the caller's organization (requires the
+// users:read permission)
+func (h *AdminHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	stats := map[string]interface{}{
-		"users": map[string]interface{}{
-			"total":         h.getTotalUsers(),
-			"active":        h.getActiveUsers(),
-			"verified":      h.getVerifiedUsers(),
-			"recent_logins": h.getRecentLogins(),
-		},
-		"products": map[string]interface{}{
-			"total":  h.getTotalProducts(),
-			"active": h.getActiveProducts(),
-		},
-		"system": map[string]interface{}{
-			"database_status": h.checkDatabaseHealth(),
-			"uptime":         "N/A", // Would be calculated in a real system
-		},
+	params, paramErrs := httputil.ParseListParams(r, httputil.ListSpec{SortFields: models.UserSortFields})
+	if paramErrs.HasErrors() {
+		writeValidationErrors(w, r, h.logger, "GetAllUsers.params", paramErrs)
+		return
+	}
+
+	orgID, ok := auth.GetOrgFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	users, total, err := h.userRepo.ListSorted(orgID, params.Sort, params.Limit, params.Offset)
+	if errors.Is(err, models.ErrInvalidSort) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to list users", slog.String("error", err.Error()))
+		http.Error(w, "Failed to retrieve users", http.StatusInternalServerError)
+		return
+	}
+	setTotalCount(w, total)
+
+	writeJSON(w, r, h.logger, "GetAllUsers", http.StatusOK, users)
+}
+
+// ImportUsers creates users in bulk (admin only). Each user is validated and
+// created independently; the response is a BulkResult (see bulk.go).
+func (h *AdminHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	orgID, ok := auth.GetOrgFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	var importReq struct {
+		Users []models.CreateUserRequest `json:"users"`
+	}
+	if !decodeJSON(w, r, &importReq) {
+		return
+	}
+	if len(importReq.Users) == 0 {
+		http.Error(w, "At least one user is required", http.StatusBadRequest)
+		return
+	}
+	if len(importReq.Users) > MaxUserImportBatch {
+		http.Error(w, fmt.Sprintf("At most %d users can be imported at once", MaxUserImportBatch), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	result := &BulkResult{Results: []BulkItemResult{}}
+	seen := make(map[string]bool)
+	for i, userReq := range importReq.Users {
+		item := h.importUser(userReq, orgID, seen)
+		item.Index = i
+		result.Add(item)
+	}
+
+	recordAudit(h.auditRepo, h.logger, r, "users.import", "users", map[string]interface{}{
+		"succeeded": result.Succeeded,
+		"failed":    result.Failed,
+	})
+
+	writeJSON(w, r, h.logger, "ImportUsers", result.StatusCode(), result)
+}
+
+// importUser validates and creates a single imported user in the importing
+// admin's organization. seen tracks the emails already claimed earlier in
+// the same batch.
+func (h *AdminHandler) importUser(userReq models.CreateUserRequest, orgID int, seen map[string]bool) BulkItemResult {
+	if validationErrors := validator.Validate(userReq); validationErrors.HasErrors() {
+		return BulkItemResult{Status: http.StatusBadRequest, Error: "Validation failed", Details: validationErrors}
+	}
+
+	email := strings.ToLower(strings.TrimSpace(userReq.Email))
+	if seen[email] {
+		return BulkItemResult{Status: http.StatusConflict, Error: "Email appears more than once in this import"}
+	}
+	seen[email] = true
+
+	exists, err := h.userRepo.EmailExists(email)
+	if err != nil {
+		return BulkItemResult{Status: http.StatusInternalServerError, Error: "Internal server error"}
+	}
+	if exists {
+		return BulkItemResult{Status: http.StatusConflict, Error: "Email already registered"}
+	}
+
+	passwordHash, err := crypto.HashPassword(userReq.Password)
+	if err != nil {
+		return BulkItemResult{Status: http.StatusInternalServerError, Error: "Internal server error"}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(stats); err != nil {
-		h.logger.Error("Failed to encode JSON response",
+	user := &models.User{
+		OrgID:        orgID,
+		Name:         strings.TrimSpace(userReq.Name),
+		Email:        email,
+		PasswordHash: passwordHash,
+		IsActive:     true,
+	}
+	if err := h.userRepo.Create(user); err != nil {
+		if status, details, ok := constraintErrors(err); ok {
+			return BulkItemResult{Status: status, Error: "Validation failed", Details: details}
+		}
+		h.logger.Error("Failed to import user",
 			slog.String("error", err.Error()),
-			slog.String("handler", "GetSystemStats"),
 		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return BulkItemResult{Status: http.StatusInternalServerError, Error: "Failed to create user"}
 	}
+
+	return BulkItemResult{Status: http.StatusCreated, ID: user.ID}
 }
 
-// GetAllUsers returns all users (admin only)
-func (h *AdminHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
+// GetAuditLogs returns a filtered, paginated view of the audit trail (admin only).
+// Supported query parameters: actor (user ID or email), action, target,
+// from/to (RFC 3339 or YYYY-MM-DD), page, page_size and format=csv.
+func (h *AdminHandler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	query := `
-		SELECT id, name, email, email_verified, is_active, created_at, last_login
-		FROM users 
-		ORDER BY created_at DESC`
+	orgID, ok := auth.GetOrgFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
 
-	rows, err := h.db.Query(query)
+	params := r.URL.Query()
+	filter, err := parseAuditFilter(params)
 	if err != nil {
-		http.Error(w, "Failed to retrieve users", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer rows.Close()
-
-	var users []map[string]interface{}
-	for rows.Next() {
-		var id int
-		var name, email string
-		var emailVerified, isActive bool
-		var createdAt string
-		var lastLogin sql.NullString
+	filter.OrgID = orgID
 
-		err := rows.Scan(&id, &name, &email, &emailVerified, &isActive, &createdAt, &lastLogin)
+	if params.Get("format") == "csv" {
+		filter.Limit = models.MaxAuditExportRows
+		entries, _, err := h.auditRepo.Query(filter)
 		if err != nil {
-			continue // Skip problematic rows
+			http.Error(w, "Failed to retrieve audit logs", http.StatusInternalServerError)
+			return
 		}
+		h.writeAuditCSV(w, entries)
+		return
+	}
 
-		user := map[string]interface{}{
-			"id":             id,
-			"name":           name,
-			"email":          email,
-			"email_verified": emailVerified,
-			"is_active":      isActive,
-			"created_at":     createdAt,
-			"last_login":     nil,
-		}
+	page, err := parsePositiveInt(params.Get("page"), 1)
+	if err != nil {
+		http.Error(w, "Invalid page", http.StatusBadRequest)
+		return
+	}
+	pageSize, err := parsePositiveInt(params.Get("page_size"), models.DefaultAuditPageSize)
+	if err != nil || pageSize > models.MaxAuditPageSize {
+		http.Error(w, fmt.Sprintf("page_size must be between 1 and %d", models.MaxAuditPageSize), http.StatusBadRequest)
+		return
+	}
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
 
-		if lastLogin.Valid {
-			user["last_login"] = lastLogin.String
-		}
+	entries, total, err := h.auditRepo.Query(filter)
+	if err != nil {
+		http.Error(w, "Failed to retrieve audit logs", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []models.AuditLog{}
+	}
 
-		users = append(users, user)
+	response := map[string]interface{}{
+		"entries": entries,
+		"pagination": map[string]interface{}{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": (total + pageSize - 1) / pageSize,
+		},
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(users); err != nil {
-		h.logger.Error("Failed to encode JSON response",
+	writeJSON(w, r, h.logger, "GetAuditLogs", http.StatusOK, response)
+}
+
+// csvFlushInterval is how many rows are buffered between write error checks
+const csvFlushInterval = 500
+
+// writeAuditCSV streams audit entries as a CSV attachment
+func (h *AdminHandler) writeAuditCSV(w http.ResponseWriter, entries []models.AuditLog) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-log.csv"`)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "created_at", "actor_id", "actor_email", "action", "target", "ip_address", "details"})
+	for i, e := range entries {
+		// Stop early once the client has gone away; the status is already sent,
+		// so all that is left to do is log the failure
+		if i%csvFlushInterval == 0 {
+			if cw.Flush(); cw.Error() != nil {
+				break
+			}
+		}
+
+		actorID := ""
+		if e.ActorID != nil {
+			actorID = strconv.Itoa(*e.ActorID)
+		}
+		cw.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			actorID,
+			e.ActorEmail,
+			e.Action,
+			e.Target,
+			e.IPAddress,
+			string(e.Details),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		h.logger.Error("Failed to write CSV response",
 			slog.String("error", err.Error()),
-			slog.String("handler", "GetAllUsers"),
+			slog.String("handler", "GetAuditLogs"),
 		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
 	}
 }
 
-// Helper functions for gathering statistics
+// parseAuditFilter reads the audit filters from the query string
+func parseAuditFilter(params url.Values) (models.AuditFilter, error) {
+	var filter models.AuditFilter
+
+	if actor := params.Get("actor"); actor != "" {
+		if id, err := strconv.Atoi(actor); err == nil {
+			filter.ActorID = &id
+		} else {
+			filter.ActorEmail = actor
+		}
+	}
+	filter.Action = params.Get("action")
+	filter.Target = params.Get("target")
+
+	if v := params.Get("from"); v != "" {
+		from, _, err := parseAuditTime(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from: %v", err)
+		}
+		filter.From = &from
+	}
+	if v := params.Get("to"); v != "" {
+		to, dateOnly, err := parseAuditTime(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to: %v", err)
+		}
+		// A bare date includes the whole day
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, fmt.Errorf("from must be before to")
+	}
+
+	return filter, nil
+}
+
+// parseAuditTime accepts RFC 3339 timestamps or YYYY-MM-DD dates (UTC)
+func parseAuditTime(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected RFC 3339 timestamp or YYYY-MM-DD date")
+	}
+	return t, true, nil
+}
+
+// parsePositiveInt parses an optional positive integer query parameter
+func parsePositiveInt(v string, def int) (int, error) {
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("must be a positive integer")
+	}
+	return n, nil
+}
+
+// Helper functions for gathering the statistics of one organization
 
-func (h *AdminHandler) getTotalUsers() int {
+func (h *AdminHandler) getTotalUsers(orgID int) int {
 	var count int
-	err := h.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	err := h.db.QueryRow("SELECT COUNT(*) FROM users WHERE org_id = $1", orgID).Scan(&count)
 	if err != nil {
 		return 0
 	}
 	return count
 }
 
-func (h *AdminHandler) getActiveUsers() int {
+func (h *AdminHandler) getActiveUsers(orgID int) int {
 	var count int
-	err := h.db.QueryRow("SELECT COUNT(*) FROM users WHERE is_active = true").Scan(&count)
+	err := h.db.QueryRow("SELECT COUNT(*) FROM users WHERE org_id = $1 AND is_active = true", orgID).Scan(&count)
 	if err != nil {
 		return 0
 	}
 	return count
 }
 
-func (h *AdminHandler) getVerifiedUsers() int {
+func (h *AdminHandler) getVerifiedUsers(orgID int) int {
 	var count int
-	err := h.db.QueryRow("SELECT COUNT(*) FROM users WHERE email_verified = true").Scan(&count)
+	err := h.db.QueryRow("SELECT COUNT(*) FROM users WHERE org_id = $1 AND email_verified = true", orgID).Scan(&count)
 	if err != nil {
 		return 0
 	}
 	return count
 }
 
-func (h *AdminHandler) getRecentLogins() int {
+func (h *AdminHandler) getRecentLogins(orgID int) int {
 	var count int
-	query := "SELECT COUNT(*) FROM users WHERE last_login > NOW() - INTERVAL '24 hours'"
-	err := h.db.QueryRow(query).Scan(&count)
+	query := "SELECT COUNT(*) FROM users WHERE org_id = $1 AND last_login > NOW() - INTERVAL '24 hours'"
+	err := h.db.QueryRow(query, orgID).Scan(&count)
 	if err != nil {
 		return 0
 	}
 	return count
 }
 
-func (h *AdminHandler) getTotalProducts() int {
+func (h *AdminHandler) getTotalProducts(orgID int) int {
 	var count int
-	err := h.db.QueryRow("SELECT COUNT(*) FROM products").Scan(&count)
+	err := h.db.QueryRow("SELECT COUNT(*) FROM products WHERE org_id = $1", orgID).Scan(&count)
 	if err != nil {
 		return 0
 	}
 	return count
 }
 
-func (h *AdminHandler) getActiveProducts() int {
+func (h *AdminHandler) getActiveProducts(orgID int) int {
 	var count int
-	err := h.db.QueryRow("SELECT COUNT(*) FROM products WHERE is_active = true").Scan(&count)
+	err := h.db.QueryRow("SELECT COUNT(*) FROM products WHERE org_id = $1 AND is_active = true", orgID).Scan(&count)
 	if err != nil {
 		return 0
 	}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// RoleChangeRequest names a user and a role to grant or revoke
+type RoleChangeRequest struct {
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// HandleUserRoles dispatches /admin/users/roles by method: POST assigns a
+// role, DELETE removes one
+func (h *AdminHandler) HandleUserRoles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.AssignRole(w, r)
+	case http.MethodDelete:
+		h.RemoveRole(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// AssignRole grants a role to a user of the admin's organization (admin
+// only). Granting a role the user already has succeeds without change.
+func (h *AdminHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, ok := h.decodeRoleChange(w, r, "AssignRole")
+	if !ok {
+		return
+	}
+
+	h.changeRole(w, r, "AssignRole", "user.role_assign", req, h.userRepo.AssignRole)
+}
+
+// RemoveRole revokes a role from a user of the admin's organization (admin
+// only). Admins cannot remove their own admin role, so an organization is
+// never locked out by accident.
+func (h *AdminHandler) RemoveRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, ok := h.decodeRoleChange(w, r, "RemoveRole")
+	if !ok {
+		return
+	}
+	if callerID, _ := auth.GetUserIDFromContext(r.Context()); req.UserID == callerID && req.Role == "admin" {
+		http.Error(w, "You cannot remove your own admin role", http.StatusBadRequest)
+		return
+	}
+
+	h.changeRole(w, r, "RemoveRole", "user.role_remove", req, h.userRepo.RemoveRole)
+}
+
+// decodeRoleChange reads and validates a RoleChangeRequest
+func (h *AdminHandler) decodeRoleChange(w http.ResponseWriter, r *http.Request, handler string) (RoleChangeRequest, bool) {
+	var req RoleChangeRequest
+	if !decodeJSON(w, r, &req) {
+		return req, false
+	}
+	req.Role = strings.TrimSpace(req.Role)
+
+	var errs validator.ValidationErrors
+	if req.UserID <= 0 {
+		errs.AddCode("user_id", validator.CodeRequired, "user_id must be a positive integer")
+	}
+	if err := validator.ValidateRequired("role", req.Role); err != nil {
+		errs.AddError("role", err)
+	}
+	if errs.HasErrors() {
+		writeValidationErrors(w, r, h.logger, handler+".validation", errs)
+		return req, false
+	}
+	return req, true
+}
+
+// changeRole applies a role change to a user of the admin's organization,
+// answering 404 for unknown users and roles
+func (h *AdminHandler) changeRole(w http.ResponseWriter, r *http.Request, handler, action string, req RoleChangeRequest, apply func(int, string) error) {
+	// Users of other organizations are indistinguishable from missing ones
+	user, err := h.userRepo.GetByID(req.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if orgID, ok := auth.GetOrgFromContext(r.Context()); !ok || user.OrgID != orgID {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	err = apply(user.ID, req.Role)
+	switch {
+	case errors.Is(err, models.ErrRoleNotFound):
+		http.Error(w, "Role not found", http.StatusNotFound)
+		return
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	case err != nil:
+		h.logger.Error("Failed to change user role",
+			slog.String("handler", handler),
+			slog.Int("user_id", user.ID),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	recordAudit(h.auditRepo, h.logger, r, action, "user:"+strconv.Itoa(user.ID), map[string]interface{}{
+		"role": req.Role,
+	})
+
+	roles, err := h.userRepo.GetRoles(user.ID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, r, h.logger, handler, http.StatusOK, map[string]interface{}{
+		"user_id": user.ID,
+		"roles":   roles,
+	})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// UserStatusRequest names a user and whether their account is active
+type UserStatusRequest struct {
+	UserID   int   `json:"user_id"`
+	IsActive *bool `json:"is_active"`
+}
+
+// SetUserActive deactivates or reactivates a user of the admin's
+// organization (admin only). Deactivated users can no longer log in, and
+// their existing tokens are revoked by bumping their token version. Admins
+// cannot deactivate themselves.
+func (h *AdminHandler) SetUserActive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req UserStatusRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	var errs validator.ValidationErrors
+	if req.UserID <= 0 {
+		errs.AddCode("user_id", validator.CodeRequired, "user_id must be a positive integer")
+	}
+	if req.IsActive == nil {
+		errs.AddCode("is_active", validator.CodeRequired, "is_active is required")
+	}
+	if errs.HasErrors() {
+		writeValidationErrors(w, r, h.logger, "SetUserActive.validation", errs)
+		return
+	}
+	active := *req.IsActive
+
+	if callerID, _ := auth.GetUserIDFromContext(r.Context()); req.UserID == callerID && !active {
+		http.Error(w, "You cannot deactivate your own account", http.StatusBadRequest)
+		return
+	}
+
+	// Inactive users must be found too, so they can be reactivated; users
+	// of other organizations are indistinguishable from missing ones
+	orgID, err := h.userRepo.GetOrgID(req.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if callerOrg, ok := auth.GetOrgFromContext(r.Context()); !ok || orgID != callerOrg {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.userRepo.SetActive(req.UserID, active); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to update user status",
+			slog.Int("user_id", req.UserID),
+			slog.Bool("is_active", active),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	action := "user.deactivate"
+	if active {
+		action = "user.activate"
+	}
+	recordAudit(h.auditRepo, h.logger, r, action, "user:"+strconv.Itoa(req.UserID), nil)
+
+	writeJSON(w, r, h.logger, "SetUserActive", http.StatusOK, map[string]interface{}{
+		"user_id":   req.UserID,
+		"is_active": active,
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+func TestImportUsersIntoAdminOrg(t *testing.T) {
+	const orgID = 3
+	h, mock := newTestHandlers(t)
+	admin := &models.User{ID: 1, OrgID: orgID, Email: "admin@example.com", Roles: []string{"admin"}}
+	token := issueToken(t, h.auth, admin)
+
+	expectTokenVersion(mock, admin.ID, 0)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM users WHERE email = $1")).WithArgs("new@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (org_id,")).
+		WithArgs(orgID, "New User", "new@example.com", sqlmock.AnyArg(), false, true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "created_at", "updated_at"}).
+			AddRow(9, orgID, time.Now(), time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_roles")).WithArgs(9).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectEvent(mock)
+	mock.ExpectCommit()
+	expectAudit(mock)
+
+	body := `{"users":[{"name":"New User","email":"new@example.com","password":"Correct-Horse-9"}]}`
+	rec := serve(h.auth.RequireAuth(h.admin.ImportUsers), http.MethodPost, "/admin/users/import", body, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+}
+
+func TestGetSystemStatsScopedToOrg(t *testing.T) {
+	const orgID = 3
+	h, mock := newTestHandlers(t)
+	admin := &models.User{ID: 1, OrgID: orgID, Email: "admin@example.com", Roles: []string{"admin"}}
+	token := issueToken(t, h.auth, admin)
+
+	expectTokenVersion(mock, admin.ID, 0)
+	for _, table := range []string{"users", "users", "users", "users", "products", "products"} {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM " + table + " WHERE org_id = $1")).WithArgs(orgID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	}
+
+	rec := serve(h.auth.RequireAuth(h.admin.GetSystemStats), http.MethodGet, "/admin/stats", "", token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+}
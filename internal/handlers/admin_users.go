@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+)
+
+// DeleteUser permanently deletes the user of the admin's organization named
+// by the id query parameter (admin only), for data-deletion requests. Use
+// SetUserActive to merely disable an account. Admins cannot delete
+// themselves.
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil || userID <= 0 {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if callerID, _ := auth.GetUserIDFromContext(r.Context()); userID == callerID {
+		http.Error(w, "You cannot delete your own account", http.StatusBadRequest)
+		return
+	}
+
+	// Users of other organizations are indistinguishable from missing ones
+	orgID, err := h.userRepo.GetOrgID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if callerOrg, ok := auth.GetOrgFromContext(r.Context()); !ok || orgID != callerOrg {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.userRepo.Delete(userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to delete user",
+			slog.Int("user_id", userID),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	recordAudit(h.auditRepo, h.logger, r, "user.delete", "user:"+strconv.Itoa(userID), nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
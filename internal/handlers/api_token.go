@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// MaxAPITokenLifetimeDays bounds the expiry an API token can be created with
+const MaxAPITokenLifetimeDays = 3650
+
+// CreateAPITokenRequest describes a new service account token
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // Zero means the token never expires
+}
+
+// Validate checks the token name, scopes, and lifetime
+func (req CreateAPITokenRequest) Validate() validator.ValidationErrors {
+	var errs validator.ValidationErrors
+
+	if err := validator.ValidateName(strings.TrimSpace(req.Name)); err != nil {
+		errs.AddError("name", err)
+	}
+	if len(req.Scopes) == 0 {
+		errs.AddCode("scopes", validator.CodeRequired, "at least one scope is required")
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(models.APITokenScopes, scope) {
+			errs.AddCode("scopes", validator.CodeNotAllowed, "unknown scope "+strconv.Quote(scope))
+		}
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > MaxAPITokenLifetimeDays {
+		errs.Add("expires_in_days", "expires_in_days must be between 0 and "+strconv.Itoa(MaxAPITokenLifetimeDays))
+	}
+
+	return errs
+}
+
+// APITokenHandler manages the service account tokens of the caller's
+// organization (admin only)
+type APITokenHandler struct {
+	tokenRepo *models.APITokenRepository
+	auditRepo *models.AuditRepository
+	logger    *slog.Logger
+}
+
+// NewAPITokenHandler creates a new API token handler
+func NewAPITokenHandler(db database.DB, logger *slog.Logger) *APITokenHandler {
+	return &APITokenHandler{
+		tokenRepo: models.NewAPITokenRepository(db),
+		auditRepo: models.NewAuditRepository(db),
+		logger:    logger,
+	}
+}
+
+// HandleAPITokens dispatches /admin/api-tokens by method: GET lists, POST
+// generates, DELETE revokes
+func (h *APITokenHandler) HandleAPITokens(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.ListAPITokens(w, r)
+	case http.MethodPost:
+		h.GenerateAPIToken(w, r)
+	case http.MethodDelete:
+		h.RevokeAPIToken(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GenerateAPIToken creates a scoped token. The token is returned only in
+// this response; afterwards just its hash is kept.
+func (h *APITokenHandler) GenerateAPIToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+	orgID, ok := auth.GetOrgFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	var createReq CreateAPITokenRequest
+	if !decodeJSON(w, r, &createReq) {
+		return
+	}
+	if validationErrors := validator.Validate(createReq); validationErrors.HasErrors() {
+		writeValidationErrors(w, r, h.logger, "GenerateAPIToken.validation", validationErrors)
+		return
+	}
+
+	raw, tokenHash, prefix, err := auth.GenerateAPIToken()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	token := &models.APIToken{
+		OrgID:     orgID,
+		Name:      strings.TrimSpace(createReq.Name),
+		Prefix:    prefix,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(createReq.Scopes))),
+		CreatedBy: &userID,
+	}
+	if createReq.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, createReq.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := h.tokenRepo.Create(token, tokenHash); err != nil {
+		h.logger.Error("Failed to create API token", slog.String("error", err.Error()))
+		http.Error(w, "Failed to create API token", http.StatusInternalServerError)
+		return
+	}
+
+	recordAudit(h.auditRepo, h.logger, r, "api_token.create", "api_token:"+strconv.Itoa(token.ID), map[string]interface{}{
+		"name":   token.Name,
+		"scopes": token.Scopes,
+	})
+
+	writeJSON(w, r, h.logger, "GenerateAPIToken", http.StatusCreated, map[string]interface{}{
+		"token":     raw,
+		"api_token": token,
+	})
+}
+
+// ListAPITokens returns the organization's tokens, without their secrets
+func (h *APITokenHandler) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	orgID, ok := auth.GetOrgFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := h.tokenRepo.ListByOrg(orgID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve API tokens", http.StatusInternalServerError)
+		return
+	}
+	if tokens == nil {
+		tokens = []models.APIToken{}
+	}
+
+	writeJSON(w, r, h.logger, "ListAPITokens", http.StatusOK, tokens)
+}
+
+// RevokeAPIToken permanently disables the token named by ?id=
+func (h *APITokenHandler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	orgID, ok := auth.GetOrgFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tokenRepo.Revoke(id, orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "API token not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to revoke API token", http.StatusInternalServerError)
+		return
+	}
+
+	recordAudit(h.auditRepo, h.logger, r, "api_token.revoke", "api_token:"+strconv.Itoa(id), nil)
+
+	writeJSON(w, r, h.logger, "RevokeAPIToken", http.StatusOK, map[string]string{
+		"message": "API token revoked",
+	})
+}
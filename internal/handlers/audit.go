@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// recordAudit appends an entry for the authenticated user's action to the
+// audit trail of their organization. Failures are logged rather than failing
+// the request, since the action itself has already happened.
+func recordAudit(repo *models.AuditRepository, logger *slog.Logger, r *http.Request, action, target string, details map[string]interface{}) {
+	orgID, _ := auth.GetOrgFromContext(r.Context())
+	recordOrgAudit(repo, logger, r, orgID, action, target, details)
+}
+
+// recordOrgAudit is recordAudit for unauthenticated requests, where the
+// organization comes from the user the action targets rather than the caller
+func recordOrgAudit(repo *models.AuditRepository, logger *slog.Logger, r *http.Request, orgID int, action, target string, details map[string]interface{}) {
+	entry := &models.AuditLog{
+		OrgID:     orgID,
+		Action:    action,
+		Target:    target,
+		IPAddress: clientIP(r),
+	}
+	if userID, ok := auth.GetUserIDFromContext(r.Context()); ok {
+		entry.ActorID = &userID
+	}
+	if email, ok := auth.GetUserEmailFromContext(r.Context()); ok {
+		entry.ActorEmail = email
+	}
+	if len(details) > 0 {
+		if raw, err := json.Marshal(details); err == nil {
+			entry.Details = raw
+		}
+	}
+
+	if err := repo.Log(entry); err != nil {
+		logger.Error("Failed to record audit entry",
+			slog.String("error", err.Error()),
+			slog.String("action", action),
+			slog.String("target", target),
+		)
+	}
+}
+
+// clientIP returns the client address the server resolved for the request,
+// falling back to the direct peer when the request did not pass through it
+func clientIP(r *http.Request) string {
+	if ip, ok := auth.GetClientIPFromContext(r.Context()); ok {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+func TestGetAuditLogsScopedToOrg(t *testing.T) {
+	const orgID = 3
+	h, mock := newTestHandlers(t)
+	admin := &models.User{ID: 1, OrgID: orgID, Email: "admin@example.com", Roles: []string{"admin"}}
+	token := issueToken(t, h.auth, admin)
+
+	expectTokenVersion(mock, admin.ID, 0)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM audit_logs WHERE org_id = $1 AND action = $2")).
+		WithArgs(orgID, "user.delete").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE org_id = $1 AND action = $2")).
+		WithArgs(orgID, "user.delete", models.DefaultAuditPageSize, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "actor_id", "actor_email", "action", "target", "details", "ip_address", "created_at"}))
+
+	rec := serve(h.auth.RequireAuth(h.admin.GetAuditLogs), http.MethodGet, "/admin/audit?action=user.delete", "", token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+}
+
+func TestRecordAuditWritesCallerOrg(t *testing.T) {
+	const orgID = 3
+	h, mock := newTestHandlers(t)
+	user := &models.User{ID: 7, OrgID: orgID, Email: "user@example.com", Roles: []string{"user"}}
+	token := issueToken(t, h.auth, user)
+
+	expectTokenVersion(mock, user.ID, 0)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO audit_logs")).
+		WithArgs(orgID, user.ID, user.Email, "test.action", "user:7", nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		recordAudit(h.admin.auditRepo, discardLogger, r, "test.action", "user:7", nil)
+		w.WriteHeader(http.StatusNoContent)
+	}
+	rec := serve(h.auth.RequireAuth(handler), http.MethodPost, "/test", "", token)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusNoContent, rec.Body.String())
+	}
+}
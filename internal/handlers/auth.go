@@ -1,41 +1,154 @@
 package handlers
 
 import (
-	"encoding/json"
 	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 	"log/slog"
 
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/ratelimit"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/monitoring"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
 	"github.com/amillerrr/jwt-rbac-cors-app/pkg/crypto"
 	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // AuthHandler handles authentication-related HTTP requests
 type AuthHandler struct {
-	userRepo   *models.UserRepository
-	jwtService *auth.JWTService
-	middleware *auth.Middleware
-	logger     *slog.Logger
-	metrics    *monitoring.Metrics
+	userRepo     *models.UserRepository
+	auditRepo    *models.AuditRepository
+	exportRepo   *models.ExportRepository
+	jwtService   *auth.JWTService
+	middleware   *auth.Middleware
+	maxTokenSize int
+	logger       *slog.Logger
+	metrics      *monitoring.Metrics
+
+	// passwordMaxAge expires passwords older than this at login; zero disables expiry
+	passwordMaxAge time.Duration
+	// formRedirect is where form logins are sent on success; empty disables the form flow
+	formRedirect string
+	// secureRequest reports whether a request arrived over HTTPS
+	secureRequest func(*http.Request) bool
+	// bindFingerprint binds issued tokens to the requesting client's fingerprint
+	bindFingerprint bool
+	// rehashCost is the bcrypt cost weaker hashes are upgraded to at login; zero disables rehashing
+	rehashCost int
+	// verifySender delivers email verification tokens; nil disables RequestVerification
+	verifySender VerificationSender
+	// verifyTTL is how long an email verification token is valid
+	verifyTTL time.Duration
+	// verifyRequests bounds verification emails per user
+	verifyRequests *ratelimit.KeyedLimiter
+	// resetSender delivers password reset tokens; nil disables RequestPasswordReset
+	resetSender PasswordResetSender
+	// resetTTL is how long a password reset token is valid
+	resetTTL time.Duration
+	// resetThrottle bounds reset emails per address and per client IP
+	resetThrottle *ratelimit.ResetThrottle
 }
 
 // NewAuthHandler creates a new authentication handler
-func NewAuthHandler(db database.DB, jwtSecret string, logger *slog.Logger, metrics *monitoring.Metrics) *AuthHandler {
-	jwtService := auth.NewJWTService(jwtSecret)
+func NewAuthHandler(db database.DB, jwtCfg config.JWTConfig, logger *slog.Logger, metrics *monitoring.Metrics) *AuthHandler {
+	jwtService := auth.NewJWTService(jwtCfg.Secret, jwtCfg.AccessTokenTTL, jwtCfg.RefreshTokenTTL)
+	jwtService.SetTokenStore(auth.NewMemoryTokenStore())
+	jwtService.SetLeeway(jwtCfg.Leeway)
+	jwtService.SetIssuer(jwtCfg.Issuer, jwtCfg.Audience)
+	if jwtCfg.MonotonicIAT {
+		jwtService.EnableMonotonicIssuance(logger)
+	}
+	middleware := auth.NewMiddleware(jwtService)
+	middleware.SetMaxTokenSize(jwtCfg.MaxTokenSize)
+	userRepo := models.NewUserRepository(db)
+	middleware.SetTokenVersionSource(userRepo)
+	if metrics != nil {
+		middleware.SetHeaderErrorReporting(logger, metrics.AuthHeaderErrors)
+	}
+	if metrics != nil {
+		jwtService.SetMetrics(metrics.TokenGenerations, metrics.TokenValidations)
+	}
+	if jwtCfg.BindFingerprint {
+		var failures prometheus.Counter
+		if metrics != nil {
+			failures = metrics.TokenBindingFailures
+		}
+		middleware.SetFingerprintBinding(failures)
+	}
 	return &AuthHandler{
-		userRepo:   models.NewUserRepository(db),
-		jwtService: jwtService,
-		middleware: auth.NewMiddleware(jwtService),
-		logger: logger,
-		metrics: metrics,
+		userRepo:     userRepo,
+		auditRepo:    models.NewAuditRepository(db),
+		exportRepo:   models.NewExportRepository(db),
+		jwtService:   jwtService,
+		middleware:   middleware,
+		maxTokenSize: jwtCfg.MaxTokenSize,
+		logger:       logger,
+		metrics:      metrics,
+
+		bindFingerprint: jwtCfg.BindFingerprint,
+		verifyTTL:       jwtCfg.VerifyTokenTTL,
+		verifyRequests:  ratelimit.NewKeyedLimiter(verificationRequestLimit, verificationRequestWindow),
+	}
+}
+
+// bindingOptions binds a token issued in response to r to r's client when
+// fingerprint binding is enabled
+func (h *AuthHandler) bindingOptions(r *http.Request) []auth.TokenOption {
+	if !h.bindFingerprint {
+		return nil
+	}
+	return []auth.TokenOption{auth.WithFingerprint(auth.ClientFingerprint(r))}
+}
+
+// SetPasswordMaxAge enables password expiry. Users whose password is older
+// than maxAge receive a token restricted to the given routes, through which
+// they must change it. Zero disables expiry.
+func (h *AuthHandler) SetPasswordMaxAge(maxAge time.Duration, changeRoutes ...string) {
+	h.passwordMaxAge = maxAge
+	h.middleware.SetPasswordChangeRoutes(changeRoutes...)
+}
+
+// SetRehashCost upgrades password hashes as users log in: hashes below the
+// given bcrypt cost, or made with an algorithm other than the current
+// default, are replaced with a fresh hash. Zero disables rehashing.
+func (h *AuthHandler) SetRehashCost(cost int) {
+	h.rehashCost = cost
+}
+
+// rehashPassword replaces the user's stored hash if it is weaker than the
+// current settings. Failures are only logged; the login goes ahead.
+func (h *AuthHandler) rehashPassword(user *models.User, password string) {
+	if h.rehashCost == 0 || !crypto.NeedsRehash(user.PasswordHash, h.rehashCost) {
+		return
+	}
+	passwordHash, err := crypto.HashPassword(password)
+	if err == nil {
+		err = h.userRepo.RehashPassword(user.ID, passwordHash)
+	}
+	if err != nil {
+		h.logger.Error("Failed to rehash password",
+			slog.Int("user_id", user.ID),
+			slog.String("error", err.Error()),
+			slog.String("handler", "Login"),
+		)
 	}
 }
 
+// recordLoginFailure counts a failed login attempt and its reason
+func (h *AuthHandler) recordLoginFailure(reason string) {
+	if h.metrics == nil {
+		return
+	}
+	h.metrics.LoginAttempts.WithLabelValues("failure").Inc()
+	h.metrics.LoginFailures.WithLabelValues(reason).Inc()
+}
+
 // Login handles user authentication
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -43,16 +156,21 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse login request
+	// Parse login request: HTML forms when the form flow is enabled, JSON otherwise
 	var loginReq models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&loginReq); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	formLogin := h.formRedirect != "" && isFormSubmission(r)
+	if formLogin {
+		if !decodeLoginForm(w, r, &loginReq) {
+			return
+		}
+	} else if !decodeJSON(w, r, &loginReq) {
 		return
 	}
 
 	// Validate input
-	if loginReq.Email == "" || loginReq.Password == "" {
-		http.Error(w, "Email and password are required", http.StatusBadRequest)
+	if validationErrors := validator.Validate(loginReq); validationErrors.HasErrors() {
+		h.recordLoginFailure("invalid_request")
+		writeValidationErrors(w, r, h.logger, "Login.validation", validationErrors)
 		return
 	}
 
@@ -60,18 +178,22 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	user, err := h.userRepo.GetByEmail(loginReq.Email)
 	if err != nil {
 		if err == sql.ErrNoRows {
+			h.recordLoginFailure("user_not_found")
 			http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 			return
 		}
+		h.recordLoginFailure("internal_error")
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	// Verify password
 	if !crypto.CheckPasswordHash(loginReq.Password, user.PasswordHash) {
+		h.recordLoginFailure("invalid_credentials")
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
+	h.rehashPassword(user, loginReq.Password)
 
 	// Update last login timestamp
 	if err := h.userRepo.UpdateLastLogin(user.ID); err != nil {
@@ -81,33 +203,41 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		)
 	}
 
+	// An expired password still logs in, but the token only reaches the
+	// password change routes until the password is changed
+	opts := h.bindingOptions(r)
+	passwordExpired := user.PasswordExpired(h.passwordMaxAge, time.Now())
+	if passwordExpired {
+		opts = append(opts, auth.WithPasswordExpired())
+	}
+
 	// Generate JWT token
-	token, err := h.jwtService.GenerateToken(user)
+	token, err := h.jwtService.GenerateToken(user, opts...)
 	if err != nil {
 		http.Error(w, "Error generating token", http.StatusInternalServerError)
 		return
 	}
 
-	h.metrics.LoginSuccesses.Inc()  // Add this
-	h.metrics.LoginAttempts.WithLabelValues("success").Inc()
+	if h.metrics != nil {
+		h.metrics.LoginSuccesses.Inc()
+		h.metrics.LoginAttempts.WithLabelValues("success").Inc()
+	}
+
+	// Form submissions get the token as a cookie, unless they asked for JSON
+	if h.wantsLoginRedirect(r) {
+		h.writeLoginRedirect(w, r, token)
+		return
+	}
 
 	// Prepare response
 	response := models.LoginResponse{
-		Token: token,
-		User:  *user,
+		Token:           token,
+		User:            *user,
+		PasswordExpired: passwordExpired,
 	}
 
 	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "Login"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
+	writeJSON(w, r, h.logger, "Login", http.StatusOK, response)
 }
 
 // Register handles user registration
@@ -117,29 +247,19 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.metrics != nil {
+		h.metrics.RegistrationAttempts.Inc()
+	}
+
 	// Parse registration request
 	var registerReq models.CreateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&registerReq); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !decodeJSON(w, r, &registerReq) {
 		return
 	}
 
 	// Validate input
-	validationErrors := validator.ValidateUserRegistration(registerReq.Name, registerReq.Email, registerReq.Password)
-	if validationErrors.HasErrors() {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		if err := json.NewEncoder(w).Encode(map[string]interface{}{
-			"error": "Validation failed",
-			"details": validationErrors,
-		}); err != nil {
-			h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "Register.emailExists"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-		}
+	if validationErrors := validator.Validate(registerReq); validationErrors.HasErrors() {
+		writeValidationErrors(w, r, h.logger, "Register.validation", validationErrors)
 		return
 	}
 
@@ -150,21 +270,12 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if emailExists {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusConflict)
-		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		writeJSON(w, r, h.logger, "Register.emailExists", http.StatusConflict, map[string]interface{}{
 			"error": "Email already registered",
 			"details": []validator.ValidationError{
 				{Field: "email", Message: "An account with this email already exists"},
 			},
-		}); err != nil {
-			h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "Register.emailExists"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-		}
+		})
 		return
 	}
 
@@ -186,12 +297,15 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	// Save user to database
 	if err := h.userRepo.Create(user); err != nil {
+		if writeConstraintError(w, r, h.logger, "Register.constraint", err) {
+			return
+		}
 		http.Error(w, "Failed to create user", http.StatusInternalServerError)
 		return
 	}
 
 	// Generate JWT token for immediate login
-	token, err := h.jwtService.GenerateToken(user)
+	token, err := h.jwtService.GenerateToken(user, h.bindingOptions(r)...)
 	if err != nil {
 		http.Error(w, "Error generating token", http.StatusInternalServerError)
 		return
@@ -204,16 +318,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "Register"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
+	writeJSON(w, r, h.logger, "Register", http.StatusCreated, response)
 }
 
 // RefreshToken handles token refresh requests
@@ -230,6 +335,12 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Reject oversized tokens before doing any parsing work
+	if len(authHeader) > len("Bearer ")+h.maxTokenSize {
+		http.Error(w, "Authorization header too large", http.StatusRequestHeaderFieldsTooLarge)
+		return
+	}
+
 	// Parse Bearer token
 	parts := strings.Split(authHeader, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
@@ -237,6 +348,25 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Tokens revoked by a logout-all cannot be refreshed
+	claims, err := h.jwtService.ValidateRefreshable(parts[1])
+	if err != nil {
+		http.Error(w, "Cannot refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err := h.middleware.CheckTokenVersion(claims); err != nil {
+		if errors.Is(err, auth.ErrTokenRevoked) {
+			http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.middleware.CheckFingerprint(r, claims); err != nil {
+		http.Error(w, "Cannot refresh token", http.StatusUnauthorized)
+		return
+	}
+
 	// Generate new token
 	newToken, err := h.jwtService.RefreshToken(parts[1])
 	if err != nil {
@@ -246,15 +376,48 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 
 	// Send new token
 	response := map[string]string{"token": newToken}
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "RefreshToken"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	writeJSON(w, r, h.logger, "RefreshToken", http.StatusOK, response)
+}
+
+// IntrospectToken decodes a submitted token and explains why it would fail
+// validation (admin only). It is a diagnostic aid and never authenticates.
+func (h *AuthHandler) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+
+	var req struct {
+		Token string `json:"token"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Token == "" {
+		http.Error(w, "Token is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Token) > h.maxTokenSize {
+		http.Error(w, "Token too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	claims, failures, err := h.jwtService.Inspect(req.Token)
+	if err != nil {
+		http.Error(w, "Malformed token", http.StatusBadRequest)
+		return
+	}
+	if failures == nil {
+		failures = []string{}
+	}
+
+	response := map[string]interface{}{
+		"valid":    len(failures) == 0,
+		"failures": failures,
+		"claims":   claims,
+	}
+
+	writeJSON(w, r, h.logger, "IntrospectToken", http.StatusOK, response)
 }
 
 // GetProfile returns the current user's profile
@@ -283,15 +446,126 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return user profile
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(user); err != nil {
-		h.logger.Error("Failed to encode JSON response",
+	writeJSON(w, r, h.logger, "GetProfile", http.StatusOK, user)
+}
+
+// ExportProfile returns everything stored about the current user as a
+// downloadable JSON document (data portability)
+func (h *AuthHandler) ExportProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	export, err := h.exportRepo.ExportUser(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to export user data",
+			slog.Int("user_id", userID),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Failed to export data", http.StatusInternalServerError)
+		return
+	}
+
+	recordAudit(h.auditRepo, h.logger, r, "user.export", "user:"+strconv.Itoa(userID), nil)
+
+	w.Header().Set("Content-Disposition", `attachment; filename="user-`+strconv.Itoa(userID)+`-export.json"`)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, h.logger, "ExportProfile", http.StatusOK, export)
+}
+
+// LogoutAll revokes every token issued to the current user, including the
+// one used for this request, by bumping the user's token version
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.userRepo.IncrementTokenVersion(userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to revoke tokens",
+			slog.Int("user_id", userID),
 			slog.String("error", err.Error()),
-			slog.String("handler", "GetProfile"),
 		)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+
+	h.logger.Info("User logged out of all sessions", slog.Int("user_id", userID))
+	recordAudit(h.auditRepo, h.logger, r, "user.logout_all", "user:"+strconv.Itoa(userID), nil)
+
+	writeJSON(w, r, h.logger, "LogoutAll", http.StatusOK, map[string]string{
+		"message": "All sessions have been signed out",
+	})
+}
+
+// Logout revokes the token used for this request. Other sessions of the
+// user are unaffected; see LogoutAll.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := auth.GetClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	store := h.jwtService.TokenStore()
+	if store == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		// Tokens issued before jti claims existed cannot be revoked individually
+		http.Error(w, "Token cannot be revoked; use /profile/logout-all", http.StatusBadRequest)
+		return
+	}
+	// Expired tokens stay refreshable for a while, so the revocation must
+	// outlive the token's expiry
+	store.Revoke(claims.ID, h.jwtService.RevocationDeadline(claims))
+
+	h.logger.Info("User logged out", slog.Int("user_id", claims.UserID))
+	recordAudit(h.auditRepo, h.logger, r, "user.logout", "user:"+strconv.Itoa(claims.UserID), nil)
+
+	writeJSON(w, r, h.logger, "Logout", http.StatusOK, map[string]string{
+		"message": "logged out",
+	})
+}
+
+// JWTService returns the token service, for handlers that issue tokens through other flows
+func (h *AuthHandler) JWTService() *auth.JWTService {
+	return h.jwtService
+}
+
+// SetAuthorizer resolves the roles and permissions of authenticated requests
+// through a instead of trusting those in the token
+func (h *AuthHandler) SetAuthorizer(a auth.Authorizer) {
+	h.middleware.SetAuthorizer(a)
+}
+
+// SetRoleHierarchy lets higher roles satisfy the lower roles they outrank
+// in role-protected routes; see auth.Middleware.SetRoleHierarchy
+func (h *AuthHandler) SetRoleHierarchy(hierarchy map[string][]string) {
+	h.middleware.SetRoleHierarchy(hierarchy)
 }
 
 // RequireAuth wraps handlers that require authentication
@@ -299,6 +573,17 @@ func (h *AuthHandler) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 	return h.middleware.RequireAuth(next)
 }
 
+// OptionalAuth wraps handlers that personalize responses for logged-in
+// users but also serve anonymous requests
+func (h *AuthHandler) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
+	return h.middleware.OptionalAuth(next)
+}
+
+// RequireSameOrg wraps handlers that must stay within the user's organization
+func (h *AuthHandler) RequireSameOrg(next http.HandlerFunc) http.HandlerFunc {
+	return h.middleware.RequireSameOrg(next)
+}
+
 // RequireRole wraps handlers that require a specific role
 func (h *AuthHandler) RequireRole(role string, next http.HandlerFunc) http.HandlerFunc {
 	return h.middleware.RequireRole(role)(next)
@@ -308,3 +593,8 @@ func (h *AuthHandler) RequireRole(role string, next http.HandlerFunc) http.Handl
 func (h *AuthHandler) RequireAnyRole(next http.HandlerFunc, roles ...string) http.HandlerFunc {
 	return h.middleware.RequireAnyRole(roles...)(next)
 }
+
+// RequirePermission wraps handlers that require a specific permission
+func (h *AuthHandler) RequirePermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return h.middleware.RequirePermission(permission)(next)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/crypto"
+)
+
+func TestLogoutOutlivesTokenExpiry(t *testing.T) {
+	tests := []struct {
+		name       string
+		logout     bool
+		wantStatus int
+	}{
+		{name: "logged out token", logout: true, wantStatus: http.StatusUnauthorized},
+		{name: "expired token still refreshable", logout: false, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testJWTConfig()
+			cfg.AccessTokenTTL = time.Second
+			h, mock := newTestAuthHandler(t, cfg)
+			user := &models.User{ID: 7, Email: "user@example.com", Roles: []string{"user"}}
+			token := issueToken(t, h, user)
+
+			if tt.logout {
+				expectTokenVersion(mock, user.ID, 0)
+				expectAudit(mock)
+				if rec := serve(h.RequireAuth(h.Logout), http.MethodPost, "/logout", "", token); rec.Code != http.StatusOK {
+					t.Fatalf("Logout status = %d, want %d", rec.Code, http.StatusOK)
+				}
+			} else {
+				expectTokenVersion(mock, user.ID, 0)
+			}
+
+			// exp has one-second precision, so this is always past it
+			time.Sleep(2 * time.Second)
+
+			rec := serve(h.RefreshToken, http.MethodPost, "/refresh", "", token)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("RefreshToken status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestLoginPasswordExpiry(t *testing.T) {
+	const (
+		maxAge   = 90 * 24 * time.Hour
+		password = "Passw0rd!"
+	)
+	hash, err := crypto.HashPasswordWithCost(password, bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("HashPasswordWithCost() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		passwordAge time.Duration // Zero leaves the change time unrecorded
+		wantExpired bool
+	}{
+		{name: "under the limit", passwordAge: maxAge - time.Minute, wantExpired: false},
+		{name: "at the limit", passwordAge: maxAge, wantExpired: true},
+		{name: "beyond the limit", passwordAge: maxAge + 24*time.Hour, wantExpired: true},
+		{name: "never changed", wantExpired: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock := newTestAuthHandler(t, testJWTConfig())
+			h.SetPasswordMaxAge(maxAge, "/change-password")
+
+			user := &models.User{ID: 7, Email: "user@example.com", PasswordHash: hash, Roles: []string{"user"}}
+			if tt.passwordAge > 0 {
+				changedAt := time.Now().Add(-tt.passwordAge)
+				user.PasswordChangedAt = &changedAt
+			}
+			expectUserByEmail(mock, user)
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET last_login")).WithArgs(user.ID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			rec := serve(h.Login, http.MethodPost, "/login", `{"email":"user@example.com","password":"`+password+`"}`, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Login status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+
+			var response models.LoginResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("invalid response body: %v", err)
+			}
+			if response.PasswordExpired != tt.wantExpired {
+				t.Errorf("password_expired = %v, want %v", response.PasswordExpired, tt.wantExpired)
+			}
+			claims, err := h.JWTService().ValidateToken(response.Token)
+			if err != nil {
+				t.Fatalf("ValidateToken() error = %v", err)
+			}
+			if claims.PasswordExpired != tt.wantExpired {
+				t.Errorf("token pwd_expired = %v, want %v", claims.PasswordExpired, tt.wantExpired)
+			}
+		})
+	}
+}
+
+func TestExpiredPasswordTokenRestricted(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{path: "/change-password", wantStatus: http.StatusOK},
+		{path: "/profile", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			h, mock := newTestAuthHandler(t, testJWTConfig())
+			h.SetPasswordMaxAge(90*24*time.Hour, "/change-password")
+			user := &models.User{ID: 7, Email: "user@example.com", Roles: []string{"user"}}
+			token := issueToken(t, h, user, auth.WithPasswordExpired())
+
+			expectTokenVersion(mock, user.ID, 0)
+			rec := serve(h.RequireAuth(ok), http.MethodPost, tt.path, "", token)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
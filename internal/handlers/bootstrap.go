@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/ratelimit"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/crypto"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// Bootstrap attempts allowed per client IP per window
+const (
+	bootstrapAttemptLimit  = 5
+	bootstrapAttemptWindow = 15 * time.Minute
+)
+
+// BootstrapRequest carries the one-time token and the first admin's details
+type BootstrapRequest struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Validate applies the registration rules to the first admin's details
+func (req BootstrapRequest) Validate() validator.ValidationErrors {
+	return validator.ValidateUserRegistration(req.Name, req.Email, req.Password)
+}
+
+// BootstrapHandler creates the first admin of a fresh deployment using the
+// one-time BOOTSTRAP_TOKEN. Once any admin exists the endpoint permanently
+// answers 410 Gone for the lifetime of the process.
+type BootstrapHandler struct {
+	token     []byte
+	userRepo  *models.UserRepository
+	auditRepo *models.AuditRepository
+	attempts  *ratelimit.KeyedLimiter
+	done      atomic.Bool
+	logger    *slog.Logger
+}
+
+// NewBootstrapHandler creates a new bootstrap handler for the given token
+func NewBootstrapHandler(db database.DB, token string, logger *slog.Logger) *BootstrapHandler {
+	return &BootstrapHandler{
+		token:     []byte(token),
+		userRepo:  models.NewUserRepository(db),
+		auditRepo: models.NewAuditRepository(db),
+		attempts:  ratelimit.NewKeyedLimiter(bootstrapAttemptLimit, bootstrapAttemptWindow),
+		logger:    logger,
+	}
+}
+
+// Bootstrap creates the first admin user
+func (h *BootstrapHandler) Bootstrap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.done.Load() {
+		http.Error(w, "Bootstrap is no longer available", http.StatusGone)
+		return
+	}
+
+	ip := clientIP(r)
+	if !h.attempts.Allow(ip) {
+		h.logger.Warn("Admin bootstrap rate limited", slog.String("ip", ip))
+		http.Error(w, "Too many bootstrap attempts", http.StatusTooManyRequests)
+		return
+	}
+
+	var bootstrapReq BootstrapRequest
+	if !decodeJSON(w, r, &bootstrapReq) {
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(bootstrapReq.Token), h.token) != 1 {
+		h.logger.Error("Admin bootstrap attempted with invalid token", slog.String("ip", ip))
+		http.Error(w, "Invalid bootstrap token", http.StatusUnauthorized)
+		return
+	}
+
+	exists, err := h.userRepo.AdminExists()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if exists {
+		h.done.Store(true)
+		h.logger.Warn("Admin bootstrap attempted after an admin exists", slog.String("ip", ip))
+		http.Error(w, "Bootstrap is no longer available", http.StatusGone)
+		return
+	}
+
+	if validationErrors := validator.Validate(bootstrapReq); validationErrors.HasErrors() {
+		writeValidationErrors(w, r, h.logger, "Bootstrap.validation", validationErrors)
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(bootstrapReq.Email))
+	emailExists, err := h.userRepo.EmailExists(email)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if emailExists {
+		http.Error(w, "Email already registered", http.StatusConflict)
+		return
+	}
+
+	passwordHash, err := crypto.HashPassword(bootstrapReq.Password)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// The first admin administers the organization every deployment starts with
+	user := &models.User{
+		OrgID:         models.DefaultOrgID,
+		Name:          strings.TrimSpace(bootstrapReq.Name),
+		Email:         email,
+		PasswordHash:  passwordHash,
+		EmailVerified: true,
+		IsActive:      true,
+	}
+	if err := h.userRepo.CreateFirstAdmin(user); err != nil {
+		if errors.Is(err, models.ErrAdminExists) {
+			h.done.Store(true)
+			http.Error(w, "Bootstrap is no longer available", http.StatusGone)
+			return
+		}
+		if writeConstraintError(w, r, h.logger, "Bootstrap.constraint", err) {
+			return
+		}
+		h.logger.Error("Admin bootstrap failed", slog.String("error", err.Error()))
+		http.Error(w, "Failed to create admin", http.StatusInternalServerError)
+		return
+	}
+	h.done.Store(true)
+
+	h.logger.Warn("First admin created via bootstrap; remove BOOTSTRAP_TOKEN from the environment",
+		slog.Int("user_id", user.ID),
+		slog.String("email", user.Email),
+		slog.String("ip", ip),
+	)
+	recordOrgAudit(h.auditRepo, h.logger, r, user.OrgID, "admin.bootstrap", "user:"+strconv.Itoa(user.ID), nil)
+
+	writeJSON(w, r, h.logger, "Bootstrap", http.StatusCreated, user)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// Bulk endpoints process each item independently and report per-item
+// outcomes in a BulkResult instead of failing the whole request. The
+// response status summarizes the batch:
+//
+//   - 200 OK: every item succeeded
+//   - 207 Multi-Status: some items succeeded and some failed
+//   - 422 Unprocessable Entity: no item succeeded
+//
+// Each item carries the status it would have received as a single request
+// (e.g. 201, 400, 409), so clients can retry just the failed items. Errors
+// that affect the request as a whole (bad JSON, too many items, missing
+// authorization) are still reported with a plain 4xx before any item runs.
+
+// BulkItemResult is the outcome of a single item in a bulk request
+type BulkItemResult struct {
+	Index   int                        `json:"index"` // Position of the item in the request
+	Status  int                        `json:"status"`
+	ID      int                        `json:"id,omitempty"`
+	Error   string                     `json:"error,omitempty"`
+	Details validator.ValidationErrors `json:"details,omitempty"`
+}
+
+// BulkResult is the response envelope of bulk endpoints
+type BulkResult struct {
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// Add records the outcome of one item; statuses below 400 count as successes
+func (b *BulkResult) Add(item BulkItemResult) {
+	if item.Status < http.StatusBadRequest {
+		b.Succeeded++
+	} else {
+		b.Failed++
+	}
+	b.Results = append(b.Results, item)
+}
+
+// StatusCode returns the overall response status for the batch
+func (b *BulkResult) StatusCode() int {
+	switch {
+	case b.Failed == 0:
+		return http.StatusOK
+	case b.Succeeded == 0:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusMultiStatus
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/crypto"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// ChangePassword lets the authenticated user replace their password after
+// proving they know the current one. With revoke_token set, the token used
+// for the change is revoked and the user must log in again; other sessions
+// are unaffected (see LogoutAll).
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := auth.GetClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	var changeReq models.ChangePasswordRequest
+	if !decodeJSON(w, r, &changeReq) {
+		return
+	}
+	if validationErrors := validator.Validate(changeReq); validationErrors.HasErrors() {
+		writeValidationErrors(w, r, h.logger, "ChangePassword.validation", validationErrors)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(claims.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !crypto.CheckPasswordHash(changeReq.CurrentPassword, user.PasswordHash) {
+		http.Error(w, "Current password is incorrect", http.StatusUnauthorized)
+		return
+	}
+	if crypto.CheckPasswordHash(changeReq.NewPassword, user.PasswordHash) {
+		writeValidationErrors(w, r, h.logger, "ChangePassword.unchanged", validator.ValidationErrors{
+			{Field: "new_password", Code: validator.CodeDuplicate, Message: "new password must differ from the current password"},
+		})
+		return
+	}
+
+	passwordHash, err := crypto.HashPassword(changeReq.NewPassword)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.userRepo.UpdatePassword(user.ID, passwordHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to change password",
+			slog.Int("user_id", user.ID),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	revoked := false
+	if store := h.jwtService.TokenStore(); changeReq.RevokeToken && store != nil && claims.ID != "" && claims.ExpiresAt != nil {
+		store.Revoke(claims.ID, h.jwtService.RevocationDeadline(claims))
+		revoked = true
+	}
+
+	h.logger.Info("User changed password", slog.Int("user_id", user.ID))
+	recordAudit(h.auditRepo, h.logger, r, "user.password_change", "user:"+strconv.Itoa(user.ID), map[string]interface{}{
+		"token_revoked": revoked,
+	})
+
+	writeJSON(w, r, h.logger, "ChangePassword", http.StatusOK, map[string]interface{}{
+		"message":       "Password changed",
+		"token_revoked": revoked,
+	})
+}
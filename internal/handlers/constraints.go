@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// constraintField describes how a violated database constraint is reported
+type constraintField struct {
+	field   string
+	code    string
+	message string
+	status  int
+}
+
+// constraintFields maps database constraint names to the request field they
+// guard. Handlers check these rules up front; the mapping covers races and
+// rules only the database enforces. Keep it in sync with the migrations.
+var constraintFields = map[string]constraintField{
+	"users_email_key":             {"email", validator.CodeAlreadyExists, "email is already in use", http.StatusConflict},
+	"products_price_check":        {"price", validator.CodeNegative, "price cannot be negative", http.StatusBadRequest},
+	"products_category_fkey":      {"category", validator.CodeNotAllowed, "category is not one of the allowed values", http.StatusBadRequest},
+	"products_availability_check": {"available_until", validator.CodeInvalidRange, "available_until must be later than available_from", http.StatusBadRequest},
+}
+
+// constraintErrors maps a violation of a known constraint to the status and
+// field errors to respond with. It reports false for any other error.
+func constraintErrors(err error) (int, validator.ValidationErrors, bool) {
+	name, ok := database.ConstraintViolation(err)
+	if !ok {
+		return 0, nil, false
+	}
+	mapped, ok := constraintFields[name]
+	if !ok {
+		return 0, nil, false
+	}
+
+	var errs validator.ValidationErrors
+	errs.AddCode(mapped.field, mapped.code, mapped.message)
+	return mapped.status, errs, true
+}
+
+// writeConstraintError responds with field errors if err violated a known
+// constraint, reporting whether it did
+func writeConstraintError(w http.ResponseWriter, r *http.Request, logger *slog.Logger, handler string, err error) bool {
+	status, errs, ok := constraintErrors(err)
+	if !ok {
+		return false
+	}
+
+	summary := "validation_failed"
+	if status == http.StatusConflict {
+		summary = "conflict"
+	}
+	writeFieldErrors(w, r, logger, handler, status, summary, errs)
+	return true
+}
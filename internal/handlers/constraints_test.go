@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// constraintError returns err as a repository wraps a violation of constraint
+func constraintError(code, constraint string) error {
+	return fmt.Errorf("failed to create product: %w", &pgconn.PgError{Code: code, ConstraintName: constraint})
+}
+
+func TestConstraintErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantField  string
+		wantCode   string
+		wantOK     bool
+	}{
+		{name: "users_email_key", err: constraintError("23505", "users_email_key"),
+			wantStatus: http.StatusConflict, wantField: "email", wantCode: validator.CodeAlreadyExists, wantOK: true},
+		{name: "products_price_check", err: constraintError("23514", "products_price_check"),
+			wantStatus: http.StatusBadRequest, wantField: "price", wantCode: validator.CodeNegative, wantOK: true},
+		{name: "products_category_fkey", err: constraintError("23503", "products_category_fkey"),
+			wantStatus: http.StatusBadRequest, wantField: "category", wantCode: validator.CodeNotAllowed, wantOK: true},
+		{name: "products_availability_check", err: constraintError("23514", "products_availability_check"),
+			wantStatus: http.StatusBadRequest, wantField: "available_until", wantCode: validator.CodeInvalidRange, wantOK: true},
+		{name: "unmapped constraint", err: constraintError("23505", "products_pkey")},
+		{name: "not an integrity violation", err: constraintError("42P01", "products_price_check")},
+		{name: "not a database error", err: errors.New("connection reset")},
+	}
+
+	mapped := 0
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, errs, ok := constraintErrors(tt.err)
+			if ok != tt.wantOK {
+				t.Fatalf("constraintErrors() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			mapped++
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if len(errs) != 1 || errs[0].Field != tt.wantField || errs[0].Code != tt.wantCode {
+				t.Errorf("errors = %v, want one %s error on %s", errs, tt.wantCode, tt.wantField)
+			}
+		})
+	}
+	if mapped != len(constraintFields) {
+		t.Errorf("tested %d mapped constraints, constraintFields has %d", mapped, len(constraintFields))
+	}
+}
+
+func TestCreateProductConstraintViolation(t *testing.T) {
+	h, mock := newTestHandlers(t)
+	user := &models.User{ID: 7, Email: "user@example.com", Roles: []string{"user"}}
+	token := issueToken(t, h.auth, user)
+
+	expectTokenVersion(mock, user.ID, 0)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO products")).
+		WillReturnError(&pgconn.PgError{Code: "23514", ConstraintName: "products_price_check"})
+	mock.ExpectRollback()
+
+	rec := serve(h.auth.RequireAuth(h.products.CreateProduct), http.MethodPost, "/products",
+		`{"name":"Widget","price":1}`, token)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+
+	var body struct {
+		Details validator.ValidationErrors `json:"details"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response body: %v", err)
+	}
+	if len(body.Details) != 1 || body.Details[0].Field != "price" {
+		t.Errorf("details = %v, want one error on price", body.Details)
+	}
+}
+
+func TestCreateProductDuplicateName(t *testing.T) {
+	h, mock := newTestHandlers(t)
+	user := &models.User{ID: 7, Email: "user@example.com", Roles: []string{"user"}}
+	token := issueToken(t, h.auth, user)
+
+	expectTokenVersion(mock, user.ID, 0)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO products")).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_products_user_name_unique"})
+	mock.ExpectRollback()
+
+	rec := serve(h.auth.RequireAuth(h.products.CreateProduct), http.MethodPost, "/products",
+		`{"name":"Widget","price":1}`, token)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusConflict, rec.Body.String())
+	}
+
+	var body struct {
+		Details validator.ValidationErrors `json:"details"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response body: %v", err)
+	}
+	if len(body.Details) != 1 || body.Details[0].Field != "name" || body.Details[0].Code != validator.CodeDuplicate {
+		t.Errorf("details = %v, want one %s error on name", body.Details, validator.CodeDuplicate)
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// EventHandler serves the change feed of user and product mutations (admin only)
+type EventHandler struct {
+	eventRepo *models.EventRepository
+	logger    *slog.Logger
+}
+
+// NewEventHandler creates a new event handler
+func NewEventHandler(db database.DB, logger *slog.Logger) *EventHandler {
+	return &EventHandler{
+		eventRepo: models.NewEventRepository(db),
+		logger:    logger,
+	}
+}
+
+// GetEventsSince returns the events of the caller's organization after the
+// ?id= cursor, oldest first. Consumers resume from next_id, so each event is
+// delivered at least once.
+func (h *EventHandler) GetEventsSince(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	afterID := int64(0)
+	if raw := params.Get("id"); raw != "" {
+		var err error
+		afterID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || afterID < 0 {
+			http.Error(w, "Invalid event ID", http.StatusBadRequest)
+			return
+		}
+	}
+	limit, err := parsePositiveInt(params.Get("limit"), models.DefaultEventPageSize)
+	if err != nil || limit > models.MaxEventPageSize {
+		http.Error(w, fmt.Sprintf("limit must be between 1 and %d", models.MaxEventPageSize), http.StatusBadRequest)
+		return
+	}
+
+	orgID, ok := auth.GetOrgFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	events, err := h.eventRepo.Since(orgID, afterID, limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve events", http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []models.Event{}
+	}
+
+	nextID := afterID
+	if len(events) > 0 {
+		nextID = events[len(events)-1].ID
+	}
+
+	writeJSON(w, r, h.logger, "GetEventsSince", http.StatusOK, map[string]interface{}{
+		"events":   events,
+		"next_id":  nextID,
+		"has_more": len(events) == limit,
+	})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+func TestGetEventsSinceScopedToOrg(t *testing.T) {
+	const orgID = 3
+	h, mock := newTestHandlers(t)
+	admin := &models.User{ID: 1, OrgID: orgID, Email: "admin@example.com", Roles: []string{"admin"}}
+	token := issueToken(t, h.auth, admin)
+
+	expectTokenVersion(mock, admin.ID, 0)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE org_id = $1 AND id > $2")).WithArgs(orgID, int64(10), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "event_type", "entity_type", "entity_id", "payload", "created_at"}).
+			AddRow(11, orgID, models.EventUserCreated, "user", 5, []byte(`{"id":5}`), time.Now()))
+
+	rec := serve(h.auth.RequireAuth(h.events.GetEventsSince), http.MethodGet, "/admin/events/since?id=10&limit=2", "", token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+}
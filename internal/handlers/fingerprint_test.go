@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+func TestFingerprintBinding(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	tests := []struct {
+		name       string
+		route      func(h *AuthHandler) http.HandlerFunc
+		client     string // FingerprintHeader of the presenting client
+		wantStatus int
+	}{
+		{name: "RequireAuth from bound client", route: func(h *AuthHandler) http.HandlerFunc { return h.RequireAuth(ok) }, client: "client-a", wantStatus: http.StatusOK},
+		{name: "RequireAuth from other client", route: func(h *AuthHandler) http.HandlerFunc { return h.RequireAuth(ok) }, client: "client-b", wantStatus: http.StatusUnauthorized},
+		{name: "refresh from bound client", route: func(h *AuthHandler) http.HandlerFunc { return h.RefreshToken }, client: "client-a", wantStatus: http.StatusOK},
+		{name: "refresh from other client", route: func(h *AuthHandler) http.HandlerFunc { return h.RefreshToken }, client: "client-b", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testJWTConfig()
+			cfg.BindFingerprint = true
+			h, mock := newTestAuthHandler(t, cfg)
+
+			issuedTo := httptest.NewRequest(http.MethodPost, "/login", nil)
+			issuedTo.Header.Set("User-Agent", "test-browser")
+			issuedTo.Header.Set(auth.FingerprintHeader, "client-a")
+			user := &models.User{ID: 7, Email: "user@example.com", Roles: []string{"user"}}
+			token := issueToken(t, h, user, auth.WithFingerprint(auth.ClientFingerprint(issuedTo)))
+
+			expectTokenVersion(mock, user.ID, 0)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("User-Agent", "test-browser")
+			req.Header.Set(auth.FingerprintHeader, tt.client)
+			rec := httptest.NewRecorder()
+			tt.route(h)(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/crypto"
+)
+
+func TestLoginNegotiation(t *testing.T) {
+	const (
+		redirect = "/dashboard"
+		password = "Passw0rd!"
+	)
+	hash, err := crypto.HashPasswordWithCost(password, bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("HashPasswordWithCost() error = %v", err)
+	}
+
+	jsonBody := `{"email":"user@example.com","password":"` + password + `"}`
+	formBody := url.Values{"email": {"user@example.com"}, "password": {password}}.Encode()
+	const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+	tests := []struct {
+		name         string
+		formLogin    bool
+		secure       bool
+		contentType  string
+		accept       string
+		body         string
+		wantRedirect bool
+	}{
+		{name: "JSON client", formLogin: true, contentType: "application/json", accept: "application/json", body: jsonBody},
+		{name: "JSON client without Accept", formLogin: true, contentType: "application/json", body: jsonBody},
+		{name: "form submission", formLogin: true, contentType: "application/x-www-form-urlencoded", accept: browserAccept, body: formBody, wantRedirect: true},
+		{name: "form submission over HTTPS", formLogin: true, secure: true, contentType: "application/x-www-form-urlencoded", body: formBody, wantRedirect: true},
+		{name: "form submission asking for JSON", formLogin: true, contentType: "application/x-www-form-urlencoded; charset=utf-8", accept: "application/json", body: formBody},
+		{name: "JSON client with form login disabled", contentType: "application/json", accept: browserAccept, body: jsonBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock := newTestAuthHandler(t, testJWTConfig())
+			if tt.formLogin {
+				h.SetFormLogin(redirect, func(*http.Request) bool { return tt.secure })
+			}
+
+			user := &models.User{ID: 7, Email: "user@example.com", PasswordHash: hash, Roles: []string{"user"}}
+			expectUserByEmail(mock, user)
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET last_login")).WithArgs(user.ID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.Login(rec, req)
+
+			cookies := rec.Result().Cookies()
+			if !tt.wantRedirect {
+				if rec.Code != http.StatusOK {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+				}
+				var response models.LoginResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || response.Token == "" {
+					t.Errorf("body = %q, want a login response with a token", rec.Body.String())
+				}
+				if len(cookies) != 0 {
+					t.Errorf("cookies = %v, want none", cookies)
+				}
+				return
+			}
+
+			if rec.Code != http.StatusSeeOther {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusSeeOther, rec.Body.String())
+			}
+			if got := rec.Header().Get("Location"); got != redirect {
+				t.Errorf("Location = %q, want %q", got, redirect)
+			}
+			if len(cookies) != 1 || cookies[0].Name != LoginCookieName {
+				t.Fatalf("cookies = %v, want one %s cookie", cookies, LoginCookieName)
+			}
+			cookie := cookies[0]
+			if !cookie.HttpOnly || cookie.Secure != tt.secure || cookie.SameSite != http.SameSiteLaxMode {
+				t.Errorf("cookie HttpOnly = %v, Secure = %v, SameSite = %v; want true, %v, Lax",
+					cookie.HttpOnly, cookie.Secure, cookie.SameSite, tt.secure)
+			}
+			if _, err := h.JWTService().ValidateToken(cookie.Value); err != nil {
+				t.Errorf("cookie token invalid: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoginFormDisabled(t *testing.T) {
+	h, _ := newTestAuthHandler(t, testJWTConfig())
+
+	req := httptest.NewRequest(http.MethodPost, "/login",
+		strings.NewReader(url.Values{"email": {"user@example.com"}, "password": {"Passw0rd!"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.Login(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"database/sql"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database/dbtest"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// testSecret signs every token issued in handler tests
+const testSecret = "handlers-test-secret-at-least-32-bytes"
+
+// discardLogger drops everything handlers log
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// testJWTConfig returns the token settings handler tests start from
+func testJWTConfig() config.JWTConfig {
+	return config.JWTConfig{
+		Secret:          testSecret,
+		MaxTokenSize:    auth.DefaultMaxTokenSize,
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: 24 * time.Hour,
+	}
+}
+
+// newTestAuthHandler creates an AuthHandler over a mocked database
+func newTestAuthHandler(t *testing.T, cfg config.JWTConfig) (*AuthHandler, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := dbtest.New(t)
+	return NewAuthHandler(db, cfg, discardLogger, dbtest.Metrics(t)), mock
+}
+
+// testHandlers are handlers sharing one mocked database
+type testHandlers struct {
+	auth     *AuthHandler
+	products *ProductHandler
+	admin    *AdminHandler
+	events   *EventHandler
+}
+
+// newTestHandlers creates handlers over one mocked database
+func newTestHandlers(t *testing.T) (*testHandlers, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := dbtest.New(t)
+	return &testHandlers{
+		auth:     NewAuthHandler(db, testJWTConfig(), discardLogger, dbtest.Metrics(t)),
+		products: NewProductHandler(db, config.ProductConfig{}, nil, discardLogger),
+		admin:    NewAdminHandler(db, discardLogger),
+		events:   NewEventHandler(db, discardLogger),
+	}, mock
+}
+
+// issueToken returns an access token for user, failing the test on error
+func issueToken(t *testing.T, h *AuthHandler, user *models.User, opts ...auth.TokenOption) string {
+	t.Helper()
+	token, err := h.JWTService().GenerateToken(user, opts...)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	return token
+}
+
+// expectTokenVersion expects RequireAuth's token version lookup for userID
+func expectTokenVersion(mock sqlmock.Sqlmock, userID, version int) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT token_version FROM users")).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"token_version"}).AddRow(version))
+}
+
+// expectAudit expects one audit log entry to be written
+func expectAudit(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO audit_logs")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectEvent expects one change feed event to be recorded
+func expectEvent(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta("pg_advisory_xact_lock(hashtext('events.order'))")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO events")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// serve runs handler on a request with the given method, path, body and
+// bearer token (omitted when empty) and returns the recorded response
+func serve(handler http.HandlerFunc, method, path, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+// expectUserByEmail expects the lookup of user by email, with its roles and
+// permissions
+func expectUserByEmail(mock sqlmock.Sqlmock, user *models.User) {
+	now := time.Now()
+	var passwordChangedAt interface{}
+	if user.PasswordChangedAt != nil {
+		passwordChangedAt = *user.PasswordChangedAt
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE u.email = $1")).WithArgs(user.Email).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "org_id", "name", "email", "password_hash", "email_verified",
+			"is_active", "last_login", "created_at", "updated_at", "token_version",
+			"password_changed_at",
+		}).AddRow(user.ID, user.OrgID, user.Name, user.Email, user.PasswordHash, true,
+			true, nil, now, now, 0, passwordChangedAt))
+
+	roles := sqlmock.NewRows([]string{"name"})
+	for _, role := range user.Roles {
+		roles.AddRow(role)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("JOIN user_roles ur ON r.id = ur.role_id")).WithArgs(user.ID).
+		WillReturnRows(roles)
+
+	permissions := sqlmock.NewRows([]string{"name"})
+	for _, permission := range user.Permissions {
+		permissions.AddRow(permission)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("FROM permissions p")).WithArgs(user.ID).
+		WillReturnRows(permissions)
+}
+
+// expectNoUserByEmail expects a lookup of email that finds no user
+func expectNoUserByEmail(mock sqlmock.Sqlmock, email string) {
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE u.email = $1")).WithArgs(email).
+		WillReturnError(sql.ErrNoRows)
+}
+
+// productColumns are the columns scanned by product queries, in order
+var productColumns = []string{
+	"id", "org_id", "name", "description", "price", "user_id", "category",
+	"image_url", "image_key", "is_active", "created_at", "updated_at",
+	"available_from", "available_until",
+}
+
+// productRows returns n rows in the column order scanned by product listings
+func productRows(n int) *sqlmock.Rows {
+	rows := sqlmock.NewRows(productColumns)
+	for i := 1; i <= n; i++ {
+		rows.AddRow(i, models.DefaultOrgID, "Product", "", 9.99, 7, "", "", "", true, time.Now(), time.Now(), nil, nil)
+	}
+	return rows
+}
+
+// userRows returns n rows in the column order scanned by user listings
+func userRows(n int) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "org_id", "name", "email", "email_verified", "is_active", "last_login",
+		"created_at", "updated_at", "password_changed_at",
+	})
+	for i := 1; i <= n; i++ {
+		rows.AddRow(i, models.DefaultOrgID, "User", "user@example.com", true, true, nil, time.Now(), time.Now(), nil)
+	}
+	return rows
+}
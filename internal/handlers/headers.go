@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// totalCountHeader carries the number of items matching a listing's filters
+const totalCountHeader = "X-Total-Count"
+
+// setTotalCount sets the X-Total-Count header; call it before writing the body
+func setTotalCount(w http.ResponseWriter, total int) {
+	w.Header().Set(totalCountHeader, strconv.Itoa(total))
+}
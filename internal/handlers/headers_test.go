@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+func TestListTotalCountHeader(t *testing.T) {
+	user := &models.User{ID: 7, OrgID: models.DefaultOrgID, Email: "user@example.com", Roles: []string{"user"}}
+
+	tests := []struct {
+		name   string
+		path   string
+		route  func(h *testHandlers) http.HandlerFunc
+		expect func(mock sqlmock.Sqlmock)
+		want   string
+	}{
+		{
+			name:  "GetProducts counts with the listing's filters",
+			path:  "/products?category=books&q=go",
+			route: func(h *testHandlers) http.HandlerFunc { return h.products.GetProducts },
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM products")).WillReturnRows(productRows(2))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM products WHERE org_id = $1 AND is_active = true AND category = $2")).
+					WithArgs(models.DefaultOrgID, "books", "%go%", user.ID).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(57))
+			},
+			want: "57",
+		},
+		{
+			name:  "GetMyProducts",
+			path:  "/my-products?limit=1",
+			route: func(h *testHandlers) http.HandlerFunc { return h.products.GetMyProducts },
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM products")).WillReturnRows(productRows(1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM products WHERE user_id = $1")).WithArgs(user.ID).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+			},
+			want: "3",
+		},
+		{
+			name:  "GetAllUsers",
+			path:  "/admin/users",
+			route: func(h *testHandlers) http.HandlerFunc { return h.admin.GetAllUsers },
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM users")).WillReturnRows(userRows(2))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM users WHERE org_id = $1")).WithArgs(models.DefaultOrgID).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+			},
+			want: "2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock := newTestHandlers(t)
+			token := issueToken(t, h.auth, user)
+
+			expectTokenVersion(mock, user.ID, 0)
+			tt.expect(mock)
+
+			rec := serve(h.auth.RequireAuth(tt.route(h)), http.MethodGet, tt.path, "", token)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+			if got := rec.Header().Get(totalCountHeader); got != tt.want {
+				t.Errorf("%s = %q, want %q", totalCountHeader, got, tt.want)
+			}
+		})
+	}
+}
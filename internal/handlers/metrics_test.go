@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/crypto"
+)
+
+func TestLoginMetrics(t *testing.T) {
+	const password = "Passw0rd!"
+	hash, err := crypto.HashPasswordWithCost(password, bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("HashPasswordWithCost() error = %v", err)
+	}
+	user := &models.User{ID: 7, Email: "user@example.com", PasswordHash: hash, Roles: []string{"user"}}
+
+	tests := []struct {
+		name        string
+		body        string
+		expect      func(mock sqlmock.Sqlmock)
+		wantStatus  int
+		wantFailure string // LoginFailures reason; empty for a successful login
+	}{
+		{
+			name: "success",
+			body: `{"email":"user@example.com","password":"` + password + `"}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectUserByEmail(mock, user)
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET last_login")).WithArgs(user.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "invalid request",
+			body:        `{"email":"user@example.com"}`,
+			expect:      func(sqlmock.Sqlmock) {},
+			wantStatus:  http.StatusBadRequest,
+			wantFailure: "invalid_request",
+		},
+		{
+			name:        "unknown user",
+			body:        `{"email":"nobody@example.com","password":"` + password + `"}`,
+			expect:      func(mock sqlmock.Sqlmock) { expectNoUserByEmail(mock, "nobody@example.com") },
+			wantStatus:  http.StatusUnauthorized,
+			wantFailure: "user_not_found",
+		},
+		{
+			name:        "wrong password",
+			body:        `{"email":"user@example.com","password":"Wr0ngPassword!"}`,
+			expect:      func(mock sqlmock.Sqlmock) { expectUserByEmail(mock, user) },
+			wantStatus:  http.StatusUnauthorized,
+			wantFailure: "invalid_credentials",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock := newTestAuthHandler(t, testJWTConfig())
+			m := h.metrics
+			tt.expect(mock)
+
+			counters := map[string]prometheus.Counter{
+				"successes":         m.LoginSuccesses,
+				"attempts{success}": m.LoginAttempts.WithLabelValues("success"),
+				"attempts{failure}": m.LoginAttempts.WithLabelValues("failure"),
+				"token generations": m.TokenGenerations,
+				"registrations":     m.RegistrationAttempts,
+			}
+			if tt.wantFailure != "" {
+				counters["failures{reason}"] = m.LoginFailures.WithLabelValues(tt.wantFailure)
+			}
+			before := make(map[string]float64, len(counters))
+			for name, c := range counters {
+				before[name] = testutil.ToFloat64(c)
+			}
+
+			rec := serve(h.Login, http.MethodPost, "/login", tt.body, "")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Login status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+
+			want := map[string]float64{
+				"successes":         1,
+				"attempts{success}": 1,
+				"token generations": 1,
+			}
+			if tt.wantFailure != "" {
+				want = map[string]float64{"attempts{failure}": 1, "failures{reason}": 1}
+			}
+			for name, c := range counters {
+				if got := testutil.ToFloat64(c) - before[name]; got != want[name] {
+					t.Errorf("%s moved by %v, want %v", name, got, want[name])
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	h, _ := newTestAuthHandler(t, testJWTConfig())
+	before := testutil.ToFloat64(h.metrics.RegistrationAttempts)
+
+	// Attempts are counted before the request is even validated
+	rec := serve(h.Register, http.MethodPost, "/register", `{}`, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Register status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if got := testutil.ToFloat64(h.metrics.RegistrationAttempts) - before; got != 1 {
+		t.Errorf("registration attempts moved by %v, want 1", got)
+	}
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/crypto"
+)
+
+const (
+	googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+
+	oauthStateCookie     = "oauth_state"
+	oauthVerifierCookie  = "oauth_verifier"
+	oauthCookieTTL       = 10 * time.Minute
+	oauthExchangeTimeout = 10 * time.Second
+)
+
+var (
+	errIdentityDisabled   = errors.New("account is disabled")
+	errIdentityUnverified = errors.New("a verified email address is required")
+)
+
+// googleUserInfo is the subset of the OIDC userinfo response we rely on
+type googleUserInfo struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// OAuthHandler handles sign-in through external OAuth2/OIDC providers
+type OAuthHandler struct {
+	google          *oauth2.Config
+	userRepo        *models.UserRepository
+	identityRepo    *models.IdentityRepository
+	jwtService      *auth.JWTService
+	successRedirect string
+	secureCookies   bool
+	logger          *slog.Logger
+}
+
+// NewOAuthHandler creates a new OAuth handler that issues tokens with jwtService
+func NewOAuthHandler(db database.DB, cfg config.OAuthConfig, jwtService *auth.JWTService, logger *slog.Logger) *OAuthHandler {
+	return &OAuthHandler{
+		google: &oauth2.Config{
+			ClientID:     cfg.GoogleClientID,
+			ClientSecret: cfg.GoogleClientSecret,
+			RedirectURL:  cfg.GoogleRedirectURL,
+			Endpoint:     endpoints.Google,
+			Scopes:       []string{"openid", "email", "profile"},
+		},
+		userRepo:        models.NewUserRepository(db),
+		identityRepo:    models.NewIdentityRepository(db),
+		jwtService:      jwtService,
+		successRedirect: cfg.SuccessRedirect,
+		secureCookies:   strings.HasPrefix(cfg.GoogleRedirectURL, "https://"),
+		logger:          logger,
+	}
+}
+
+// GoogleLogin redirects the browser to Google's consent screen
+func (h *OAuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	state, err := randomToken(32)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	h.setCookie(w, oauthStateCookie, state, int(oauthCookieTTL.Seconds()))
+	h.setCookie(w, oauthVerifierCookie, verifier, int(oauthCookieTTL.Seconds()))
+
+	authURL := h.google.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// GoogleCallback completes the Google sign-in: it exchanges the code, resolves
+// or creates the local user, and hands our own JWT to the frontend
+func (h *OAuthHandler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stateCookie, err := r.Cookie(oauthStateCookie)
+	verifierCookie, verr := r.Cookie(oauthVerifierCookie)
+	h.setCookie(w, oauthStateCookie, "", -1)
+	h.setCookie(w, oauthVerifierCookie, "", -1)
+	if err != nil || verr != nil {
+		http.Error(w, "Sign-in session expired, please try again", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(stateCookie.Value)) != 1 {
+		http.Error(w, "Invalid OAuth state", http.StatusBadRequest)
+		return
+	}
+	if errParam := query.Get("error"); errParam != "" {
+		http.Error(w, "Sign-in was cancelled or denied", http.StatusUnauthorized)
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		http.Error(w, "Authorization code required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), oauthExchangeTimeout)
+	defer cancel()
+
+	oauthToken, err := h.google.Exchange(ctx, code, oauth2.VerifierOption(verifierCookie.Value))
+	if err != nil {
+		h.logger.Warn("OAuth code exchange failed",
+			slog.String("provider", models.IdentityProviderGoogle),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Sign-in failed", http.StatusUnauthorized)
+		return
+	}
+
+	info, err := h.fetchGoogleUserInfo(ctx, oauthToken)
+	if err != nil {
+		h.logger.Warn("Failed to fetch OAuth user info",
+			slog.String("provider", models.IdentityProviderGoogle),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Sign-in failed", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.resolveUser(models.IdentityProviderGoogle, info)
+	switch {
+	case err == nil:
+	case errors.Is(err, errIdentityDisabled):
+		http.Error(w, "Account is disabled", http.StatusForbidden)
+		return
+	case errors.Is(err, errIdentityUnverified):
+		http.Error(w, "A verified email address is required", http.StatusForbidden)
+		return
+	default:
+		h.logger.Error("Failed to resolve OAuth user",
+			slog.String("provider", models.IdentityProviderGoogle),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	token, err := h.jwtService.GenerateToken(user)
+	if err != nil {
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+
+	// The token travels in the fragment so it never reaches server logs or Referer headers
+	http.Redirect(w, r, h.successRedirect+"#token="+url.QueryEscape(token), http.StatusSeeOther)
+}
+
+// resolveUser finds the user linked to the provider account, linking an
+// existing account by verified email or creating a new one as needed
+func (h *OAuthHandler) resolveUser(provider string, info *googleUserInfo) (*models.User, error) {
+	userID, err := h.identityRepo.GetUserID(provider, info.Subject)
+	if err == nil {
+		user, err := h.userRepo.GetByID(userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errIdentityDisabled
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := h.identityRepo.UpdateLastLogin(provider, info.Subject); err != nil {
+			h.logger.Warn("Failed to update identity last login", slog.String("error", err.Error()))
+		}
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// Only a provider-verified email may be trusted to link or create an account
+	if !info.EmailVerified || info.Email == "" {
+		return nil, errIdentityUnverified
+	}
+	email := strings.ToLower(strings.TrimSpace(info.Email))
+
+	user, err := h.userRepo.GetByEmail(email)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		exists, err := h.userRepo.EmailExists(email)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, errIdentityDisabled
+		}
+		if user, err = h.createUser(email, info.Name); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if err := h.identityRepo.Link(user.ID, provider, info.Subject, email); err != nil {
+		return nil, err
+	}
+	h.logger.Info("Linked external identity",
+		slog.String("provider", provider),
+		slog.Int("user_id", user.ID),
+	)
+
+	return user, nil
+}
+
+// createUser registers a user who signed in through a provider. The account
+// gets an unguessable password, so it can only be used through the provider
+// until a password is set.
+func (h *OAuthHandler) createUser(email, name string) (*models.User, error) {
+	password, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := crypto.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+
+	user := &models.User{
+		Name:          name,
+		Email:         email,
+		PasswordHash:  passwordHash,
+		EmailVerified: true, // Verified by the identity provider
+		IsActive:      true,
+	}
+	if err := h.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// fetchGoogleUserInfo retrieves the signed-in user's OIDC profile
+func (h *OAuthHandler) fetchGoogleUserInfo(ctx context.Context, token *oauth2.Token) (*googleUserInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, googleUserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.google.Client(ctx, token).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo returned status %d", resp.StatusCode)
+	}
+
+	var info googleUserInfo
+	if err := json.NewDecoder(http.MaxBytesReader(nil, resp.Body, 1<<20)).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode userinfo: %w", err)
+	}
+	if info.Subject == "" {
+		return nil, fmt.Errorf("userinfo is missing the subject")
+	}
+	return &info, nil
+}
+
+// setCookie sets a short-lived cookie scoped to the OAuth routes; a negative
+// maxAge deletes it
+func (h *OAuthHandler) setCookie(w http.ResponseWriter, name, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/auth/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// randomToken returns n random bytes encoded as URL-safe base64
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
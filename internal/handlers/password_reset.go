@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/ratelimit"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/crypto"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// PasswordResetSender delivers a password reset token to the user's
+// address, typically as a link to a frontend page that posts it to
+// /password-reset
+type PasswordResetSender interface {
+	SendPasswordReset(ctx context.Context, user *models.User, token string) error
+}
+
+// PasswordResetRequest names the account a reset is requested for
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+// PasswordResetConfirmation carries a reset token and the new password
+type PasswordResetConfirmation struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// passwordResetAccepted is the one answer to every well-formed reset
+// request, so responses never reveal which emails have accounts
+const passwordResetAccepted = "If an account exists for that email, a password reset link has been sent"
+
+// SetPasswordReset configures reset token lifetime and throttling
+func (h *AuthHandler) SetPasswordReset(cfg config.PasswordResetConfig) {
+	h.resetTTL = cfg.TokenTTL
+	h.resetThrottle = ratelimit.NewResetThrottle(cfg)
+}
+
+// SetPasswordResetSender enables RequestPasswordReset, delivering tokens
+// through sender. Without one reset requests are answered 503.
+func (h *AuthHandler) SetPasswordResetSender(sender PasswordResetSender) {
+	h.resetSender = sender
+}
+
+// RequestPasswordReset emails a single-use reset token to the account with
+// the given email. Every well-formed request gets the same 200 answer,
+// whether or not the account exists or the send was throttled, and the
+// email is sent in the background so timing does not tell them apart either.
+func (h *AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.resetSender == nil || h.resetThrottle == nil {
+		http.Error(w, "Password reset is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var resetReq PasswordResetRequest
+	if !decodeJSON(w, r, &resetReq) {
+		return
+	}
+	resetReq.Email = strings.TrimSpace(resetReq.Email)
+	if err := validator.ValidateEmail(resetReq.Email); err != nil {
+		var errs validator.ValidationErrors
+		errs.AddError("email", err)
+		writeValidationErrors(w, r, h.logger, "RequestPasswordReset.validation", errs)
+		return
+	}
+
+	h.startPasswordReset(r, resetReq.Email)
+
+	writeJSON(w, r, h.logger, "RequestPasswordReset", http.StatusOK, map[string]interface{}{
+		"message": passwordResetAccepted,
+	})
+}
+
+// startPasswordReset sends a reset token if the account exists and the
+// throttle allows it. Every outcome is only logged, never reported to the
+// client.
+func (h *AuthHandler) startPasswordReset(r *http.Request, email string) {
+	if allowed, reason := h.resetThrottle.Allow(email, clientIP(r)); !allowed {
+		if h.metrics != nil {
+			h.metrics.PasswordResetSuppressed.WithLabelValues(reason).Inc()
+		}
+		return
+	}
+
+	user, err := h.userRepo.GetByEmail(email)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			h.logger.Error("Failed to look up user for password reset",
+				slog.String("error", err.Error()),
+			)
+		}
+		return
+	}
+
+	token, err := h.jwtService.GeneratePurposeToken(user, auth.TokenTypeReset, h.resetTTL)
+	if err != nil {
+		h.logger.Error("Failed to generate password reset token",
+			slog.Int("user_id", user.ID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	ctx := context.WithoutCancel(r.Context())
+	go func() {
+		if err := h.resetSender.SendPasswordReset(ctx, user, token); err != nil {
+			h.logger.Error("Failed to send password reset email",
+				slog.Int("user_id", user.ID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}()
+}
+
+// ResetPassword consumes a reset token and replaces the user's password,
+// signing them out of every session. Tokens are single-use and stop
+// working once any reset succeeds.
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var resetReq PasswordResetConfirmation
+	if !decodeJSON(w, r, &resetReq) {
+		return
+	}
+	resetReq.Token = strings.TrimSpace(resetReq.Token)
+	if err := validator.ValidatePassword(resetReq.NewPassword); err != nil {
+		var errs validator.ValidationErrors
+		errs.AddError("new_password", err)
+		writeValidationErrors(w, r, h.logger, "ResetPassword.validation", errs)
+		return
+	}
+	if resetReq.Token == "" || len(resetReq.Token) > h.maxTokenSize {
+		http.Error(w, "Invalid or expired reset token", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := h.jwtService.ConsumePurposeToken(resetReq.Token, auth.TokenTypeReset)
+	if err != nil {
+		http.Error(w, "Invalid or expired reset token", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(claims.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Invalid or expired reset token", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !strings.EqualFold(user.Email, claims.Email) || user.TokenVersion != claims.TokenVersion {
+		http.Error(w, "Invalid or expired reset token", http.StatusBadRequest)
+		return
+	}
+
+	passwordHash, err := crypto.HashPassword(resetReq.NewPassword)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.userRepo.UpdatePasswordHash(user.ID, passwordHash); err != nil {
+		h.logger.Error("Failed to reset password",
+			slog.Int("user_id", user.ID),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	recordOrgAudit(h.auditRepo, h.logger, r, user.OrgID, "user.password_reset", "user:"+strconv.Itoa(user.ID), map[string]interface{}{
+		"self_service": true,
+	})
+
+	writeJSON(w, r, h.logger, "ResetPassword", http.StatusOK, map[string]interface{}{
+		"message": "Password reset; please log in with your new password",
+	})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// recordingResetSender reports the address of every reset email sent
+type recordingResetSender struct {
+	sent chan string
+}
+
+func (s *recordingResetSender) SendPasswordReset(ctx context.Context, user *models.User, token string) error {
+	s.sent <- user.Email
+	return nil
+}
+
+func TestRequestPasswordResetHidesAccounts(t *testing.T) {
+	const existing = "user@example.com"
+
+	tests := []struct {
+		name     string
+		email    string
+		wantSent bool
+	}{
+		{name: "existing email", email: existing, wantSent: true},
+		{name: "unknown email", email: "nobody@example.com"},
+	}
+
+	var bodies []string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock := newTestAuthHandler(t, testJWTConfig())
+			h.SetPasswordReset(config.PasswordResetConfig{TokenTTL: time.Hour})
+			sender := &recordingResetSender{sent: make(chan string, 1)}
+			h.SetPasswordResetSender(sender)
+
+			if tt.email == existing {
+				expectUserByEmail(mock, &models.User{ID: 7, Email: existing, Roles: []string{"user"}})
+			} else {
+				expectNoUserByEmail(mock, tt.email)
+			}
+
+			rec := serve(h.RequestPasswordReset, http.MethodPost, "/password-reset/request", `{"email":"`+tt.email+`"}`, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("RequestPasswordReset status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			bodies = append(bodies, rec.Body.String())
+
+			select {
+			case email := <-sender.sent:
+				if !tt.wantSent {
+					t.Errorf("reset email sent to %q, want none", email)
+				}
+			case <-time.After(time.Second):
+				if tt.wantSent {
+					t.Error("no reset email sent")
+				}
+			}
+		})
+	}
+
+	if len(bodies) == len(tests) && bodies[0] != bodies[1] {
+		t.Errorf("responses differ:\n%s\n%s", bodies[0], bodies[1])
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+)
+
+// PermissionsHandler reports what the current user is allowed to do, so
+// clients can render capability-aware UIs without hard-coding role logic
+type PermissionsHandler struct {
+	routeRoles map[string][]string
+	logger     *slog.Logger
+}
+
+// NewPermissionsHandler creates a permissions handler over the effective
+// route policy (route pattern -> roles allowed to call it). The map is read
+// on every request, so routes registered after construction are included.
+func NewPermissionsHandler(routeRoles map[string][]string, logger *slog.Logger) *PermissionsHandler {
+	return &PermissionsHandler{
+		routeRoles: routeRoles,
+		logger:     logger,
+	}
+}
+
+// GetPermissions returns the roles and permissions of the authenticated user
+// and the role-protected routes they can call. Everything is resolved from the token
+// claims and the in-memory policy, so the endpoint never touches the database.
+func (h *PermissionsHandler) GetPermissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+	roles, _ := auth.GetUserRolesFromContext(r.Context())
+	if roles == nil {
+		roles = []string{}
+	}
+	permissions, _ := auth.GetUserPermissionsFromContext(r.Context())
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	routes := []string{}
+	for pattern, allowed := range h.routeRoles {
+		if auth.HasAnyRole(r.Context(), allowed...) {
+			routes = append(routes, pattern)
+		}
+	}
+	sort.Strings(routes)
+
+	// Responses differ per user and change when roles do
+	w.Header().Set("Cache-Control", "private, no-store")
+	writeJSON(w, r, h.logger, "GetPermissions", http.StatusOK, map[string]interface{}{
+		"user_id":     userID,
+		"roles":       roles,
+		"permissions": permissions,
+		"routes":      routes,
+	})
+}
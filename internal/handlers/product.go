@@ -1,30 +1,100 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
-	"log/slog"
+	"time"
 
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
-	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/storage"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/httputil"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
 )
 
+// productListSpec is the list parameters product listings accept
+var productListSpec = httputil.ListSpec{
+	DefaultLimit: 20,
+	MaxLimit:     100,
+	SortFields:   models.ProductSortFields,
+	Filters:      []string{"category", "q"},
+}
+
+// ProductPage is one page of a product listing
+type ProductPage struct {
+	Products []models.Product `json:"products"`
+	Total    int              `json:"total"`
+	Limit    int              `json:"limit"`
+	Offset   int              `json:"offset"`
+}
+
 // ProductHandler handles product-related HTTP requests
 type ProductHandler struct {
-	productRepo *models.ProductRepository
-	logger *slog.Logger
+	productRepo   *models.ProductRepository
+	categoryRepo  *models.CategoryRepository
+	roleLimits    map[string]int
+	storage       storage.Storage
+	maxImageBytes int64
+	logger        *slog.Logger
 }
 
 // NewProductHandler creates a new product handler
-func NewProductHandler(db database.DB, logger *slog.Logger) *ProductHandler {
+func NewProductHandler(db database.DB, cfg config.ProductConfig, store storage.Storage, logger *slog.Logger) *ProductHandler {
 	return &ProductHandler{
-		productRepo: models.NewProductRepository(db),
-		logger: logger,
+		productRepo:   models.NewProductRepository(db),
+		categoryRepo:  models.NewCategoryRepository(db),
+		roleLimits:    cfg.RoleLimits,
+		storage:       store,
+		maxImageBytes: cfg.MaxImageBytes,
+		logger:        logger,
+	}
+}
+
+// HandleProducts dispatches /products by method: GET lists, POST creates
+func (h *ProductHandler) HandleProducts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetProducts(w, r)
+	case http.MethodPost:
+		h.CreateProduct(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleProductPath dispatches the /products/ subtree: the bare path lists
+// and creates like /products, and /products/{id} fetches (GET) or updates
+// (PUT) one product
+func (h *ProductHandler) HandleProductPath(w http.ResponseWriter, r *http.Request) {
+	id := productPathID(r)
+	if id == "" {
+		h.HandleProducts(w, r)
+		return
 	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.GetProduct(w, r)
+	case http.MethodPut:
+		r.SetPathValue("id", id)
+		h.UpdateProduct(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// productPathID returns what follows /products/ in the request path,
+// without surrounding slashes
+func productPathID(r *http.Request) string {
+	return strings.Trim(strings.TrimPrefix(r.URL.Path, "/products/"), "/")
 }
 
 // GetProducts returns all products (protected endpoint)
@@ -34,24 +104,52 @@ func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Scope the listing to the user's organization
+	orgID, ok := auth.GetOrgFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	params, paramErrs := httputil.ParseListParams(r, productListSpec)
+	if paramErrs.HasErrors() {
+		writeValidationErrors(w, r, h.logger, "GetProducts.params", paramErrs)
+		return
+	}
+
 	// Get products from database
-	products, err := h.productRepo.GetAll()
+	userID, _ := auth.GetUserIDFromContext(r.Context())
+	opts := models.ProductListOptions{
+		Category:           params.Filters["category"],
+		Search:             params.Filters["q"],
+		Sort:               params.Sort,
+		Limit:              params.Limit,
+		Offset:             params.Offset,
+		IncludeUnavailable: auth.HasAnyRole(r.Context(), "admin"),
+		OwnerID:            userID,
+	}
+	products, err := h.productRepo.GetAllByOrg(orgID, opts)
 	if err != nil {
 		http.Error(w, "Failed to retrieve products", http.StatusInternalServerError)
 		return
 	}
 
-	// Return products as JSON
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(products); err != nil {
-		h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "GetProducts"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	total, err := h.productRepo.CountByOrg(orgID, opts)
+	if err != nil {
+		http.Error(w, "Failed to retrieve products", http.StatusInternalServerError)
 		return
 	}
+	setTotalCount(w, total)
+
+	if products == nil {
+		products = []models.Product{}
+	}
+	writeJSON(w, r, h.logger, "GetProducts", http.StatusOK, ProductPage{
+		Products: products,
+		Total:    total,
+		Limit:    params.Limit,
+		Offset:   params.Offset,
+	})
 }
 
 // GetProduct returns a specific product by ID
@@ -61,8 +159,8 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract product ID from URL path
-	path := strings.TrimPrefix(r.URL.Path, "/products/")
+	// Extract product ID from URL path, tolerating a trailing slash
+	path := productPathID(r)
 	if path == "" {
 		http.Error(w, "Product ID required", http.StatusBadRequest)
 		return
@@ -85,19 +183,19 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Return product as JSON
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(product); err != nil {
-		h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "GetProduct"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	// Products from other organizations, and scheduled or expired ones the
+	// caller may not see, are indistinguishable from missing ones
+	if orgID, ok := auth.GetOrgFromContext(r.Context()); !ok || product.OrgID != orgID || !canViewProduct(r.Context(), product) {
+		http.Error(w, "Product not found", http.StatusNotFound)
 		return
 	}
+
+	// Return product as JSON
+	writeJSON(w, r, h.logger, "GetProduct", http.StatusOK, product)
 }
 
-// GetMyProducts returns products created by the current user
+// GetMyProducts returns one page of the products created by the current
+// user, in the same envelope as GetProducts
 func (h *ProductHandler) GetMyProducts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -111,22 +209,39 @@ func (h *ProductHandler) GetMyProducts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	params, paramErrs := httputil.ParseListParams(r, productListSpec)
+	if paramErrs.HasErrors() {
+		writeValidationErrors(w, r, h.logger, "GetMyProducts.params", paramErrs)
+		return
+	}
+
 	// Get user's products from database
-	products, err := h.productRepo.GetByUserID(userID)
+	products, err := h.productRepo.GetByUserID(userID, models.ProductListOptions{
+		Sort:   params.Sort,
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	})
 	if err != nil {
 		http.Error(w, "Failed to retrieve products", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(products); err != nil {
-		h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "GetMyProducts"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	total, err := h.productRepo.CountByUserID(userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve products", http.StatusInternalServerError)
 		return
 	}
+	setTotalCount(w, total)
+
+	if products == nil {
+		products = []models.Product{}
+	}
+	writeJSON(w, r, h.logger, "GetMyProducts", http.StatusOK, ProductPage{
+		Products: products,
+		Total:    total,
+		Limit:    params.Limit,
+		Offset:   params.Offset,
+	})
 }
 
 // CreateProduct creates a new product (authenticated users only)
@@ -136,39 +251,206 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(map[string]string{
-		"message": "Product creation endpoint - implementation pending",
-		"status":  "placeholder",
-	}); err != nil {
-		h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "CreateProduct"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+	orgID, ok := auth.GetOrgFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	var createReq models.CreateProductRequest
+	if !decodeJSON(w, r, &createReq) {
+		return
+	}
+	if !h.validateProductRequest(w, r, "CreateProduct.validation", &createReq) {
+		return
+	}
+
+	product := &models.Product{
+		OrgID:       orgID,
+		Name:        createReq.Name,
+		Description: strings.TrimSpace(createReq.Description),
+		Price:       createReq.Price,
+		UserID:      &userID,
+		Category:    createReq.Category,
+
+		AvailableFrom:  createReq.AvailableFrom,
+		AvailableUntil: createReq.AvailableUntil,
+	}
+	var opts models.ProductCreateOptions
+	// Enforce the per-role cap on active products
+	limit, limited := h.productLimit(r.Context())
+	if limited {
+		opts.MaxActive = &limit
+	}
+
+	err := h.productRepo.Create(product, opts)
+	if errors.Is(err, models.ErrProductLimitReached) {
+		http.Error(w, fmt.Sprintf("Product limit reached: you can have at most %d active products", limit), http.StatusForbidden)
 		return
 	}
+	if errors.Is(err, models.ErrDuplicateProductName) {
+		writeFieldErrors(w, r, h.logger, "CreateProduct.duplicate", http.StatusConflict, "duplicate_product_name", validator.ValidationErrors{
+			{Field: "name", Code: validator.CodeDuplicate, Message: "You already have a product with this name"},
+		})
+		return
+	}
+	if err != nil {
+		if writeConstraintError(w, r, h.logger, "CreateProduct.constraint", err) {
+			return
+		}
+		http.Error(w, "Failed to create product", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, h.logger, "CreateProduct", http.StatusCreated, product)
+}
+
+// validateProductRequest normalizes and validates a create or update
+// request, including the category against the allowed values, writing the
+// error response on failure
+func (h *ProductHandler) validateProductRequest(w http.ResponseWriter, r *http.Request, handler string, req *models.CreateProductRequest) bool {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Category = strings.TrimSpace(req.Category)
+
+	validationErrors := validator.Validate(*req)
+	if req.Category != "" {
+		exists, err := h.categoryRepo.Exists(req.Category)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return false
+		}
+		if !exists {
+			validationErrors.AddCode("category", validator.CodeNotAllowed, "category is not one of the allowed values")
+		}
+	}
+	if validationErrors.HasErrors() {
+		writeValidationErrors(w, r, h.logger, handler, validationErrors)
+		return false
+	}
+	return true
+}
+
+// canModifyProduct reports whether the caller may change product: its
+// owner and admins can
+func canModifyProduct(ctx context.Context, product *models.Product) bool {
+	if auth.HasAnyRole(ctx, "admin") {
+		return true
+	}
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	return ok && product.UserID != nil && *product.UserID == userID
+}
+
+// canViewProduct reports whether the caller may see product right now.
+// Outside its publish window only the owner and admins can.
+func canViewProduct(ctx context.Context, product *models.Product) bool {
+	if product.AvailableAt(time.Now()) || auth.HasAnyRole(ctx, "admin") {
+		return true
+	}
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	return ok && product.UserID != nil && *product.UserID == userID
+}
+
+// productLimit returns the most generous product limit among the user's
+// roles. Admins, and users holding any role without a configured limit, are
+// unlimited.
+func (h *ProductHandler) productLimit(ctx context.Context) (int, bool) {
+	if len(h.roleLimits) == 0 || auth.HasAnyRole(ctx, "admin") {
+		return 0, false
+	}
+
+	roles, _ := auth.GetUserRolesFromContext(ctx)
+	if len(roles) == 0 {
+		return 0, false
+	}
+
+	best := 0
+	for _, role := range roles {
+		limit, ok := h.roleLimits[role]
+		if !ok {
+			return 0, false
+		}
+		best = max(best, limit)
+	}
+	return best, true
+}
+
+// GetCategories returns the allowed product categories
+func (h *ProductHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	categories, err := h.categoryRepo.GetAll()
+	if err != nil {
+		http.Error(w, "Failed to retrieve categories", http.StatusInternalServerError)
+		return
+	}
+	if categories == nil {
+		categories = []models.Category{}
+	}
+
+	writeJSON(w, r, h.logger, "GetCategories", http.StatusOK, categories)
 }
 
+// UpdateProduct replaces the editable fields of the product named by the
+// {id} path segment. Only its owner and admins may update it; the body has
+// the same shape and rules as CreateProduct.
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "PUT" {
+	if r.Method != http.MethodPut {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{
-		"message": "Product update endpoint - implementation pending",
-		"status":  "placeholder",
-	}); err != nil {
-		h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "UpdateProduct"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	product, ok := h.productFromPath(w, r)
+	if !ok {
+		return
+	}
+	if !canModifyProduct(r.Context(), product) {
+		http.Error(w, "You can only update your own products", http.StatusForbidden)
+		return
+	}
+
+	var updateReq models.CreateProductRequest
+	if !decodeJSON(w, r, &updateReq) {
 		return
 	}
+	if !h.validateProductRequest(w, r, "UpdateProduct.validation", &updateReq) {
+		return
+	}
+
+	product.Name = updateReq.Name
+	product.Description = strings.TrimSpace(updateReq.Description)
+	product.Price = updateReq.Price
+	product.Category = updateReq.Category
+	product.AvailableFrom = updateReq.AvailableFrom
+	product.AvailableUntil = updateReq.AvailableUntil
+
+	err := h.productRepo.Update(product)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, models.ErrDuplicateProductName) {
+		writeFieldErrors(w, r, h.logger, "UpdateProduct.duplicate", http.StatusConflict, "duplicate_product_name", validator.ValidationErrors{
+			{Field: "name", Code: validator.CodeDuplicate, Message: "You already have a product with this name"},
+		})
+		return
+	}
+	if err != nil {
+		if writeConstraintError(w, r, h.logger, "UpdateProduct.constraint", err) {
+			return
+		}
+		http.Error(w, "Failed to update product", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, h.logger, "UpdateProduct", http.StatusOK, product)
 }
 
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
@@ -177,16 +459,8 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{
+	writeJSON(w, r, h.logger, "DeleteProduct", http.StatusOK, map[string]string{
 		"message": "Product deletion endpoint - implementation pending",
 		"status":  "placeholder",
-	}); err != nil {
-		h.logger.Error("Failed to encode JSON response",
-			slog.String("error", err.Error()),
-			slog.String("handler", "DeleteProduct"),
-		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
+	})
 }
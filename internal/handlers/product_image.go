@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/storage"
+)
+
+// imageFormField is the multipart field carrying the uploaded image
+const imageFormField = "image"
+
+// multipartOverhead allows for boundaries and part headers around the image
+const multipartOverhead = 64 << 10
+
+// allowedImageTypes maps the accepted image MIME types to their file extension
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// HandleProductImage dispatches /products/{id}/image by method: GET serves
+// the image, POST uploads a new one
+func (h *ProductHandler) HandleProductImage(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetProductImage(w, r)
+	case http.MethodPost:
+		h.UploadProductImage(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// UploadProductImage stores a multipart image upload for a product and
+// records its URL (product owner or admin only)
+func (h *ProductHandler) UploadProductImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	product, ok := h.productFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+	isOwner := product.UserID != nil && *product.UserID == userID
+	if !isOwner && !auth.HasAnyRole(r.Context(), "admin") {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	data, status, message := h.readImageUpload(w, r)
+	if status != http.StatusOK {
+		http.Error(w, message, status)
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, allowed := allowedImageTypes[contentType]
+	if !allowed {
+		http.Error(w, "Image must be JPEG, PNG, GIF, or WebP", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// A fresh key per upload keeps cached copies of the old image from being served
+	suffix, err := randomToken(8)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	key := fmt.Sprintf("product-%d-%s%s", product.ID, suffix, ext)
+
+	if err := h.storage.Put(r.Context(), key, bytes.NewReader(data)); err != nil {
+		h.logger.Error("Failed to store product image",
+			slog.Int("product_id", product.ID),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Failed to store image", http.StatusInternalServerError)
+		return
+	}
+
+	oldKey := product.ImageKey
+	imageURL := fmt.Sprintf("/products/%d/image", product.ID)
+	if err := h.productRepo.SetImage(product, key, imageURL); err != nil {
+		h.deleteImage(r, key)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to save image", http.StatusInternalServerError)
+		return
+	}
+	if oldKey != "" {
+		h.deleteImage(r, oldKey)
+	}
+
+	writeJSON(w, r, h.logger, "UploadProductImage", http.StatusOK, product)
+}
+
+// GetProductImage serves a product's image
+func (h *ProductHandler) GetProductImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	product, ok := h.productFromPath(w, r)
+	if !ok {
+		return
+	}
+	if product.ImageKey == "" {
+		http.Error(w, "Product has no image", http.StatusNotFound)
+		return
+	}
+
+	image, err := h.storage.Get(r.Context(), product.ImageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Product has no image", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to retrieve image", http.StatusInternalServerError)
+		return
+	}
+	defer image.Close()
+
+	w.Header().Set("Content-Type", imageContentType(product.ImageKey))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if _, err := io.Copy(w, image); err != nil {
+		h.logger.Debug("Failed to write product image",
+			slog.Int("product_id", product.ID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// productFromPath loads the product named by the {id} path segment, writing
+// 404 for missing products and products of other organizations
+func (h *ProductHandler) productFromPath(w http.ResponseWriter, r *http.Request) (*models.Product, bool) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	product, err := h.productRepo.GetByID(productID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return nil, false
+		}
+		http.Error(w, "Failed to retrieve product", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	// Products from other organizations, and scheduled or expired ones the
+	// caller may not see, are indistinguishable from missing ones
+	if orgID, ok := auth.GetOrgFromContext(r.Context()); !ok || product.OrgID != orgID || !canViewProduct(r.Context(), product) {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return product, true
+}
+
+// readImageUpload reads the image part of a multipart upload, enforcing the
+// size limit. On failure it returns the status and message to respond with.
+func (h *ProductHandler) readImageUpload(w http.ResponseWriter, r *http.Request) ([]byte, int, string) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxImageBytes+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, http.StatusBadRequest, "Expected a multipart/form-data upload"
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, http.StatusBadRequest, fmt.Sprintf("Missing %q file field", imageFormField)
+		}
+		if err != nil {
+			return nil, uploadErrorStatus(err), "Invalid multipart upload"
+		}
+		if part.FormName() != imageFormField {
+			part.Close()
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, h.maxImageBytes+1))
+		part.Close()
+		if err != nil {
+			return nil, uploadErrorStatus(err), "Failed to read upload"
+		}
+		if int64(len(data)) > h.maxImageBytes {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Sprintf("Image exceeds %d bytes", h.maxImageBytes)
+		}
+		if len(data) == 0 {
+			return nil, http.StatusBadRequest, "Image is empty"
+		}
+		return data, http.StatusOK, ""
+	}
+}
+
+// uploadErrorStatus maps a body read error to 413 when the request was too large
+func uploadErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// deleteImage removes a stored image, logging rather than failing the request
+func (h *ProductHandler) deleteImage(r *http.Request, key string) {
+	if err := h.storage.Delete(r.Context(), key); err != nil {
+		h.logger.Warn("Failed to delete product image",
+			slog.String("key", key),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// imageContentType derives the MIME type of a stored image from its key
+func imageContentType(key string) string {
+	ext := filepath.Ext(key)
+	for contentType, e := range allowedImageTypes {
+		if e == ext {
+			return contentType
+		}
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database/dbtest"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+func TestCreateProductLimit(t *testing.T) {
+	const limit = 3
+
+	tests := []struct {
+		name       string
+		roles      []string
+		limited    bool // Whether the count is checked against the limit
+		existing   int
+		wantStatus int
+	}{
+		{name: "below limit", roles: []string{"user"}, limited: true, existing: limit - 1, wantStatus: http.StatusCreated},
+		{name: "at limit", roles: []string{"user"}, limited: true, existing: limit, wantStatus: http.StatusForbidden},
+		{name: "over limit", roles: []string{"user"}, limited: true, existing: limit + 1, wantStatus: http.StatusForbidden},
+		{name: "admin unlimited", roles: []string{"admin"}, existing: limit, wantStatus: http.StatusCreated},
+		{name: "unlisted role unlimited", roles: []string{"user", "seller"}, existing: limit, wantStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.New(t)
+			authHandler := NewAuthHandler(db, testJWTConfig(), discardLogger, dbtest.Metrics(t))
+			productHandler := NewProductHandler(db, config.ProductConfig{
+				RoleLimits: map[string]int{"user": limit},
+			}, nil, discardLogger)
+
+			user := &models.User{ID: 7, Email: "user@example.com", Roles: tt.roles}
+			token := issueToken(t, authHandler, user)
+
+			expectTokenVersion(mock, user.ID, 0)
+			mock.ExpectBegin()
+			if tt.limited {
+				mock.ExpectExec(regexp.QuoteMeta("pg_advisory_xact_lock(hashtext('products.limit')")).WithArgs(user.ID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM products WHERE user_id = $1")).WithArgs(user.ID).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.existing))
+			}
+			if tt.wantStatus == http.StatusCreated {
+				mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO products")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "is_active", "created_at", "updated_at"}).
+						AddRow(1, true, time.Now(), time.Now()))
+				expectEvent(mock)
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			rec := serve(authHandler.RequireAuth(productHandler.CreateProduct), http.MethodPost, "/products",
+				`{"name":"Widget","description":"A widget","price":9.99}`, token)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("CreateProduct status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetProductAvailabilityWindow(t *testing.T) {
+	const ownerID = 7
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		from       *time.Time
+		until      *time.Time
+		viewer     *models.User
+		wantStatus int
+	}{
+		{name: "available to others", from: &past, until: &future,
+			viewer: &models.User{ID: 8, Roles: []string{"user"}}, wantStatus: http.StatusOK},
+		{name: "scheduled hidden from others", from: &future,
+			viewer: &models.User{ID: 8, Roles: []string{"user"}}, wantStatus: http.StatusNotFound},
+		{name: "expired hidden from others", until: &past,
+			viewer: &models.User{ID: 8, Roles: []string{"user"}}, wantStatus: http.StatusNotFound},
+		{name: "scheduled visible to owner", from: &future,
+			viewer: &models.User{ID: ownerID, Roles: []string{"user"}}, wantStatus: http.StatusOK},
+		{name: "expired visible to admin", until: &past,
+			viewer: &models.User{ID: 9, Roles: []string{"admin"}}, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock := newTestHandlers(t)
+			token := issueToken(t, h.auth, tt.viewer)
+
+			expectTokenVersion(mock, tt.viewer.ID, 0)
+			mock.ExpectQuery(regexp.QuoteMeta("WHERE id = $1 AND is_active = true")).WithArgs(1).
+				WillReturnRows(sqlmock.NewRows(productColumns).AddRow(1, models.DefaultOrgID, "Widget", "", 9.99, ownerID, "", "", "", true, now, now, tt.from, tt.until))
+
+			rec := serve(h.auth.RequireAuth(h.products.GetProduct), http.MethodGet, "/products/1", "", token)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetMyProductsPage(t *testing.T) {
+	h, mock := newTestHandlers(t)
+	user := &models.User{ID: 7, Email: "user@example.com", Roles: []string{"user"}}
+	token := issueToken(t, h.auth, user)
+
+	expectTokenVersion(mock, user.ID, 0)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE user_id = $1")).WithArgs(user.ID, 2, 4).WillReturnRows(productRows(2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM products WHERE user_id = $1")).WithArgs(user.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+
+	rec := serve(h.auth.RequireAuth(h.products.GetMyProducts), http.MethodGet, "/my-products?limit=2&offset=4", "", token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var page ProductPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("invalid response body: %v", err)
+	}
+	if len(page.Products) != 2 || page.Total != 6 || page.Limit != 2 || page.Offset != 4 {
+		t.Errorf("page = %d products, total %d, limit %d, offset %d, want 2, 6, 2, 4",
+			len(page.Products), page.Total, page.Limit, page.Offset)
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Defaults for the JSON request guards, overridden at startup by SetJSONLimits
+const (
+	DefaultMaxBodyBytes = 1 << 20
+	DefaultJSONMaxDepth = 32
+)
+
+var (
+	maxBodyBytes int64 = DefaultMaxBodyBytes
+	jsonMaxDepth       = DefaultJSONMaxDepth
+)
+
+var errJSONTooDeep = errors.New("JSON nesting too deep")
+
+// SetJSONLimits sets the body size and nesting depth allowed for JSON request
+// bodies; non-positive values keep the current limit
+func SetJSONLimits(maxBytes int64, maxDepth int) {
+	if maxBytes > 0 {
+		maxBodyBytes = maxBytes
+	}
+	if maxDepth > 0 {
+		jsonMaxDepth = maxDepth
+	}
+}
+
+// decodeJSON decodes the request body into v. Oversized bodies get 413 and
+// malformed or overly nested JSON gets 400; in either case the response has
+// been written and false is returned.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return false
+		}
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return false
+	}
+
+	// Check nesting before decoding so pathological input never reaches the parser
+	if err := checkJSONDepth(body, jsonMaxDepth); err != nil {
+		http.Error(w, "JSON nesting too deep", http.StatusBadRequest)
+		return false
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// checkJSONDepth returns errJSONTooDeep if objects and arrays in data nest
+// deeper than maxDepth. Brackets inside strings are ignored; everything else
+// about validity is left to the decoder.
+func checkJSONDepth(data []byte, maxDepth int) error {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return errJSONTooDeep
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// nestedJSON returns an object whose value nests depth arrays deep
+func nestedJSON(depth int) string {
+	return `{"name":` + strings.Repeat("[", depth-1) + strings.Repeat("]", depth-1) + `}`
+}
+
+func TestDecodeJSONDepth(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "flat", body: `{"name":"widget"}`, wantStatus: http.StatusOK},
+		{name: "at depth limit", body: nestedJSON(DefaultJSONMaxDepth), wantStatus: http.StatusOK},
+		{name: "one level too deep", body: nestedJSON(DefaultJSONMaxDepth + 1), wantStatus: http.StatusBadRequest},
+		{name: "deeply nested", body: nestedJSON(100000), wantStatus: http.StatusBadRequest},
+		{name: "brackets inside strings", body: `{"name":"` + strings.Repeat("[", 100) + `"}`, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			var v map[string]interface{}
+			if decodeJSON(rec, req, &v) {
+				rec.WriteHeader(http.StatusOK)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/i18n"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// prettyJSONAllowed lets clients ask for indented JSON with ?pretty=true.
+// It is a development aid and stays off unless enabled at startup.
+var prettyJSONAllowed bool
+
+// SetPrettyJSON allows or disallows ?pretty=true on JSON responses
+func SetPrettyJSON(allowed bool) {
+	prettyJSONAllowed = allowed
+}
+
+// writeJSON writes v as the JSON response body with the given status. The
+// body is encoded before anything is sent, so an encoding failure still
+// produces a clean 500 instead of a truncated response.
+func writeJSON(w http.ResponseWriter, r *http.Request, logger *slog.Logger, handler string, status int, v interface{}) {
+	var body []byte
+	var err error
+	if prettyJSONAllowed && r.URL.Query().Get("pretty") == "true" {
+		body, err = json.MarshalIndent(v, "", "  ")
+	} else {
+		body, err = json.Marshal(v)
+	}
+	if err != nil {
+		logger.Error("Failed to encode JSON response",
+			slog.String("error", err.Error()),
+			slog.String("handler", handler),
+		)
+		if !responseWritten(w) {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(append(body, '\n')); err != nil {
+		logger.Debug("Failed to write JSON response",
+			slog.String("error", err.Error()),
+			slog.String("handler", handler),
+		)
+	}
+}
+
+// responseWritten reports whether headers were already sent on w, in which
+// case a failure can only be logged: a second WriteHeader is invalid and
+// would corrupt the partial body
+func responseWritten(w http.ResponseWriter) bool {
+	if ww, ok := w.(interface{ Written() bool }); ok {
+		return ww.Written()
+	}
+	return false
+}
+
+// writeValidationErrors responds 400 with the standard validation error shape
+func writeValidationErrors(w http.ResponseWriter, r *http.Request, logger *slog.Logger, handler string, errs validator.ValidationErrors) {
+	writeFieldErrors(w, r, logger, handler, http.StatusBadRequest, "validation_failed", errs)
+}
+
+// writeFieldErrors writes the standard field error shape with the given
+// status. The summary (looked up by code) and every coded field message are
+// localized to the request's Accept-Language, falling back to English.
+func writeFieldErrors(w http.ResponseWriter, r *http.Request, logger *slog.Logger, handler string, status int, code string, errs validator.ValidationErrors) {
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+
+	summary, _ := i18n.Message(lang, code, "")
+	localized := make(validator.ValidationErrors, len(errs))
+	for i, e := range errs {
+		localized[i] = e
+		if message, ok := i18n.Message(lang, e.Code, e.Field); ok && e.Code != "" {
+			localized[i].Message = message
+		}
+	}
+
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	writeJSON(w, r, logger, handler, status, map[string]interface{}{
+		"error":   summary,
+		"details": localized,
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/i18n"
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+func TestWriteValidationErrorsLocalized(t *testing.T) {
+	if err := i18n.SetLanguages([]string{"es", "de"}); err != nil {
+		t.Fatalf("SetLanguages() error = %v", err)
+	}
+	t.Cleanup(func() { _ = i18n.SetLanguages(nil) })
+
+	tests := []struct {
+		acceptLanguage string
+		wantLanguage   string
+		wantSummary    string
+		wantMessage    string
+	}{
+		{acceptLanguage: "", wantLanguage: "en", wantSummary: "Validation failed", wantMessage: "email is required"},
+		{acceptLanguage: "es-ES", wantLanguage: "es", wantSummary: "La validación ha fallado", wantMessage: "email es obligatorio"},
+		{acceptLanguage: "de", wantLanguage: "de", wantSummary: "Validierung fehlgeschlagen", wantMessage: "email ist erforderlich"},
+		{acceptLanguage: "fr", wantLanguage: "en", wantSummary: "Validation failed", wantMessage: "email is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantLanguage+"/"+tt.acceptLanguage, func(t *testing.T) {
+			var errs validator.ValidationErrors
+			errs.AddCode("email", validator.CodeRequired, "email is required")
+			// Uncoded messages are passed through untranslated
+			errs.Add("name", "custom message")
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rec := httptest.NewRecorder()
+			writeValidationErrors(rec, req, discardLogger, "Test", errs)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+			if got := rec.Header().Get("Content-Language"); got != tt.wantLanguage {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLanguage)
+			}
+
+			var body struct {
+				Error   string                     `json:"error"`
+				Details validator.ValidationErrors `json:"details"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid response body: %v", err)
+			}
+			if body.Error != tt.wantSummary {
+				t.Errorf("error = %q, want %q", body.Error, tt.wantSummary)
+			}
+			if len(body.Details) != 2 {
+				t.Fatalf("details = %v, want 2 entries", body.Details)
+			}
+			if body.Details[0].Message != tt.wantMessage {
+				t.Errorf("coded message = %q, want %q", body.Details[0].Message, tt.wantMessage)
+			}
+			if body.Details[1].Message != "custom message" {
+				t.Errorf("uncoded message = %q, want it unchanged", body.Details[1].Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// statsCache holds computed dashboard stats for a short time so a polling
+// dashboard does not run its COUNT queries on every request. Keys include
+// the organization the stats describe. Each instance keeps its own cache.
+type statsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedStats
+	// calls are the computations in progress, so concurrent misses for a
+	// key wait for one computation instead of starting their own
+	calls map[string]*statsCall
+}
+
+type cachedStats struct {
+	value   map[string]interface{}
+	expires time.Time
+}
+
+// statsCall is one computation of a key's stats; value is set before done
+// is closed
+type statsCall struct {
+	done  chan struct{}
+	value map[string]interface{}
+}
+
+func newStatsCache(ttl time.Duration) *statsCache {
+	return &statsCache{ttl: ttl, entries: make(map[string]cachedStats), calls: make(map[string]*statsCall)}
+}
+
+// get returns the cached value for key, computing and storing it when it is
+// missing, expired, or fresh is set. hit reports whether the cache served it.
+// Concurrent misses for the same key compute once. The lock is not held
+// while computing, so a slow computation never delays other keys or hits.
+func (c *statsCache) get(key string, fresh bool, compute func() map[string]interface{}) (value map[string]interface{}, hit bool) {
+	if c == nil || c.ttl <= 0 {
+		return compute(), false
+	}
+
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && !fresh && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.value, true
+	}
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.value, false
+	}
+	call := &statsCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	// Release waiters even if compute panics
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.value = compute()
+	now := time.Now()
+	c.mu.Lock()
+	c.entries[key] = cachedStats{value: call.value, expires: now.Add(c.ttl)}
+	// Keys are per organization, so drop expired ones rather than keep them
+	for cachedKey, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, cachedKey)
+		}
+	}
+	c.mu.Unlock()
+	return call.value, false
+}
+
+// cachedStats serves key from the stats cache, honouring ?fresh=true and
+// reporting the outcome in the X-Cache header
+func (h *AdminHandler) cachedStats(w http.ResponseWriter, r *http.Request, key string, compute func() map[string]interface{}) map[string]interface{} {
+	fresh := r.URL.Query().Get("fresh") == "true"
+	value, hit := h.statsCache.get(key, fresh, compute)
+	if h.statsCache != nil && h.statsCache.ttl > 0 {
+		if hit {
+			w.Header().Set("X-Cache", "HIT")
+		} else {
+			w.Header().Set("X-Cache", "MISS")
+		}
+	}
+	return value
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStatsCacheComputesOncePerKey(t *testing.T) {
+	c := newStatsCache(time.Minute)
+	release := make(chan struct{})
+	var computed atomic.Int32
+	compute := func() map[string]interface{} {
+		computed.Add(1)
+		<-release
+		return map[string]interface{}{"total": 1}
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value, _ := c.get("stats", false, compute); value["total"] != 1 {
+				t.Errorf("get() = %v, want total 1", value)
+			}
+		}()
+	}
+	// Let the callers pile up behind the first computation
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := computed.Load(); got != 1 {
+		t.Errorf("computed %d times, want 1", got)
+	}
+	if _, hit := c.get("stats", false, compute); !hit {
+		t.Error("get() after computing = miss, want hit")
+	}
+}
+
+func TestStatsCacheComputesOutsideLock(t *testing.T) {
+	c := newStatsCache(time.Minute)
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	go c.get("slow", false, func() map[string]interface{} {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		c.get("other", false, func() map[string]interface{} { return nil })
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("get() for another key blocked behind a slow computation")
+	}
+}
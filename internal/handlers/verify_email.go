@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// Verification emails a user may request per window
+const (
+	verificationRequestLimit  = 3
+	verificationRequestWindow = time.Hour
+)
+
+// VerificationSender delivers an email verification token to the user's
+// address, typically as a link to a frontend page that posts it to
+// /verify-email
+type VerificationSender interface {
+	SendVerification(ctx context.Context, user *models.User, token string) error
+}
+
+// VerifyEmailRequest carries a token from a verification email
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// SetVerificationSender enables RequestVerification, delivering tokens
+// through sender. Without one the application has no way to reach the
+// user's inbox, so verification requests are answered 503.
+func (h *AuthHandler) SetVerificationSender(sender VerificationSender) {
+	h.verifySender = sender
+}
+
+// RequestVerification emails the authenticated user a single-use token
+// proving control of their address. Users already verified get 400.
+func (h *AuthHandler) RequestVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.verifySender == nil {
+		http.Error(w, "Email verification is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User context not found", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user.EmailVerified {
+		http.Error(w, "Email is already verified", http.StatusBadRequest)
+		return
+	}
+
+	if !h.verifyRequests.Allow(strconv.Itoa(user.ID)) {
+		http.Error(w, "Too many verification requests", http.StatusTooManyRequests)
+		return
+	}
+
+	token, err := h.jwtService.GeneratePurposeToken(user, auth.TokenTypeVerify, h.verifyTTL)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.verifySender.SendVerification(r.Context(), user, token); err != nil {
+		h.logger.Error("Failed to send verification email",
+			slog.Int("user_id", user.ID),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Failed to send verification email", http.StatusBadGateway)
+		return
+	}
+
+	writeJSON(w, r, h.logger, "RequestVerification", http.StatusAccepted, map[string]interface{}{
+		"message": "Verification email sent",
+	})
+}
+
+// VerifyEmail consumes a verification token and marks the user's email as
+// verified. Tokens are single-use; users already verified get 400.
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var verifyReq VerifyEmailRequest
+	if !decodeJSON(w, r, &verifyReq) {
+		return
+	}
+	verifyReq.Token = strings.TrimSpace(verifyReq.Token)
+	if verifyReq.Token == "" || len(verifyReq.Token) > h.maxTokenSize {
+		http.Error(w, "Invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := h.jwtService.ConsumePurposeToken(verifyReq.Token, auth.TokenTypeVerify)
+	if err != nil {
+		http.Error(w, "Invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(claims.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Invalid or expired verification token", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	// The token proves control of the address it was sent to, not a newer one
+	if !strings.EqualFold(user.Email, claims.Email) {
+		http.Error(w, "Invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+	if user.EmailVerified {
+		http.Error(w, "Email is already verified", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userRepo.MarkEmailVerified(user.ID); err != nil {
+		h.logger.Error("Failed to mark email verified",
+			slog.Int("user_id", user.ID),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	recordOrgAudit(h.auditRepo, h.logger, r, user.OrgID, "user.email_verified", "user:"+strconv.Itoa(user.ID), nil)
+
+	writeJSON(w, r, h.logger, "VerifyEmail", http.StatusOK, map[string]interface{}{
+		"message": "Email verified",
+	})
+}
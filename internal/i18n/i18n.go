@@ -0,0 +1,90 @@
+// Package i18n localizes user-facing messages by error code.
+package i18n
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when a request names no supported language and
+// whenever a message is missing from the requested locale
+const DefaultLanguage = "en"
+
+//go:embed catalog.json
+var catalogJSON []byte
+
+// catalog maps language -> error code -> message template. Templates may
+// contain {field}, replaced with the name of the offending field.
+var catalog = mustLoadCatalog(catalogJSON)
+
+// enabled lists the languages requests may select, in preference order
+var enabled = []string{DefaultLanguage}
+
+func mustLoadCatalog(data []byte) map[string]map[string]string {
+	var c map[string]map[string]string
+	if err := json.Unmarshal(data, &c); err != nil {
+		panic(fmt.Sprintf("i18n: invalid message catalog: %v", err))
+	}
+	if _, ok := c[DefaultLanguage]; !ok {
+		panic("i18n: message catalog has no " + DefaultLanguage + " messages")
+	}
+	return c
+}
+
+// SetLanguages enables the given languages for Accept-Language negotiation.
+// The default language is always enabled; unknown languages are an error.
+func SetLanguages(languages []string) error {
+	langs := []string{DefaultLanguage}
+	for _, lang := range languages {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if _, ok := catalog[lang]; !ok {
+			return fmt.Errorf("no messages for language %q", lang)
+		}
+		if !slices.Contains(langs, lang) {
+			langs = append(langs, lang)
+		}
+	}
+	enabled = langs
+	return nil
+}
+
+// Negotiate picks the enabled language that best matches an Accept-Language
+// header, falling back to the default language
+func Negotiate(acceptLanguage string) string {
+	best, bestQ := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		// Match on the primary subtag, so "es-MX" selects "es"
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if q > bestQ && slices.Contains(enabled, base) {
+			best, bestQ = base, q
+		}
+	}
+	return best
+}
+
+// Message returns the localized message for code, falling back to the
+// default language. It reports false when no language has the code.
+func Message(lang, code, field string) (string, bool) {
+	template, ok := catalog[lang][code]
+	if !ok {
+		template, ok = catalog[DefaultLanguage][code]
+	}
+	if !ok {
+		return "", false
+	}
+	return strings.ReplaceAll(template, "{field}", field), true
+}
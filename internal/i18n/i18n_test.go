@@ -0,0 +1,77 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	if err := SetLanguages([]string{"es", "de"}); err != nil {
+		t.Fatalf("SetLanguages() error = %v", err)
+	}
+	t.Cleanup(func() { _ = SetLanguages(nil) })
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{acceptLanguage: "", want: "en"},
+		{acceptLanguage: "es", want: "es"},
+		{acceptLanguage: "de-DE", want: "de"},
+		{acceptLanguage: "es-MX,es;q=0.9", want: "es"},
+		{acceptLanguage: "fr", want: "en"},
+		{acceptLanguage: "fr, de;q=0.5, es;q=0.8", want: "es"},
+		{acceptLanguage: "de;q=bogus, es;q=0.1", want: "es"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			if got := Negotiate(tt.acceptLanguage); got != tt.want {
+				t.Errorf("Negotiate(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiateDisabledLanguage(t *testing.T) {
+	if got := Negotiate("es"); got != DefaultLanguage {
+		t.Errorf("Negotiate(%q) without enabled languages = %q, want %q", "es", got, DefaultLanguage)
+	}
+}
+
+func TestSetLanguagesUnknown(t *testing.T) {
+	if err := SetLanguages([]string{"xx"}); err == nil {
+		t.Error("SetLanguages() accepted a language without messages")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		lang   string
+		code   string
+		want   string
+		wantOK bool
+	}{
+		{lang: "en", code: "required", want: "email is required", wantOK: true},
+		{lang: "es", code: "required", want: "email es obligatorio", wantOK: true},
+		{lang: "de", code: "required", want: "email ist erforderlich", wantOK: true},
+		{lang: "fr", code: "required", want: "email is required", wantOK: true},
+		{lang: "es", code: "no_such_code", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang+"/"+tt.code, func(t *testing.T) {
+			got, ok := Message(tt.lang, tt.code, "email")
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Message(%q, %q) = %q, %v, want %q, %v", tt.lang, tt.code, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCatalogComplete(t *testing.T) {
+	for lang, messages := range catalog {
+		for code := range catalog[DefaultLanguage] {
+			if _, ok := messages[code]; !ok {
+				t.Errorf("%s catalog has no message for %q", lang, code)
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+)
+
+// API token scopes. A token may only call routes requiring one of its scopes.
+const (
+	ScopeProductsRead  = "products:read"
+	ScopeProductsWrite = "products:write"
+	ScopeUsersRead     = "users:read"
+)
+
+// APITokenScopes lists every scope a token may be granted
+var APITokenScopes = []string{ScopeProductsRead, ScopeProductsWrite, ScopeUsersRead}
+
+// APIToken is a long-lived credential for a service account. Only the hash
+// of the token is stored; the token itself is shown once, at creation.
+type APIToken struct {
+	ID         int        `json:"id"`
+	OrgID      int        `json:"org_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *int       `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// apiTokenColumns is the column list scanned by scanAPIToken
+const apiTokenColumns = `id, org_id, name, token_prefix, array_to_string(scopes, ','),
+		       created_by, created_at, expires_at, last_used_at, revoked_at`
+
+// APITokenRepository handles database operations for API tokens
+type APITokenRepository struct {
+	db database.DB
+}
+
+// NewAPITokenRepository creates a new API token repository
+func NewAPITokenRepository(db database.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+// Create stores a new token under the hash of its secret
+func (r *APITokenRepository) Create(token *APIToken, tokenHash string) error {
+	query := `
+		INSERT INTO api_tokens (org_id, name, token_hash, token_prefix, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, string_to_array($5, ','), $6, $7)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(query, token.OrgID, token.Name, tokenHash, token.Prefix,
+		strings.Join(token.Scopes, ","), token.CreatedBy, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create API token: %w", err)
+	}
+	return nil
+}
+
+// GetActiveByHash returns the unrevoked, unexpired token with the given hash
+func (r *APITokenRepository) GetActiveByHash(tokenHash string) (*APIToken, error) {
+	token := &APIToken{}
+	query := `
+		SELECT ` + apiTokenColumns + `
+		FROM api_tokens
+		WHERE token_hash = $1 AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())`
+
+	err := database.Retry(context.Background(), r.db, "api_token_get_by_hash", func() error {
+		return scanAPIToken(r.db.QueryRow(query, tokenHash), token)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// ListByOrg returns every token of an organization, newest first
+func (r *APITokenRepository) ListByOrg(orgID int) ([]APIToken, error) {
+	query := `
+		SELECT ` + apiTokenColumns + `
+		FROM api_tokens
+		WHERE org_id = $1
+		ORDER BY created_at DESC, id DESC`
+
+	var tokens []APIToken
+	err := database.Retry(context.Background(), r.db, "api_token_list", func() error {
+		rows, err := r.db.Query(query, orgID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		tokens = nil
+		for rows.Next() {
+			var token APIToken
+			if err := scanAPIToken(rows, &token); err != nil {
+				return err
+			}
+			tokens = append(tokens, token)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Revoke disables a token of an organization, returning sql.ErrNoRows if it
+// does not exist or was already revoked
+func (r *APITokenRepository) Revoke(id, orgID int) error {
+	query := "UPDATE api_tokens SET revoked_at = NOW() WHERE id = $1 AND org_id = $2 AND revoked_at IS NULL"
+	result, err := r.db.Exec(query, id, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// TouchLastUsed records that a token was just used
+func (r *APITokenRepository) TouchLastUsed(id int) error {
+	_, err := r.db.Exec("UPDATE api_tokens SET last_used_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+// scanAPIToken reads a row selected with apiTokenColumns
+func scanAPIToken(row rowScanner, token *APIToken) error {
+	var scopes string
+	err := row.Scan(
+		&token.ID, &token.OrgID, &token.Name, &token.Prefix, &scopes,
+		&token.CreatedBy, &token.CreatedAt, &token.ExpiresAt, &token.LastUsedAt, &token.RevokedAt,
+	)
+	if err != nil {
+		return err
+	}
+	token.Scopes = []string{}
+	if scopes != "" {
+		token.Scopes = strings.Split(scopes, ",")
+	}
+	return nil
+}
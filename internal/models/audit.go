@@ -0,0 +1,185 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+)
+
+const (
+	// DefaultAuditPageSize is used when a query does not request a page size
+	DefaultAuditPageSize = 50
+	// MaxAuditPageSize bounds a single page of audit results
+	MaxAuditPageSize = 500
+	// MaxAuditExportRows bounds a single CSV export
+	MaxAuditExportRows = 10000
+)
+
+// AuditLog represents a single entry in the audit trail
+type AuditLog struct {
+	ID         int64           `json:"id"`
+	OrgID      int             `json:"org_id"`
+	ActorID    *int            `json:"actor_id"`
+	ActorEmail string          `json:"actor_email,omitempty"`
+	Action     string          `json:"action"`
+	Target     string          `json:"target,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	IPAddress  string          `json:"ip_address,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows an audit log query. Zero values mean "no filter".
+type AuditFilter struct {
+	OrgID      int
+	ActorID    *int
+	ActorEmail string
+	Action     string
+	Target     string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// AuditRepository handles database operations for the audit trail
+type AuditRepository struct {
+	db database.DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db database.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Log appends an entry to the audit trail. Entries without an organization
+// belong to the default one.
+func (r *AuditRepository) Log(entry *AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (org_id, actor_id, actor_email, action, target, details, ip_address)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6, NULLIF($7, ''))`
+
+	orgID := entry.OrgID
+	if orgID == 0 {
+		orgID = DefaultOrgID
+	}
+
+	var details interface{}
+	if len(entry.Details) > 0 {
+		details = string(entry.Details)
+	}
+
+	_, err := r.db.Exec(query, orgID, entry.ActorID, entry.ActorEmail, entry.Action, entry.Target, details, entry.IPAddress)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Query returns one page of audit entries matching the filter, newest first,
+// along with the total number of matching entries
+func (r *AuditRepository) Query(filter AuditFilter) ([]AuditLog, int, error) {
+	where, args := filter.where()
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM audit_logs" + where
+	err := database.Retry(context.Background(), r.db, "audit_count", func() error {
+		return r.db.QueryRow(countQuery, args...).Scan(&total)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultAuditPageSize
+	}
+	if limit > MaxAuditExportRows {
+		limit = MaxAuditExportRows
+	}
+	offset := filter.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, org_id, actor_id, COALESCE(actor_email, ''), action, COALESCE(target, ''),
+		       details, COALESCE(ip_address, ''), created_at
+		FROM audit_logs%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	pageArgs := append(args, limit, offset)
+
+	var entries []AuditLog
+	err = database.Retry(context.Background(), r.db, "audit_query", func() error {
+		rows, err := r.db.Query(query, pageArgs...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		entries = nil
+		for rows.Next() {
+			var entry AuditLog
+			var details []byte
+			err := rows.Scan(
+				&entry.ID, &entry.OrgID, &entry.ActorID, &entry.ActorEmail, &entry.Action,
+				&entry.Target, &details, &entry.IPAddress, &entry.CreatedAt,
+			)
+			if err != nil {
+				return err
+			}
+			if len(details) > 0 {
+				entry.Details = json.RawMessage(details)
+			}
+			entries = append(entries, entry)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// where builds a parameterized WHERE clause; only placeholders carry user input
+func (f AuditFilter) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.OrgID != 0 {
+		add("org_id = $%d", f.OrgID)
+	}
+	if f.ActorID != nil {
+		add("actor_id = $%d", *f.ActorID)
+	}
+	if f.ActorEmail != "" {
+		add("actor_email = $%d", f.ActorEmail)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.Target != "" {
+		add("target = $%d", f.Target)
+	}
+	if f.From != nil {
+		add("created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("created_at < $%d", *f.To)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
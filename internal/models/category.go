@@ -0,0 +1,65 @@
+package models
+
+import (
+	"context"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+)
+
+// Category represents an allowed product category
+type Category struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// CategoryRepository handles database operations for categories
+type CategoryRepository struct {
+	db database.DB
+}
+
+// NewCategoryRepository creates a new category repository
+func NewCategoryRepository(db database.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// GetAll retrieves all categories ordered by name
+func (r *CategoryRepository) GetAll() ([]Category, error) {
+	query := "SELECT name, COALESCE(description, '') FROM categories ORDER BY name"
+
+	var categories []Category
+	err := database.Retry(context.Background(), r.db, "category_get_all", func() error {
+		rows, err := r.db.Query(query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		categories = nil
+		for rows.Next() {
+			var category Category
+			if err := rows.Scan(&category.Name, &category.Description); err != nil {
+				return err
+			}
+			categories = append(categories, category)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// Exists reports whether name is an allowed category
+func (r *CategoryRepository) Exists(name string) (bool, error) {
+	var exists bool
+	query := "SELECT EXISTS(SELECT 1 FROM categories WHERE name = $1)"
+	err := database.Retry(context.Background(), r.db, "category_exists", func() error {
+		return r.db.QueryRow(query, name).Scan(&exists)
+	})
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
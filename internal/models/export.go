@@ -0,0 +1,156 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+)
+
+// UserExport is everything stored about a user, for data portability
+// requests. It relies on the models' JSON tags to leave out password hashes
+// and other internal-only fields.
+type UserExport struct {
+	ExportedAt time.Time  `json:"exported_at"`
+	Profile    User       `json:"profile"`
+	Roles      []string   `json:"roles"`
+	Products   []Product  `json:"products"`
+	Identities []string   `json:"linked_identities"`
+	Audit      []AuditLog `json:"audit_entries"`
+}
+
+// ExportRepository gathers a user's data across tables
+type ExportRepository struct {
+	db database.DB
+}
+
+// NewExportRepository creates a new export repository
+func NewExportRepository(db database.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+// ExportUser collects the user's profile, roles, products (including
+// deactivated ones), linked identity providers, and the audit entries they
+// performed. Everything is read in one read-only, repeatable-read transaction
+// so the parts are consistent with each other. Returns sql.ErrNoRows if the
+// user does not exist.
+func (r *ExportRepository) ExportUser(userID int) (*UserExport, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY"); err != nil {
+		return nil, fmt.Errorf("failed to configure transaction: %w", err)
+	}
+
+	export := &UserExport{
+		ExportedAt: time.Now().UTC(),
+		Roles:      []string{},
+		Products:   []Product{},
+		Identities: []string{},
+		Audit:      []AuditLog{},
+	}
+
+	user := &export.Profile
+	err = tx.QueryRow(`
+		SELECT id, org_id, name, email, email_verified, is_active, last_login, created_at, updated_at
+		FROM users WHERE id = $1`, userID).Scan(
+		&user.ID, &user.OrgID, &user.Name, &user.Email, &user.EmailVerified,
+		&user.IsActive, &user.LastLogin, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryEach(tx, `
+		SELECT r.name FROM roles r
+		JOIN user_roles ur ON r.id = ur.role_id
+		WHERE ur.user_id = $1 ORDER BY r.name`, []interface{}{userID}, func(rows *sql.Rows) error {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return err
+		}
+		export.Roles = append(export.Roles, role)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export roles: %w", err)
+	}
+	user.Roles = export.Roles
+
+	err = queryEach(tx, `
+		SELECT `+productColumns+`
+		FROM products WHERE user_id = $1 ORDER BY created_at, id`, []interface{}{userID}, func(rows *sql.Rows) error {
+		var product Product
+		if err := scanProduct(rows, &product); err != nil {
+			return err
+		}
+		export.Products = append(export.Products, product)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export products: %w", err)
+	}
+
+	err = queryEach(tx, `
+		SELECT provider FROM user_identities WHERE user_id = $1 ORDER BY provider`, []interface{}{userID}, func(rows *sql.Rows) error {
+		var provider string
+		if err := rows.Scan(&provider); err != nil {
+			return err
+		}
+		export.Identities = append(export.Identities, provider)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export identities: %w", err)
+	}
+
+	err = queryEach(tx, `
+		SELECT id, actor_id, COALESCE(actor_email, ''), action, COALESCE(target, ''),
+		       details, COALESCE(ip_address, ''), created_at
+		FROM audit_logs WHERE actor_id = $1 ORDER BY created_at, id
+		LIMIT $2`, []interface{}{userID, MaxAuditExportRows}, func(rows *sql.Rows) error {
+		var entry AuditLog
+		var details []byte
+		err := rows.Scan(
+			&entry.ID, &entry.ActorID, &entry.ActorEmail, &entry.Action,
+			&entry.Target, &details, &entry.IPAddress, &entry.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		if len(details) > 0 {
+			entry.Details = json.RawMessage(details)
+		}
+		export.Audit = append(export.Audit, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export audit entries: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return export, nil
+}
+
+// queryEach runs a query inside tx and calls scan for every row
+func queryEach(tx *database.Tx, query string, args []interface{}, scan func(*sql.Rows) error) error {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+)
+
+// IdentityProviderGoogle identifies accounts linked through Google sign-in
+const IdentityProviderGoogle = "google"
+
+// IdentityRepository handles database operations for external identities
+type IdentityRepository struct {
+	db database.DB
+}
+
+// NewIdentityRepository creates a new identity repository
+func NewIdentityRepository(db database.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// GetUserID returns the user linked to a provider account
+func (r *IdentityRepository) GetUserID(provider, subject string) (int, error) {
+	var userID int
+	query := "SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2"
+	err := database.Retry(context.Background(), r.db, "identity_get_user_id", func() error {
+		return r.db.QueryRow(query, provider, subject).Scan(&userID)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+// Link associates a provider account with a user
+func (r *IdentityRepository) Link(userID int, provider, subject, email string) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, last_login)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)`
+
+	if _, err := r.db.Exec(query, userID, provider, subject, email); err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	return nil
+}
+
+// UpdateLastLogin records a sign-in through a provider account
+func (r *IdentityRepository) UpdateLastLogin(provider, subject string) error {
+	query := "UPDATE user_identities SET last_login = CURRENT_TIMESTAMP WHERE provider = $1 AND subject = $2"
+	return database.Retry(context.Background(), r.db, "identity_update_last_login", func() error {
+		_, err := r.db.Exec(query, provider, subject)
+		return err
+	})
+}
@@ -1,6 +1,10 @@
 package models
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
@@ -9,69 +13,158 @@ import (
 // Product represents a product in the system
 type Product struct {
 	ID          int       `json:"id"`
+	OrgID       int       `json:"org_id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	Price       float64   `json:"price"`
 	UserID      *int      `json:"user_id"`
+	Category    string    `json:"category,omitempty"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	ImageKey    string    `json:"-"`
 	IsActive    bool      `json:"is_active"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Publish window; nil leaves that side open. Outside it the product is
+	// only visible to its owner and admins.
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+}
+
+// AvailableAt reports whether t falls inside the product's publish window.
+// The window includes its start and excludes its end.
+func (p *Product) AvailableAt(t time.Time) bool {
+	if p.AvailableFrom != nil && t.Before(*p.AvailableFrom) {
+		return false
+	}
+	if p.AvailableUntil != nil && !t.Before(*p.AvailableUntil) {
+		return false
+	}
+	return true
 }
 
+// ErrDuplicateProductName is returned when a user already has an active product with the same name
+var ErrDuplicateProductName = errors.New("duplicate product name")
+
+// productNameIndex is the unique index behind ErrDuplicateProductName
+const productNameIndex = "idx_products_user_name_unique"
+
+// ErrProductLimitReached is returned when a user already has as many active products as they may create
+var ErrProductLimitReached = errors.New("product limit reached")
+
+// ProductCreateOptions are checks on the product's owner made in the same
+// transaction as the insert. Products without an owner skip them.
+type ProductCreateOptions struct {
+	MaxActive *int // Reject the product if the owner already has this many active products; nil means unlimited
+}
+
+// CreateProductRequest represents the data needed to create a product
+type CreateProductRequest struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Category    string  `json:"category"`
+
+	AvailableFrom  *time.Time `json:"available_from"`
+	AvailableUntil *time.Time `json:"available_until"`
+}
+
+// ProductListOptions narrows and orders a product listing
+type ProductListOptions struct {
+	Category string // Only products in this category; empty means any
+	Search   string // Only products whose name or description contains this, case-insensitively
+	Sort     string // See SortFields.OrderBy
+	Limit    int    // Maximum products returned; zero returns all
+	Offset   int    // Products skipped before the first one returned
+
+	// Products outside their publish window are left out unless
+	// IncludeUnavailable is set (admins) or they belong to OwnerID
+	IncludeUnavailable bool
+	OwnerID            int
+}
+
+// availableNow matches products whose publish window contains the current time
+const availableNow = `(available_from IS NULL OR available_from <= NOW())
+		  AND (available_until IS NULL OR available_until > NOW())`
+
+// productColumns is the column list scanned by scanProduct
+const productColumns = `id, org_id, name, description, price, user_id, COALESCE(category, ''),
+		       COALESCE(image_url, ''), COALESCE(image_key, ''), is_active, created_at, updated_at,
+		       available_from, available_until`
+
 // ProductRepository handles database operations for products
 type ProductRepository struct {
 	db database.DB
+	// orgID scopes GetAll, GetAllPaginated and Search; see ForOrg
+	orgID int
 }
 
-// NewProductRepository creates a new product repository
+// NewProductRepository creates a new product repository over the default
+// organization
 func NewProductRepository(db database.DB) *ProductRepository {
-	return &ProductRepository{db: db}
+	return &ProductRepository{db: db, orgID: DefaultOrgID}
 }
 
-// GetAll retrieves all active products
+// ForOrg returns a repository whose GetAll, GetAllPaginated and Search
+// only see the products of orgID
+func (r *ProductRepository) ForOrg(orgID int) *ProductRepository {
+	return &ProductRepository{db: r.db, orgID: orgID}
+}
+
+// GetAll retrieves the repository organization's active products that are
+// inside their publish window, newest first
 func (r *ProductRepository) GetAll() ([]Product, error) {
-	query := `
-		SELECT id, name, description, price, user_id, is_active, created_at, updated_at
-		FROM products 
-		WHERE is_active = true 
-		ORDER BY created_at DESC`
+	return r.GetAllByOrg(r.orgID, ProductListOptions{})
+}
 
-	rows, err := r.db.Query(query)
+// GetAllPaginated retrieves one page of the repository organization's
+// active, currently available products, newest first, together with the
+// total number of such products
+func (r *ProductRepository) GetAllPaginated(limit, offset int) ([]Product, int, error) {
+	opts := ProductListOptions{Limit: limit, Offset: offset}
+	products, err := r.GetAllByOrg(r.orgID, opts)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	defer rows.Close()
 
-	var products []Product
-	for rows.Next() {
-		var product Product
-		err := rows.Scan(
-			&product.ID, &product.Name, &product.Description,
-			&product.Price, &product.UserID, &product.IsActive, 
-			&product.CreatedAt, &product.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		products = append(products, product)
+	total, err := r.CountByOrg(r.orgID, opts)
+	if err != nil {
+		return nil, 0, err
 	}
+	return products, total, nil
+}
 
-	return products, nil
+// Search retrieves one page of the repository organization's active,
+// currently available products whose name or description contains term,
+// case-insensitively, newest first. The term is trimmed; an empty term
+// matches every product.
+func (r *ProductRepository) Search(term string, limit, offset int) ([]Product, error) {
+	return r.GetAllByOrg(r.orgID, ProductListOptions{Search: term, Limit: limit, Offset: offset})
+}
+
+// likeEscaper escapes LIKE wildcards so a search term matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchFilter returns a parameterized condition matching term anywhere in
+// a product's name or description, appending its value to args
+func searchFilter(args *[]interface{}, term string) string {
+	*args = append(*args, "%"+likeEscaper.Replace(term)+"%")
+	n := len(*args)
+	return fmt.Sprintf(" AND (name ILIKE $%d OR description ILIKE $%d)", n, n)
 }
 
-// GetByID retrieves a specific product by ID
+// GetByID retrieves a specific product by ID, regardless of its publish
+// window; callers showing it to other users must check AvailableAt
 func (r *ProductRepository) GetByID(id int) (*Product, error) {
 	product := &Product{}
 	query := `
-		SELECT id, name, description, price, user_id, is_active, created_at, updated_at
+		SELECT ` + productColumns + `
 		FROM products 
 		WHERE id = $1 AND is_active = true`
 
-	err := r.db.QueryRow(query, id).Scan(
-		&product.ID, &product.Name, &product.Description,
-		&product.Price, &product.UserID, &product.IsActive, 
-		&product.CreatedAt, &product.UpdatedAt,
-	)
+	err := database.Retry(context.Background(), r.db, "product_get_by_id", func() error {
+		return scanProduct(r.db.QueryRow(query, id), product)
+	})
 
 	if err != nil {
 		return nil, err
@@ -80,33 +173,263 @@ func (r *ProductRepository) GetByID(id int) (*Product, error) {
 	return product, nil
 }
 
-// GetByUserID retrieves all products created by a specific user
-func (r *ProductRepository) GetByUserID(userID int) ([]Product, error) {
+// GetByUserID retrieves the active products created by a specific user,
+// ordered and paged by opts; its Category and availability fields are ignored.
+// Returns ErrInvalidSort for sort fields that are not in ProductSortFields.
+func (r *ProductRepository) GetByUserID(userID int, opts ProductListOptions) ([]Product, error) {
+	orderBy, err := ProductSortFields.OrderBy(opts.Sort, DefaultProductSort)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{userID}
 	query := `
-		SELECT id, name, description, price, user_id, is_active, created_at, updated_at
+		SELECT ` + productColumns + `
 		FROM products 
 		WHERE user_id = $1 AND is_active = true 
-		ORDER BY created_at DESC`
+		` + orderBy + pageClause(&args, opts.Limit, opts.Offset)
+
+	return r.queryProducts("product_get_by_user", query, args...)
+}
+
+// DefaultProductSort is the listing order used when no sort is requested
+const DefaultProductSort = "-created_at"
 
-	rows, err := r.db.Query(query, userID)
+// GetAllByOrg retrieves the active products belonging to an organization,
+// filtered and ordered by opts. Returns ErrInvalidSort for sort fields that
+// are not in ProductSortFields.
+func (r *ProductRepository) GetAllByOrg(orgID int, opts ProductListOptions) ([]Product, error) {
+	orderBy, err := ProductSortFields.OrderBy(opts.Sort, DefaultProductSort)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
+	where, args := orgFilter(orgID, opts)
+	query := `
+		SELECT ` + productColumns + `
+		FROM products 
+		WHERE ` + where + `
+		` + orderBy + pageClause(&args, opts.Limit, opts.Offset)
+
+	return r.queryProducts("product_get_by_org", query, args...)
+}
+
+// CountByOrg counts the active products of an organization matching the
+// filters in opts (its sort order is ignored)
+func (r *ProductRepository) CountByOrg(orgID int, opts ProductListOptions) (int, error) {
+	where, args := orgFilter(orgID, opts)
+	return r.count("product_count_by_org", "SELECT COUNT(*) FROM products WHERE "+where, args...)
+}
+
+// CountByUserID counts the active products created by a specific user
+func (r *ProductRepository) CountByUserID(userID int) (int, error) {
+	query := "SELECT COUNT(*) FROM products WHERE user_id = $1 AND is_active = true"
+	return r.count("product_count_by_user", query, userID)
+}
+
+// orgFilter builds the parameterized WHERE clause for organization listings
+func orgFilter(orgID int, opts ProductListOptions) (string, []interface{}) {
+	args := []interface{}{orgID}
+	where := "org_id = $1 AND is_active = true"
+	if opts.Category != "" {
+		args = append(args, opts.Category)
+		where += fmt.Sprintf(" AND category = $%d", len(args))
+	}
+	if term := strings.TrimSpace(opts.Search); term != "" {
+		where += searchFilter(&args, term)
+	}
+	if !opts.IncludeUnavailable {
+		args = append(args, opts.OwnerID)
+		where += fmt.Sprintf(" AND (user_id = $%d OR (%s))", len(args), availableNow)
+	}
+	return where, args
+}
+
+// count runs a COUNT query, retrying transient failures
+func (r *ProductRepository) count(operation, query string, args ...interface{}) (int, error) {
+	var total int
+	err := database.Retry(context.Background(), r.db, operation, func() error {
+		return r.db.QueryRow(query, args...).Scan(&total)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// insertProductQuery inserts a product and returns its generated fields
+const insertProductQuery = `
+		INSERT INTO products (org_id, name, description, price, user_id, category, available_from, available_until)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+		RETURNING id, is_active, created_at, updated_at`
+
+// Create inserts a new product, filling in its generated fields. It returns
+// ErrDuplicateProductName when the owner already has an active product with
+// the same name (case-insensitive), and ErrProductLimitReached when the
+// limit in opts is reached; a per-user advisory lock held for the
+// transaction serializes concurrent creates, so two requests cannot both
+// pass the limit check.
+func (r *ProductRepository) Create(product *Product, opts ProductCreateOptions) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if product.UserID != nil && opts.MaxActive != nil {
+		if err := checkProductLimit(tx, *product.UserID, *opts.MaxActive); err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRow(insertProductQuery, product.OrgID, product.Name, product.Description,
+		product.Price, product.UserID, product.Category, product.AvailableFrom, product.AvailableUntil).
+		Scan(&product.ID, &product.IsActive, &product.CreatedAt, &product.UpdatedAt)
+	if isDuplicateName(err) {
+		return ErrDuplicateProductName
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create product: %w", err)
+	}
+	if err := recordEvent(tx, product.OrgID, EventProductCreated, "product", product.ID, product); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// checkProductLimit takes the owner's product limit lock and returns
+// ErrProductLimitReached if they already have limit or more active products
+func checkProductLimit(tx *database.Tx, userID, limit int) error {
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('products.limit'), $1)", userID); err != nil {
+		return fmt.Errorf("failed to lock product limit: %w", err)
+	}
+
+	var count int
+	query := "SELECT COUNT(*) FROM products WHERE user_id = $1 AND is_active = true"
+	if err := tx.QueryRow(query, userID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count products: %w", err)
+	}
+	if count >= limit {
+		return ErrProductLimitReached
+	}
+	return nil
+}
+
+// isDuplicateName reports whether err violated the per-owner product name
+// index, which also settles concurrent creates and renames
+func isDuplicateName(err error) bool {
+	name, ok := database.ConstraintViolation(err)
+	return ok && name == productNameIndex
+}
+
+// Update saves the editable fields of an active product (name, description,
+// price, category, and publish window), returning sql.ErrNoRows if there is
+// no such product and ErrDuplicateProductName if the owner already has
+// another active product with the new name
+func (r *ProductRepository) Update(product *Product) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		UPDATE products
+		SET name = $1, description = $2, price = $3, category = NULLIF($4, ''),
+		    available_from = $5, available_until = $6, updated_at = NOW()
+		WHERE id = $7 AND is_active = true
+		RETURNING updated_at`
+
+	err = tx.QueryRow(query, product.Name, product.Description, product.Price, product.Category,
+		product.AvailableFrom, product.AvailableUntil, product.ID).Scan(&product.UpdatedAt)
+	if isDuplicateName(err) {
+		return ErrDuplicateProductName
+	}
+	if err != nil {
+		return err
+	}
+	if err := recordEvent(tx, product.OrgID, EventProductUpdated, "product", product.ID, product); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetImage records the stored image of an active product, returning
+// sql.ErrNoRows if there is no such product
+func (r *ProductRepository) SetImage(product *Product, key, url string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		UPDATE products SET image_key = $1, image_url = $2, updated_at = NOW()
+		WHERE id = $3 AND is_active = true
+		RETURNING updated_at`
+
+	err = tx.QueryRow(query, key, url, product.ID).Scan(&product.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	product.ImageKey = key
+	product.ImageURL = url
+	if err := recordEvent(tx, product.OrgID, EventProductUpdated, "product", product.ID, product); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// queryProducts runs a product listing query, retrying transient failures
+func (r *ProductRepository) queryProducts(operation, query string, args ...interface{}) ([]Product, error) {
 	var products []Product
-	for rows.Next() {
-		var product Product
-		err := rows.Scan(
-			&product.ID, &product.Name, &product.Description,
-			&product.Price, &product.UserID, &product.IsActive, 
-			&product.CreatedAt, &product.UpdatedAt,
-		)
+	err := database.Retry(context.Background(), r.db, operation, func() error {
+		rows, err := r.db.Query(query, args...)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		defer rows.Close()
+
+		products = nil
+		for rows.Next() {
+			var product Product
+			if err := scanProduct(rows, &product); err != nil {
+				return err
+			}
+			products = append(products, product)
 		}
-		products = append(products, product)
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return products, nil
 }
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct reads a row selected with productColumns
+func scanProduct(row rowScanner, product *Product) error {
+	return row.Scan(
+		&product.ID, &product.OrgID, &product.Name, &product.Description,
+		&product.Price, &product.UserID, &product.Category, &product.ImageURL,
+		&product.ImageKey, &product.IsActive, &product.CreatedAt, &product.UpdatedAt,
+		&product.AvailableFrom, &product.AvailableUntil,
+	)
+}
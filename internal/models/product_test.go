@@ -0,0 +1,95 @@
+package models
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database/dbtest"
+)
+
+func TestProductAvailableAt(t *testing.T) {
+	from := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	until := from.Add(24 * time.Hour)
+
+	tests := []struct {
+		name  string
+		from  *time.Time
+		until *time.Time
+		at    time.Time
+		want  bool
+	}{
+		{name: "open window", at: from, want: true},
+		{name: "just before start", from: &from, until: &until, at: from.Add(-time.Nanosecond), want: false},
+		{name: "at start", from: &from, until: &until, at: from, want: true},
+		{name: "inside", from: &from, until: &until, at: from.Add(time.Hour), want: true},
+		{name: "just before end", from: &from, until: &until, at: until.Add(-time.Nanosecond), want: true},
+		{name: "at end", from: &from, until: &until, at: until, want: false},
+		{name: "after end", from: &from, until: &until, at: until.Add(time.Hour), want: false},
+		{name: "start only, before", from: &from, at: from.Add(-time.Hour), want: false},
+		{name: "start only, long after", from: &from, at: from.Add(365 * 24 * time.Hour), want: true},
+		{name: "end only, long before", until: &until, at: until.Add(-365 * 24 * time.Hour), want: true},
+		{name: "end only, at end", until: &until, at: until, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := &Product{AvailableFrom: tt.from, AvailableUntil: tt.until}
+			if got := product.AvailableAt(tt.at); got != tt.want {
+				t.Errorf("AvailableAt(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+// productRows returns rows shaped like productColumns holding one product
+// per ID, all belonging to orgID
+func productRows(orgID int, ids ...int) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "org_id", "name", "description", "price", "user_id", "category", "image_url",
+		"image_key", "is_active", "created_at", "updated_at", "available_from", "available_until",
+	})
+	for _, id := range ids {
+		rows.AddRow(id, orgID, "Widget", "", 9.99, 1, "", "", "", true, time.Now(), time.Now(), nil, nil)
+	}
+	return rows
+}
+
+func TestProductRepositoryGetAllPaginated(t *testing.T) {
+	const orgID = 3
+	db, mock := dbtest.New(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE org_id = $1 AND is_active = true")).
+		WithArgs(orgID, 0, 2, 4).
+		WillReturnRows(productRows(orgID, 5, 6))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM products WHERE org_id = $1")).
+		WithArgs(orgID, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	products, total, err := NewProductRepository(db).ForOrg(orgID).GetAllPaginated(2, 4)
+	if err != nil {
+		t.Fatalf("GetAllPaginated() error = %v", err)
+	}
+	if len(products) != 2 || total != 7 {
+		t.Errorf("GetAllPaginated() = %d products, total %d, want 2 products, total 7", len(products), total)
+	}
+}
+
+func TestProductRepositorySearch(t *testing.T) {
+	const orgID = 3
+	db, mock := dbtest.New(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE org_id = $1 AND is_active = true AND (name ILIKE $2 OR description ILIKE $2)")).
+		WithArgs(orgID, `%50\%%`, 0, 10, 0).
+		WillReturnRows(productRows(orgID, 5))
+
+	products, err := NewProductRepository(db).ForOrg(orgID).Search(" 50% ", 10, 0)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(products) != 1 {
+		t.Errorf("Search() = %d products, want 1", len(products))
+	}
+}
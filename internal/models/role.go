@@ -0,0 +1,46 @@
+package models
+
+import (
+	"context"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+)
+
+// RoleRepository handles database operations for roles
+type RoleRepository struct {
+	db database.DB
+}
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository(db database.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// GetNames retrieves the names of all defined roles
+func (r *RoleRepository) GetNames() ([]string, error) {
+	query := "SELECT name FROM roles ORDER BY name"
+
+	var names []string
+	err := database.Retry(context.Background(), r.db, "role_get_names", func() error {
+		rows, err := r.db.Query(query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		names = nil
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+			names = append(names, name)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
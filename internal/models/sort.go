@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidSort is returned when a sort parameter names a field that is not allowlisted
+var ErrInvalidSort = errors.New("invalid sort field")
+
+// SortFields maps public sort keys to the SQL columns they order by.
+// Only values from this map ever reach an ORDER BY clause.
+type SortFields map[string]string
+
+// ProductSortFields lists the fields product listings may be sorted by
+var ProductSortFields = SortFields{
+	"id":         "id",
+	"name":       "name",
+	"price":      "price",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// UserSortFields lists the fields user listings may be sorted by
+var UserSortFields = SortFields{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"created_at": "created_at",
+	"last_login": "last_login",
+}
+
+// OrderBy converts a sort parameter such as "-created_at,name" into an
+// ORDER BY clause. A leading "-" sorts descending. An empty parameter
+// falls back to def, which uses the same syntax.
+func (f SortFields) OrderBy(sort, def string) (string, error) {
+	if strings.TrimSpace(sort) == "" {
+		sort = def
+	}
+
+	seen := make(map[string]bool)
+	var terms []string
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			field = field[1:]
+			direction = "DESC"
+		}
+
+		column, ok := f[field]
+		if !ok || seen[field] {
+			return "", fmt.Errorf("%w: %q", ErrInvalidSort, field)
+		}
+		seen[field] = true
+
+		if direction == "DESC" {
+			terms = append(terms, column+" DESC NULLS LAST")
+		} else {
+			terms = append(terms, column+" ASC")
+		}
+	}
+
+	return "ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+// pageClause returns a parameterized LIMIT/OFFSET clause, appending its
+// values to args. A zero limit pages nothing and returns an empty clause.
+func pageClause(args *[]interface{}, limit, offset int) string {
+	if limit <= 0 {
+		return ""
+	}
+	*args = append(*args, limit, offset)
+	return fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(*args)-1, len(*args))
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +13,7 @@ import (
 // User represents a user in the system
 type User struct {
 	ID            int        `json:"id"`
+	OrgID         int        `json:"org_id"`
 	Name          string     `json:"name"`
 	Email         string     `json:"email"`
 	PasswordHash  string     `json:"-"` // Never send password hash in JSON
@@ -19,18 +23,46 @@ type User struct {
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 	Roles         []string   `json:"roles,omitempty"`
+	Permissions   []string   `json:"permissions,omitempty"`
+	TokenVersion  int        `json:"-"` // Tokens issued with an older version are rejected
+
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+}
+
+// PasswordExpired reports whether the password is at least maxAge old at
+// now. A zero maxAge disables expiry, and passwords with no recorded change
+// time never expire.
+func (u *User) PasswordExpired(maxAge time.Duration, now time.Time) bool {
+	if maxAge <= 0 || u.PasswordChangedAt == nil {
+		return false
+	}
+	return !now.Before(u.PasswordChangedAt.Add(maxAge))
 }
 
+// ErrAdminExists is returned when bootstrapping an admin after one already exists
+var ErrAdminExists = errors.New("an admin user already exists")
+
+// DefaultOrgID is the organization every user belongs to in single-tenant deployments
+const DefaultOrgID = 1
+
 // LoginRequest represents login credentials
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+// ChangePasswordRequest represents a user changing their own password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+	RevokeToken     bool   `json:"revoke_token"` // Revoke the token used for the change, requiring a new login
+}
+
 // LoginResponse represents successful login response
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token           string `json:"token"`
+	User            User   `json:"user"`
+	PasswordExpired bool   `json:"password_expired"` // The token only reaches the password change routes
 }
 
 // CreateUserRequest represents user registration data
@@ -54,16 +86,20 @@ func NewUserRepository(db database.DB) *UserRepository {
 func (r *UserRepository) GetByEmail(email string) (*User, error) {
 	user := &User{}
 	query := `
-		SELECT u.id, u.name, u.email, u.password_hash, u.email_verified, 
-		       u.is_active, u.last_login, u.created_at, u.updated_at
+		SELECT u.id, u.org_id, u.name, u.email, u.password_hash, u.email_verified, 
+		       u.is_active, u.last_login, u.created_at, u.updated_at, u.token_version,
+		       u.password_changed_at
 		FROM users u 
 		WHERE u.email = $1 AND u.is_active = true`
 
-	err := r.db.QueryRow(query, email).Scan(
-		&user.ID, &user.Name, &user.Email, &user.PasswordHash,
-		&user.EmailVerified, &user.IsActive, &user.LastLogin, 
-		&user.CreatedAt, &user.UpdatedAt,
-	)
+	err := database.Retry(context.Background(), r.db, "user_get_by_email", func() error {
+		return r.db.QueryRow(query, email).Scan(
+			&user.ID, &user.OrgID, &user.Name, &user.Email, &user.PasswordHash,
+			&user.EmailVerified, &user.IsActive, &user.LastLogin, 
+			&user.CreatedAt, &user.UpdatedAt, &user.TokenVersion,
+			&user.PasswordChangedAt,
+		)
+	})
 
 	if err != nil {
 		return nil, err
@@ -76,6 +112,13 @@ func (r *UserRepository) GetByEmail(email string) (*User, error) {
 	}
 	user.Roles = roles
 
+	// Get permissions granted by those roles
+	permissions, err := r.getUserPermissions(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	user.Permissions = permissions
+
 	return user, nil
 }
 
@@ -83,16 +126,20 @@ func (r *UserRepository) GetByEmail(email string) (*User, error) {
 func (r *UserRepository) GetByID(id int) (*User, error) {
 	user := &User{}
 	query := `
-		SELECT u.id, u.name, u.email, u.password_hash, u.email_verified, 
-		       u.is_active, u.last_login, u.created_at, u.updated_at
+		SELECT u.id, u.org_id, u.name, u.email, u.password_hash, u.email_verified, 
+		       u.is_active, u.last_login, u.created_at, u.updated_at, u.token_version,
+		       u.password_changed_at
 		FROM users u 
 		WHERE u.id = $1 AND u.is_active = true`
 
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID, &user.Name, &user.Email, &user.PasswordHash,
-		&user.EmailVerified, &user.IsActive, &user.LastLogin, 
-		&user.CreatedAt, &user.UpdatedAt,
-	)
+	err := database.Retry(context.Background(), r.db, "user_get_by_id", func() error {
+		return r.db.QueryRow(query, id).Scan(
+			&user.ID, &user.OrgID, &user.Name, &user.Email, &user.PasswordHash,
+			&user.EmailVerified, &user.IsActive, &user.LastLogin, 
+			&user.CreatedAt, &user.UpdatedAt, &user.TokenVersion,
+			&user.PasswordChangedAt,
+		)
+	})
 
 	if err != nil {
 		return nil, err
@@ -105,14 +152,233 @@ func (r *UserRepository) GetByID(id int) (*User, error) {
 	}
 	user.Roles = roles
 
+	// Get permissions granted by those roles
+	permissions, err := r.getUserPermissions(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	user.Permissions = permissions
+
 	return user, nil
 }
 
 // UpdateLastLogin updates the user's last login timestamp
 func (r *UserRepository) UpdateLastLogin(userID int) error {
 	query := "UPDATE users SET last_login = CURRENT_TIMESTAMP WHERE id = $1"
-	_, err := r.db.Exec(query, userID)
-	return err
+	return database.Retry(context.Background(), r.db, "user_update_last_login", func() error {
+		_, err := r.db.Exec(query, userID)
+		return err
+	})
+}
+
+// GetTokenVersion returns the current token version of an active user
+func (r *UserRepository) GetTokenVersion(userID int) (int, error) {
+	var version int
+	query := "SELECT token_version FROM users WHERE id = $1 AND is_active = true"
+	err := database.Retry(context.Background(), r.db, "user_get_token_version", func() error {
+		return r.db.QueryRow(query, userID).Scan(&version)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// UpdatePasswordHash replaces a user's password and, in the same statement,
+// bumps their token version so every existing session is signed out.
+// Returns sql.ErrNoRows if the user does not exist.
+func (r *UserRepository) UpdatePasswordHash(userID int, passwordHash string) error {
+	return r.setPassword(userID, passwordHash, `
+		UPDATE users
+		SET password_hash = $1, password_changed_at = NOW(),
+		    token_version = token_version + 1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING org_id`)
+}
+
+// UpdatePassword replaces a user's password hash and records when it
+// changed. Existing sessions are left alone; see UpdatePasswordHash to sign
+// them out. Returns sql.ErrNoRows if the user does not exist.
+func (r *UserRepository) UpdatePassword(userID int, passwordHash string) error {
+	return r.setPassword(userID, passwordHash, `
+		UPDATE users
+		SET password_hash = $1, password_changed_at = NOW(), updated_at = NOW()
+		WHERE id = $2
+		RETURNING org_id`)
+}
+
+// setPassword runs query, an UPDATE of the password returning the user's
+// organization, and records a user.password_changed event (without the
+// hash) in the same transaction
+func (r *UserRepository) setPassword(userID int, passwordHash, query string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var orgID int
+	if err := tx.QueryRow(query, passwordHash, userID).Scan(&orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if err := recordEvent(tx, orgID, EventUserPasswordChanged, "user", userID, map[string]int{"id": userID}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// MarkEmailVerified records that the user has proven control of their
+// email address. Returns sql.ErrNoRows if the user does not exist.
+func (r *UserRepository) MarkEmailVerified(userID int) error {
+	query := "UPDATE users SET email_verified = true, updated_at = NOW() WHERE id = $1"
+	result, err := r.db.Exec(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RehashPassword replaces a user's password hash with a new hash of the
+// same password, e.g. at a higher bcrypt cost. Unlike UpdatePassword it
+// leaves password_changed_at alone, so a rehash does not postpone password
+// expiry. Returns sql.ErrNoRows if the user does not exist.
+func (r *UserRepository) RehashPassword(userID int, passwordHash string) error {
+	query := "UPDATE users SET password_hash = $1 WHERE id = $2"
+	result, err := r.db.Exec(query, passwordHash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to rehash password: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// IncrementTokenVersion invalidates every token issued to the user so far
+func (r *UserRepository) IncrementTokenVersion(userID int) error {
+	query := "UPDATE users SET token_version = token_version + 1 WHERE id = $1"
+	result, err := r.db.Exec(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to increment token version: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetActive activates or deactivates a user, recording a user.activated or
+// user.deactivated event in the same transaction. Deactivation also bumps
+// the token version, so tokens already issued to the user stop working even
+// where token version checks are the only revocation in place.
+// Returns sql.ErrNoRows if the user does not exist.
+func (r *UserRepository) SetActive(userID int, active bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		UPDATE users
+		SET is_active = $1, updated_at = NOW(),
+		    token_version = CASE WHEN $1 THEN token_version ELSE token_version + 1 END
+		WHERE id = $2
+		RETURNING org_id`
+	var orgID int
+	if err := tx.QueryRow(query, active, userID).Scan(&orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+
+	eventType := EventUserDeactivated
+	if active {
+		eventType = EventUserActivated
+	}
+	payload := map[string]interface{}{"id": userID, "is_active": active}
+	if err := recordEvent(tx, orgID, eventType, "user", userID, payload); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Delete permanently removes a user, active or not, for data-deletion
+// requests. Everything referencing the user is handled explicitly in one
+// transaction rather than left to foreign key actions: their role
+// assignments, sessions and linked identities are deleted, while their
+// products, audit entries and API tokens are kept for the organization with
+// the user reference cleared. The change feed is append-only, so the user's
+// earlier events are kept and a user.deleted event records the deletion.
+// Returns sql.ErrNoRows if the user does not exist.
+func (r *UserRepository) Delete(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	cascade := []string{
+		"DELETE FROM user_roles WHERE user_id = $1",
+		"DELETE FROM user_sessions WHERE user_id = $1",
+		"DELETE FROM user_identities WHERE user_id = $1",
+		"UPDATE products SET user_id = NULL WHERE user_id = $1",
+		"UPDATE audit_logs SET actor_id = NULL WHERE actor_id = $1",
+		"UPDATE api_tokens SET created_by = NULL WHERE created_by = $1",
+	}
+	for _, query := range cascade {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return fmt.Errorf("failed to remove user references: %w", err)
+		}
+	}
+
+	var orgID int
+	if err := tx.QueryRow("DELETE FROM users WHERE id = $1 RETURNING org_id", userID).Scan(&orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	if err := recordEvent(tx, orgID, EventUserDeleted, "user", userID, map[string]int{"id": userID}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetOrgID returns the organization of a user, active or not
+func (r *UserRepository) GetOrgID(userID int) (int, error) {
+	var orgID int
+	query := "SELECT org_id FROM users WHERE id = $1"
+	err := database.Retry(context.Background(), r.db, "user_get_org_id", func() error {
+		return r.db.QueryRow(query, userID).Scan(&orgID)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return orgID, nil
+}
+
+// GetRoles retrieves the roles of a user
+func (r *UserRepository) GetRoles(userID int) ([]string, error) {
+	return r.getUserRoles(userID)
 }
 
 // getUserRoles retrieves all roles for a specific user
@@ -123,22 +389,70 @@ func (r *UserRepository) getUserRoles(userID int) ([]string, error) {
 		JOIN user_roles ur ON r.id = ur.role_id 
 		WHERE ur.user_id = $1`
 
-	rows, err := r.db.Query(query, userID)
+	var roles []string
+	err := database.Retry(context.Background(), r.db, "user_get_roles", func() error {
+		rows, err := r.db.Query(query, userID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		roles = nil
+		for rows.Next() {
+			var role string
+			if err := rows.Scan(&role); err != nil {
+				return err
+			}
+			roles = append(roles, role)
+		}
+		return rows.Err()
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var roles []string
-	for rows.Next() {
-		var role string
-		if err := rows.Scan(&role); err != nil {
-			return nil, err
+	return roles, nil
+}
+
+// GetPermissions retrieves the permissions granted to a user by their roles
+func (r *UserRepository) GetPermissions(userID int) ([]string, error) {
+	return r.getUserPermissions(userID)
+}
+
+// getUserPermissions retrieves the distinct permissions granted through all
+// of a user's roles
+func (r *UserRepository) getUserPermissions(userID int) ([]string, error) {
+	query := `
+		SELECT DISTINCT p.name
+		FROM permissions p
+		JOIN role_permissions rp ON p.id = rp.permission_id
+		JOIN user_roles ur ON rp.role_id = ur.role_id
+		WHERE ur.user_id = $1
+		ORDER BY p.name`
+
+	var permissions []string
+	err := database.Retry(context.Background(), r.db, "user_get_permissions", func() error {
+		rows, err := r.db.Query(query, userID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		permissions = nil
+		for rows.Next() {
+			var permission string
+			if err := rows.Scan(&permission); err != nil {
+				return err
+			}
+			permissions = append(permissions, permission)
 		}
-		roles = append(roles, role)
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return roles, nil
+	return permissions, nil
 }
 
 // HasRole checks if a user has a specific role
@@ -151,7 +465,17 @@ func (u *User) HasRole(role string) bool {
 	return false
 }
 
-// Create creates a new user in the database
+// orgOrDefault returns the organization a new user is created in: the one
+// set by the caller, or the default organization
+func (u *User) orgOrDefault() int {
+	if u.OrgID == 0 {
+		return DefaultOrgID
+	}
+	return u.OrgID
+}
+
+// Create creates a new user in the organization set in user.OrgID, or the
+// default organization when unset
 func (r *UserRepository) Create(user *User) error {
 	// Start a transaction for creating user and assigning default role
 	tx, err := r.db.Begin()
@@ -162,12 +486,12 @@ func (r *UserRepository) Create(user *User) error {
 
 	// Insert the user
 	query := `
-		INSERT INTO users (name, email, password_hash, email_verified, is_active) 
-		VALUES ($1, $2, $3, $4, $5) 
-		RETURNING id, created_at, updated_at`
+		INSERT INTO users (org_id, name, email, password_hash, email_verified, is_active) 
+		VALUES ($1, $2, $3, $4, $5, $6) 
+		RETURNING id, org_id, created_at, updated_at`
 
-	err = tx.QueryRow(query, user.Name, user.Email, user.PasswordHash, user.EmailVerified, user.IsActive).
-		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	err = tx.QueryRow(query, user.orgOrDefault(), user.Name, user.Email, user.PasswordHash, user.EmailVerified, user.IsActive).
+		Scan(&user.ID, &user.OrgID, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
@@ -182,24 +506,216 @@ func (r *UserRepository) Create(user *User) error {
 		return fmt.Errorf("failed to assign default role: %w", err)
 	}
 
+	// Set the default role in the user object
+	user.Roles = []string{"user"}
+
+	if err := recordEvent(tx, user.OrgID, EventUserCreated, "user", user.ID, user); err != nil {
+		return err
+	}
+
 	// Commit the transaction
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
-
-	// Set the default role in the user object
-	user.Roles = []string{"user"}
 	
 	return nil
 }
 
+// Count returns the total number of users
+func (r *UserRepository) Count() (int, error) {
+	var total int
+	err := database.Retry(context.Background(), r.db, "user_count", func() error {
+		return r.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&total)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// DefaultUserSort is the user listing order used when no sort is requested
+const DefaultUserSort = "-created_at"
+
+// List retrieves one page of an organization's users, active or not, newest
+// first, together with the organization's total number of users. A zero
+// limit returns every user.
+func (r *UserRepository) List(orgID, limit, offset int) ([]User, int, error) {
+	return r.ListSorted(orgID, DefaultUserSort, limit, offset)
+}
+
+// ListSorted is List ordered by sort, a UserSortFields sort parameter such
+// as "-created_at,name". Returns ErrInvalidSort for fields that are not in
+// UserSortFields.
+func (r *UserRepository) ListSorted(orgID int, sort string, limit, offset int) ([]User, int, error) {
+	orderBy, err := UserSortFields.OrderBy(sort, DefaultUserSort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args := []interface{}{orgID}
+	query := `
+		SELECT id, org_id, name, email, email_verified, is_active, last_login,
+		       created_at, updated_at, password_changed_at
+		FROM users
+		WHERE org_id = $1
+		` + orderBy + pageClause(&args, limit, offset)
+
+	var users []User
+	err = database.Retry(context.Background(), r.db, "user_list", func() error {
+		rows, err := r.db.Query(query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		users = []User{}
+		for rows.Next() {
+			var user User
+			err := rows.Scan(
+				&user.ID, &user.OrgID, &user.Name, &user.Email, &user.EmailVerified,
+				&user.IsActive, &user.LastLogin, &user.CreatedAt, &user.UpdatedAt,
+				&user.PasswordChangedAt,
+			)
+			if err != nil {
+				return err
+			}
+			users = append(users, user)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = database.Retry(context.Background(), r.db, "user_count_by_org", func() error {
+		return r.db.QueryRow("SELECT COUNT(*) FROM users WHERE org_id = $1", orgID).Scan(&total)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// adminExistsQuery checks whether any active user holds the admin role
+const adminExistsQuery = `
+		SELECT EXISTS(
+			SELECT 1 FROM users u
+			JOIN user_roles ur ON u.id = ur.user_id
+			JOIN roles ro ON ro.id = ur.role_id
+			WHERE ro.name = 'admin' AND u.is_active = true
+		)`
+
+// AdminExists reports whether any active user holds the admin role
+func (r *UserRepository) AdminExists() (bool, error) {
+	var exists bool
+	err := database.Retry(context.Background(), r.db, "user_admin_exists", func() error {
+		return r.db.QueryRow(adminExistsQuery).Scan(&exists)
+	})
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// CreateFirstAdmin creates user with the user and admin roles, but only if
+// no admin exists yet; otherwise it returns ErrAdminExists. An advisory lock
+// held for the transaction keeps concurrent bootstraps from both succeeding.
+func (r *UserRepository) CreateFirstAdmin(user *User) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('bootstrap_admin'))"); err != nil {
+		return fmt.Errorf("failed to lock admin bootstrap: %w", err)
+	}
+
+	var exists bool
+	if err := tx.QueryRow(adminExistsQuery).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check for admins: %w", err)
+	}
+	if exists {
+		return ErrAdminExists
+	}
+
+	query := `
+		INSERT INTO users (org_id, name, email, password_hash, email_verified, is_active) 
+		VALUES ($1, $2, $3, $4, $5, $6) 
+		RETURNING id, org_id, created_at, updated_at`
+	err = tx.QueryRow(query, user.orgOrDefault(), user.Name, user.Email, user.PasswordHash, user.EmailVerified, user.IsActive).
+		Scan(&user.ID, &user.OrgID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	roleQuery := `
+		INSERT INTO user_roles (user_id, role_id) 
+		SELECT $1, id FROM roles WHERE name IN ('user', 'admin')`
+	if _, err := tx.Exec(roleQuery, user.ID); err != nil {
+		return fmt.Errorf("failed to assign admin role: %w", err)
+	}
+
+	user.Roles = []string{"user", "admin"}
+	if err := recordEvent(tx, user.OrgID, EventUserCreated, "user", user.ID, user); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 // EmailExists checks if an email address is already registered
 func (r *UserRepository) EmailExists(email string) (bool, error) {
 	var count int
 	query := "SELECT COUNT(*) FROM users WHERE email = $1"
-	err := r.db.QueryRow(query, email).Scan(&count)
+	err := database.Retry(context.Background(), r.db, "user_email_exists", func() error {
+		return r.db.QueryRow(query, email).Scan(&count)
+	})
 	if err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
+
+// GetByOrgID retrieves all active users belonging to an organization
+func (r *UserRepository) GetByOrgID(orgID int) ([]User, error) {
+	query := `
+		SELECT u.id, u.org_id, u.name, u.email, u.password_hash, u.email_verified, 
+		       u.is_active, u.last_login, u.created_at, u.updated_at
+		FROM users u 
+		WHERE u.org_id = $1 AND u.is_active = true
+		ORDER BY u.created_at DESC`
+
+	var users []User
+	err := database.Retry(context.Background(), r.db, "user_get_by_org", func() error {
+		rows, err := r.db.Query(query, orgID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		users = nil
+		for rows.Next() {
+			var user User
+			err := rows.Scan(
+				&user.ID, &user.OrgID, &user.Name, &user.Email, &user.PasswordHash,
+				&user.EmailVerified, &user.IsActive, &user.LastLogin, 
+				&user.CreatedAt, &user.UpdatedAt,
+			)
+			if err != nil {
+				return err
+			}
+			users = append(users, user)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrRoleNotFound is returned when a role name is not defined in the roles table
+var ErrRoleNotFound = errors.New("role not found")
+
+// AssignRole grants a role to a user and invalidates their tokens, so the
+// next login carries the new role. Assigning a role the user already has
+// is a no-op. Returns ErrRoleNotFound for undefined roles and sql.ErrNoRows
+// if the user does not exist.
+func (r *UserRepository) AssignRole(userID int, role string) error {
+	return r.changeRole(userID, role, EventUserRoleAssigned, `
+		INSERT INTO user_roles (user_id, role_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, role_id) DO NOTHING`)
+}
+
+// RemoveRole revokes a role from a user and invalidates their tokens, so a
+// demotion cannot be outlived by a token issued before it. Removing a role
+// the user does not have is a no-op. Returns ErrRoleNotFound for undefined roles and
+// sql.ErrNoRows if the user does not exist.
+func (r *UserRepository) RemoveRole(userID int, role string) error {
+	return r.changeRole(userID, role, EventUserRoleRemoved, "DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2")
+}
+
+// changeRole resolves the role and checks the user exists before running
+// query with the user and role IDs, bumping the token version and recording
+// eventType, all in one transaction
+func (r *UserRepository) changeRole(userID int, role, eventType, query string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var roleID int
+	err = tx.QueryRow("SELECT id FROM roles WHERE name = $1", role).Scan(&roleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRoleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up role: %w", err)
+	}
+
+	// Lock the user row so the user cannot be deleted mid-change
+	var orgID int
+	if err := tx.QueryRow("SELECT org_id FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&orgID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(query, userID, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to change role: %w", err)
+	}
+	// Assigning a role the user has, or removing one they lack, changes nothing
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec("UPDATE users SET token_version = token_version + 1 WHERE id = $1", userID); err != nil {
+		return fmt.Errorf("failed to increment token version: %w", err)
+	}
+
+	payload := map[string]interface{}{"id": userID, "role": role}
+	if err := recordEvent(tx, orgID, eventType, "user", userID, payload); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
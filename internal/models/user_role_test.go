@@ -0,0 +1,79 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database/dbtest"
+)
+
+func TestUserRepositoryChangeRole(t *testing.T) {
+	const orgID = 3
+
+	tests := []struct {
+		name       string
+		change     func(r *UserRepository) error
+		roleExists bool
+		userExists bool
+		changed    bool // Whether the user_roles statement affects a row
+		wantEvent  string
+		wantErr    error
+	}{
+		{name: "assign new role", change: func(r *UserRepository) error { return r.AssignRole(42, "moderator") },
+			roleExists: true, userExists: true, changed: true, wantEvent: EventUserRoleAssigned},
+		{name: "assign held role", change: func(r *UserRepository) error { return r.AssignRole(42, "moderator") },
+			roleExists: true, userExists: true},
+		{name: "remove held role", change: func(r *UserRepository) error { return r.RemoveRole(42, "moderator") },
+			roleExists: true, userExists: true, changed: true, wantEvent: EventUserRoleRemoved},
+		{name: "unknown role", change: func(r *UserRepository) error { return r.AssignRole(42, "moderator") },
+			wantErr: ErrRoleNotFound},
+		{name: "missing user", change: func(r *UserRepository) error { return r.RemoveRole(42, "moderator") },
+			roleExists: true, wantErr: sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.New(t)
+
+			mock.ExpectBegin()
+			role := sqlmock.NewRows([]string{"id"})
+			if tt.roleExists {
+				role.AddRow(5)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM roles WHERE name = $1")).WithArgs("moderator").WillReturnRows(role)
+			if tt.roleExists {
+				user := sqlmock.NewRows([]string{"org_id"})
+				if tt.userExists {
+					user.AddRow(orgID)
+				}
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT org_id FROM users WHERE id = $1 FOR UPDATE")).WithArgs(42).WillReturnRows(user)
+			}
+			if tt.userExists {
+				affected := int64(0)
+				if tt.changed {
+					affected = 1
+				}
+				mock.ExpectExec(regexp.QuoteMeta("user_roles")).WithArgs(42, 5).WillReturnResult(sqlmock.NewResult(0, affected))
+			}
+			if tt.wantEvent != "" {
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET token_version = token_version + 1 WHERE id = $1")).
+					WithArgs(42).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectEventLock(mock)
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO events")).
+					WithArgs(orgID, tt.wantEvent, "user", 42, `{"id":42,"role":"moderator"}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			if err := tt.change(NewUserRepository(db)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database/dbtest"
+)
+
+// userDeleteCascade lists, in order, the statements Delete runs before
+// removing the user row
+var userDeleteCascade = []string{
+	"DELETE FROM user_roles WHERE user_id = $1",
+	"DELETE FROM user_sessions WHERE user_id = $1",
+	"DELETE FROM user_identities WHERE user_id = $1",
+	"UPDATE products SET user_id = NULL WHERE user_id = $1",
+	"UPDATE audit_logs SET actor_id = NULL WHERE actor_id = $1",
+	"UPDATE api_tokens SET created_by = NULL WHERE created_by = $1",
+}
+
+// expectEventLock expects recordEvent's event ordering lock
+func expectEventLock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta("pg_advisory_xact_lock(hashtext('events.order'))")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestUserRepositoryDelete(t *testing.T) {
+	const orgID = 3
+
+	tests := []struct {
+		name    string
+		exists  bool
+		wantErr error
+	}{
+		// Users created through Create have a user.created event; the
+		// append-only events table must only ever be inserted into
+		{name: "user with events", exists: true},
+		{name: "missing user", exists: false, wantErr: sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.New(t)
+
+			mock.ExpectBegin()
+			for _, query := range userDeleteCascade {
+				mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(42).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			deleted := sqlmock.NewRows([]string{"org_id"})
+			if tt.exists {
+				deleted.AddRow(orgID)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM users WHERE id = $1 RETURNING org_id")).WithArgs(42).
+				WillReturnRows(deleted)
+			if tt.wantErr == nil {
+				// The event lands in the deleted user's organization
+				expectEventLock(mock)
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO events")).
+					WithArgs(orgID, EventUserDeleted, "user", 42, `{"id":42}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err := NewUserRepository(db).Delete(42)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUserRepositorySetActive(t *testing.T) {
+	const orgID = 3
+
+	tests := []struct {
+		name      string
+		active    bool
+		exists    bool
+		wantEvent string
+		wantErr   error
+	}{
+		{name: "deactivate", active: false, exists: true, wantEvent: EventUserDeactivated},
+		{name: "reactivate", active: true, exists: true, wantEvent: EventUserActivated},
+		{name: "missing user", exists: false, wantErr: sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.New(t)
+
+			updated := sqlmock.NewRows([]string{"org_id"})
+			if tt.exists {
+				updated.AddRow(orgID)
+			}
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta("UPDATE users")).WithArgs(tt.active, 42).WillReturnRows(updated)
+			if tt.wantErr == nil {
+				expectEventLock(mock)
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO events")).
+					WithArgs(orgID, tt.wantEvent, "user", 42, fmt.Sprintf(`{"id":42,"is_active":%t}`, tt.active)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err := NewUserRepository(db).SetActive(42, tt.active)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetActive() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryPasswordChangeEvent(t *testing.T) {
+	const orgID = 3
+
+	tests := []struct {
+		name   string
+		update func(r *UserRepository) error
+	}{
+		{name: "sign out sessions", update: func(r *UserRepository) error { return r.UpdatePasswordHash(42, "new-hash") }},
+		{name: "keep sessions", update: func(r *UserRepository) error { return r.UpdatePassword(42, "new-hash") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.New(t)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta("SET password_hash = $1")).WithArgs("new-hash", 42).
+				WillReturnRows(sqlmock.NewRows([]string{"org_id"}).AddRow(orgID))
+			// The payload identifies the user and never carries the hash
+			expectEventLock(mock)
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO events")).
+				WithArgs(orgID, EventUserPasswordChanged, "user", 42, `{"id":42}`).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			if err := tt.update(NewUserRepository(db)); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+		})
+	}
+}
+
+func TestUserPasswordExpired(t *testing.T) {
+	const maxAge = 90 * 24 * time.Hour
+	now := time.Now()
+	changedAt := func(age time.Duration) *time.Time {
+		at := now.Add(-age)
+		return &at
+	}
+
+	tests := []struct {
+		name      string
+		changedAt *time.Time
+		maxAge    time.Duration
+		want      bool
+	}{
+		{name: "recently changed", changedAt: changedAt(time.Hour), maxAge: maxAge, want: false},
+		{name: "just under the limit", changedAt: changedAt(maxAge - time.Second), maxAge: maxAge, want: false},
+		{name: "at the limit", changedAt: changedAt(maxAge), maxAge: maxAge, want: true},
+		{name: "beyond the limit", changedAt: changedAt(maxAge + time.Second), maxAge: maxAge, want: true},
+		{name: "never changed", maxAge: maxAge, want: false},
+		{name: "expiry disabled", changedAt: changedAt(10 * maxAge), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &User{PasswordChangedAt: tt.changedAt}
+			if got := user.PasswordExpired(tt.maxAge, now); got != tt.want {
+				t.Errorf("PasswordExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
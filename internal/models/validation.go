@@ -0,0 +1,50 @@
+package models
+
+import (
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// Validate checks that login credentials are present
+func (req LoginRequest) Validate() validator.ValidationErrors {
+	var errors validator.ValidationErrors
+
+	if err := validator.ValidateRequired("email", req.Email); err != nil {
+		errors.AddError("email", err)
+	}
+	if err := validator.ValidateRequired("password", req.Password); err != nil {
+		errors.AddError("password", err)
+	}
+
+	return errors
+}
+
+// Validate applies the registration rules for name, email, and password
+func (req CreateUserRequest) Validate() validator.ValidationErrors {
+	return validator.ValidateUserRegistration(req.Name, req.Email, req.Password)
+}
+
+// Validate checks the product name, price, and publish window. The category
+// is checked against the categories table by the handler, since that needs
+// the database.
+func (req CreateProductRequest) Validate() validator.ValidationErrors {
+	errs := validator.ValidateProduct(req.Name, req.Price)
+	if err := validator.ValidateAvailability(req.AvailableFrom, req.AvailableUntil); err != nil {
+		errs.AddError("available_until", err)
+	}
+	return errs
+}
+
+// Validate checks that the current password is present and the new one
+// meets the password rules
+func (req ChangePasswordRequest) Validate() validator.ValidationErrors {
+	var errors validator.ValidationErrors
+
+	if err := validator.ValidateRequired("current_password", req.CurrentPassword); err != nil {
+		errors.AddError("current_password", err)
+	}
+	if err := validator.ValidatePassword(req.NewPassword); err != nil {
+		errors.AddError("new_password", err)
+	}
+
+	return errors
+}
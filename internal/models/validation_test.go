@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+func TestRequestValidation(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		req  validator.Validator
+		want []string // field:code of each error, in order
+	}{
+		{name: "login valid", req: LoginRequest{Email: "user@example.com", Password: "x"}},
+		{name: "login missing both", req: LoginRequest{},
+			want: []string{"email:" + validator.CodeRequired, "password:" + validator.CodeRequired}},
+
+		{name: "registration valid", req: CreateUserRequest{Name: "Ada", Email: "ada@example.com", Password: "Passw0rd"}},
+		{name: "registration short name", req: CreateUserRequest{Name: "A", Email: "ada@example.com", Password: "Passw0rd"},
+			want: []string{"name:" + validator.CodeTooShort}},
+		{name: "registration bad email", req: CreateUserRequest{Name: "Ada", Email: "ada", Password: "Passw0rd"},
+			want: []string{"email:" + validator.CodeInvalidEmail}},
+		{name: "registration weak password", req: CreateUserRequest{Name: "Ada", Email: "ada@example.com", Password: "password1"},
+			want: []string{"password:" + validator.CodePasswordUppercase}},
+
+		{name: "product valid", req: CreateProductRequest{Name: "Widget", Price: 9.99, AvailableFrom: &now, AvailableUntil: &later}},
+		{name: "product missing name and negative price", req: CreateProductRequest{Price: -1},
+			want: []string{"name:" + validator.CodeRequired, "price:" + validator.CodeNegative}},
+		{name: "product price too large", req: CreateProductRequest{Name: "Widget", Price: 100000000},
+			want: []string{"price:" + validator.CodeTooLarge}},
+		{name: "product window ends before it starts", req: CreateProductRequest{Name: "Widget", AvailableFrom: &later, AvailableUntil: &now},
+			want: []string{"available_until:" + validator.CodeInvalidRange}},
+
+		{name: "password change valid", req: ChangePasswordRequest{CurrentPassword: "old", NewPassword: "Passw0rd"}},
+		{name: "password change missing current and short new", req: ChangePasswordRequest{NewPassword: "Pa1"},
+			want: []string{"current_password:" + validator.CodeRequired, "new_password:" + validator.CodePasswordTooShort}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range validator.Validate(tt.req) {
+				got = append(got, fmt.Sprintf("%s:%s", err.Field, err.Code))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
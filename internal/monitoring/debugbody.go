@@ -0,0 +1,150 @@
+package monitoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DebugBodyMaxBytes caps how much of each request and response body is
+// captured for debug logging. Larger bodies are not logged at all, since a
+// truncated body cannot be reliably redacted.
+const DebugBodyMaxBytes = 4096
+
+// redactedField replaces the values of sensitive fields in debug logs
+const redactedField = "[REDACTED]"
+
+// sensitiveField reports whether a body field must never be logged
+func sensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, marker := range []string{"password", "token", "secret", "hash", "authorization", "api_key"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// cappedBuffer records up to DebugBodyMaxBytes, noting whether more was written
+type cappedBuffer struct {
+	bytes.Buffer
+	truncated bool
+}
+
+func (c *cappedBuffer) record(p []byte) {
+	if room := DebugBodyMaxBytes - c.Len(); room < len(p) {
+		c.truncated = true
+		p = p[:max(room, 0)]
+	}
+	c.Write(p)
+}
+
+// teeBody copies what the handler reads from the request body into buf
+type teeBody struct {
+	io.ReadCloser
+	buf *cappedBuffer
+}
+
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.buf.record(p[:n])
+	return n, err
+}
+
+// DebugBodies logs the request and response bodies of next at debug level,
+// with password, token, and similar fields redacted. It is meant to be
+// enabled for individual routes while debugging and does nothing unless the
+// logger is at debug level. Only the part of the request body the handler
+// reads is logged.
+func (m *Monitor) DebugBodies(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if m.Logger == nil || !m.Logger.Enabled(r.Context(), slog.LevelDebug) {
+			next(w, r)
+			return
+		}
+
+		requestBody := &cappedBuffer{}
+		if r.Body != nil {
+			r.Body = &teeBody{ReadCloser: r.Body, buf: requestBody}
+		}
+		rw := &responseWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+			capture:        &cappedBuffer{},
+		}
+
+		next(rw, r)
+
+		m.Logger.LogAttrs(r.Context(), slog.LevelDebug, "HTTP request body",
+			slog.String("route", route),
+			slog.String("method", r.Method),
+			slog.Int("status", rw.statusCode),
+			slog.String("request_body", redactBody(r.Header.Get("Content-Type"), requestBody)),
+			slog.String("response_body", redactBody(rw.Header().Get("Content-Type"), rw.capture)),
+		)
+	}
+}
+
+// redactBody renders a captured body for logging. JSON and form bodies are
+// logged with sensitive fields redacted; anything else, and any body too
+// large to capture whole, is summarized without its content.
+func redactBody(contentType string, body *cappedBuffer) string {
+	if body.Len() == 0 && !body.truncated {
+		return ""
+	}
+	if body.truncated {
+		return fmt.Sprintf("[omitted: larger than %d bytes]", DebugBodyMaxBytes)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json":
+		var v interface{}
+		if err := json.Unmarshal(body.Bytes(), &v); err != nil {
+			return "[omitted: invalid JSON]"
+		}
+		redacted, err := json.Marshal(redactJSON(v))
+		if err != nil {
+			return "[omitted: invalid JSON]"
+		}
+		return string(redacted)
+	case "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(body.String())
+		if err != nil {
+			return "[omitted: invalid form]"
+		}
+		for name := range form {
+			if sensitiveField(name) {
+				form[name] = []string{redactedField}
+			}
+		}
+		return form.Encode()
+	default:
+		return fmt.Sprintf("[omitted: %d bytes of %s]", body.Len(), mediaType)
+	}
+}
+
+// redactJSON replaces the values of sensitive object keys at any depth
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if sensitiveField(key) {
+				v[key] = redactedField
+			} else {
+				v[key] = redactJSON(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactJSON(value)
+		}
+	}
+	return v
+}
@@ -0,0 +1,121 @@
+package monitoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugBodiesRedaction(t *testing.T) {
+	large := `{"note":"` + strings.Repeat("a", DebugBodyMaxBytes) + `","password":"big-secret"}`
+
+	tests := []struct {
+		name         string
+		requestType  string
+		request      string
+		responseType string
+		response     string
+		secrets      []string // Values that must not appear anywhere in the log
+		wantRequest  string
+		wantResponse string
+	}{
+		{
+			name:        "JSON login",
+			requestType: "application/json", request: `{"email":"ada@example.com","password":"hunter2"}`,
+			responseType: "application/json", response: `{"token":"eyJ.secret.sig","user":{"email":"ada@example.com","password_hash":"$2a$10$abc"}}`,
+			secrets:      []string{"hunter2", "eyJ.secret.sig", "$2a$10$abc"},
+			wantRequest:  `{"email":"ada@example.com","password":"[REDACTED]"}`,
+			wantResponse: `{"token":"[REDACTED]","user":{"email":"ada@example.com","password_hash":"[REDACTED]"}}`,
+		},
+		{
+			name:        "nested arrays",
+			requestType: "application/json; charset=utf-8", request: `{"keys":[{"name":"ci","api_key":"k-123"}],"client_secret":"s-456"}`,
+			secrets:     []string{"k-123", "s-456"},
+			wantRequest: `{"client_secret":"[REDACTED]","keys":[{"api_key":"[REDACTED]","name":"ci"}]}`,
+		},
+		{
+			name:        "form login",
+			requestType: "application/x-www-form-urlencoded", request: "email=ada%40example.com&password=hunter2",
+			secrets:     []string{"hunter2"},
+			wantRequest: "email=ada%40example.com&password=%5BREDACTED%5D",
+		},
+		{
+			name:        "body too large to redact",
+			requestType: "application/json", request: large,
+			secrets:     []string{"big-secret"},
+			wantRequest: "[omitted: larger than 4096 bytes]",
+		},
+		{
+			name:        "invalid JSON",
+			requestType: "application/json", request: `{"password":"hunter2"`,
+			secrets:     []string{"hunter2"},
+			wantRequest: "[omitted: invalid JSON]",
+		},
+		{
+			name:        "unstructured body",
+			requestType: "text/plain", request: "password=hunter2",
+			secrets:     []string{"hunter2"},
+			wantRequest: "[omitted: 16 bytes of text/plain]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			m := &Monitor{Logger: slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+
+			handler := m.DebugBodies("/login", func(w http.ResponseWriter, r *http.Request) {
+				io.ReadAll(r.Body)
+				if tt.responseType != "" {
+					w.Header().Set("Content-Type", tt.responseType)
+				}
+				w.Write([]byte(tt.response))
+			})
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(tt.request))
+			req.Header.Set("Content-Type", tt.requestType)
+			handler(httptest.NewRecorder(), req)
+
+			for _, secret := range tt.secrets {
+				if strings.Contains(logs.String(), secret) {
+					t.Errorf("log contains %q: %s", secret, logs.String())
+				}
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+				t.Fatalf("log entry %q: %v", logs.String(), err)
+			}
+			if got := entry["request_body"]; got != tt.wantRequest {
+				t.Errorf("request_body = %v, want %v", got, tt.wantRequest)
+			}
+			if got := entry["response_body"]; got != tt.wantResponse {
+				t.Errorf("response_body = %v, want %v", got, tt.wantResponse)
+			}
+		})
+	}
+}
+
+func TestDebugBodiesOffAboveDebugLevel(t *testing.T) {
+	var logs bytes.Buffer
+	m := &Monitor{Logger: slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))}
+
+	var body []byte
+	handler := m.DebugBodies("/login", func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler(httptest.NewRecorder(), req)
+
+	if logs.Len() != 0 {
+		t.Errorf("logged %q, want nothing", logs.String())
+	}
+	if string(body) != `{"password":"hunter2"}` {
+		t.Errorf("handler read %q, want the original body", body)
+	}
+}
@@ -0,0 +1,117 @@
+package monitoring
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// DefaultRequestLogFields is the optional field set logged for each request
+// when no allowlist is configured
+const DefaultRequestLogFields = "method,path,bytes,user_agent,remote_addr"
+
+// fieldMode controls how an optional request field is recorded
+type fieldMode int
+
+const (
+	fieldDropped fieldMode = iota
+	fieldRaw
+	fieldHashed
+)
+
+// requestFields decides which optional request fields are logged and traced.
+// Status, duration and the trace/span IDs are always recorded.
+type requestFields struct {
+	method  fieldMode
+	path    fieldMode
+	bytes   fieldMode
+	agent   fieldMode
+	addr    fieldMode
+	hashKey []byte
+}
+
+// parseRequestFields parses a comma-separated allowlist such as
+// "method,path,remote_addr:hash". Fields not listed are dropped; user_agent
+// and remote_addr may carry a ":hash" suffix to record a keyed HMAC instead
+// of the raw value, which requires hashKey.
+func parseRequestFields(list, hashKey string) (requestFields, error) {
+	f := requestFields{hashKey: []byte(hashKey)}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, modifier, hashed := strings.Cut(entry, ":")
+		mode := fieldRaw
+		if hashed {
+			if modifier != "hash" {
+				return requestFields{}, fmt.Errorf("unknown modifier %q for log field %q", modifier, name)
+			}
+			mode = fieldHashed
+		}
+
+		switch name {
+		case "method":
+			f.method = mode
+		case "path":
+			f.path = mode
+		case "bytes":
+			f.bytes = mode
+		case "user_agent":
+			f.agent = mode
+		case "remote_addr":
+			f.addr = mode
+		default:
+			return requestFields{}, fmt.Errorf("unknown log field %q", name)
+		}
+
+		if mode == fieldHashed && name != "user_agent" && name != "remote_addr" {
+			return requestFields{}, fmt.Errorf("log field %q cannot be hashed", name)
+		}
+		if mode == fieldHashed && hashKey == "" {
+			return requestFields{}, fmt.Errorf("log field %q is hashed but no hash key is configured", name)
+		}
+	}
+	return f, nil
+}
+
+// userAgent returns the user agent as configured; ok is false when dropped
+func (f requestFields) userAgent(r *http.Request) (string, bool) {
+	return f.value(f.agent, r.UserAgent())
+}
+
+// remoteAddr returns the client address as configured. Hashing covers the
+// host only so one client correlates across connections.
+func (f requestFields) remoteAddr(r *http.Request) (string, bool) {
+	addr := r.RemoteAddr
+	if f.addr == fieldHashed {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+	}
+	return f.value(f.addr, addr)
+}
+
+func (f requestFields) value(mode fieldMode, raw string) (string, bool) {
+	switch mode {
+	case fieldRaw:
+		return raw, true
+	case fieldHashed:
+		return f.hash(raw), true
+	default:
+		return "", false
+	}
+}
+
+// hash returns a truncated HMAC-SHA256 of v, stable for a given key so the
+// same client can be correlated without logging the raw value
+func (f requestFields) hash(v string) string {
+	mac := hmac.New(sha256.New, f.hashKey)
+	mac.Write([]byte(v))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,122 @@
+package monitoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	testUserAgent  = "test-agent/1.0"
+	testClientHost = "203.0.113.7"
+	testHashKey    = "log-hash-key"
+)
+
+// logRequest runs one request from port through a monitor logging fields
+// and returns the decoded request log entry
+func logRequest(t *testing.T, fields, hashKey, port string) map[string]any {
+	t.Helper()
+
+	m, err := NewMonitor(Config{RequestLogFields: fields, RequestLogHashKey: hashKey})
+	if err != nil {
+		t.Fatalf("NewMonitor() error = %v", err)
+	}
+	var logs bytes.Buffer
+	m.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/products", nil)
+	req.Header.Set("User-Agent", testUserAgent)
+	req.RemoteAddr = testClientHost + ":" + port
+	m.HTTPMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("log entry %q: %v", logs.String(), err)
+	}
+	return entry
+}
+
+func TestRequestLogFields(t *testing.T) {
+	hashed := requestFields{hashKey: []byte(testHashKey)}
+
+	tests := []struct {
+		name       string
+		fields     string
+		hashKey    string
+		wantFields map[string]any // Optional fields expected in the entry; others must be absent
+	}{
+		{name: "default", wantFields: map[string]any{
+			"method": "GET", "path": "/products", "bytes": 2.0,
+			"user_agent": testUserAgent, "remote_addr": testClientHost + ":1111",
+		}},
+		{name: "client fields dropped", fields: "method,path", wantFields: map[string]any{
+			"method": "GET", "path": "/products",
+		}},
+		{name: "client fields hashed", fields: "path,user_agent:hash,remote_addr:hash", hashKey: testHashKey, wantFields: map[string]any{
+			"path": "/products", "user_agent": hashed.hash(testUserAgent), "remote_addr": hashed.hash(testClientHost),
+		}},
+		{name: "nothing optional", fields: " , ", wantFields: map[string]any{}},
+	}
+
+	optional := []string{"method", "path", "bytes", "user_agent", "remote_addr"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := logRequest(t, tt.fields, tt.hashKey, "1111")
+
+			for _, always := range []string{"status", "duration"} {
+				if _, ok := entry[always]; !ok {
+					t.Errorf("%s missing from %v", always, entry)
+				}
+			}
+			for _, field := range optional {
+				got, ok := entry[field]
+				want, wantOK := tt.wantFields[field]
+				if ok != wantOK || got != want {
+					t.Errorf("%s = %v (present %v), want %v (present %v)", field, got, ok, want, wantOK)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestLogFieldsHashCorrelates(t *testing.T) {
+	const fields = "remote_addr:hash"
+
+	first := logRequest(t, fields, testHashKey, "1111")["remote_addr"]
+	if first == testClientHost {
+		t.Fatalf("remote_addr logged raw")
+	}
+	if got := logRequest(t, fields, testHashKey, "2222")["remote_addr"]; got != first {
+		t.Errorf("remote_addr from another port = %v, want %v", got, first)
+	}
+	if got := logRequest(t, fields, "other-key", "1111")["remote_addr"]; got == first {
+		t.Errorf("remote_addr under another key = %v, want a different hash", got)
+	}
+}
+
+func TestParseRequestFieldsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  string
+		hashKey string
+	}{
+		{name: "unknown field", fields: "method,referer"},
+		{name: "unknown modifier", fields: "user_agent:mask", hashKey: testHashKey},
+		{name: "unhashable field", fields: "path:hash", hashKey: testHashKey},
+		{name: "hash without key", fields: "remote_addr:hash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseRequestFields(tt.fields, tt.hashKey); err == nil {
+				t.Errorf("parseRequestFields(%q) error = nil, want an error", tt.fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package monitoring
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const logFileDateFormat = "2006-01-02"
+
+// dailyLogFile is an io.Writer that appends to logs/app-YYYY-MM-DD.log and
+// switches to a new file on the first write of each day, so a process that
+// runs across midnight still writes each line to the file for its date.
+// Files older than maxAge are removed whenever the file rotates.
+type dailyLogFile struct {
+	mu     sync.Mutex
+	dir    string
+	maxAge time.Duration // Zero keeps files forever
+	day    string
+	file   *os.File
+}
+
+// openDailyLogFile opens today's log file in dir
+func openDailyLogFile(dir string, maxAge time.Duration) (*dailyLogFile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create logs directory: %w", err)
+	}
+
+	d := &dailyLogFile{dir: dir, maxAge: maxAge}
+	if err := d.rotate(time.Now()); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Write appends p to the file for the current date, reopening the file
+// first if the date has changed since the last write
+func (d *dailyLogFile) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	now := time.Now()
+	if now.Format(logFileDateFormat) != d.day {
+		if err := d.rotate(now); err != nil {
+			// Keep logging to the previous day's file rather than dropping lines
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+
+	return d.file.Write(p)
+}
+
+// Close closes the current file
+func (d *dailyLogFile) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file == nil {
+		return nil
+	}
+	err := d.file.Close()
+	d.file = nil
+	return err
+}
+
+// rotate opens the file for now's date and closes the previous one.
+// The caller must hold d.mu (or have exclusive access during setup).
+func (d *dailyLogFile) rotate(now time.Time) error {
+	day := now.Format(logFileDateFormat)
+	file, err := os.OpenFile(
+		filepath.Join(d.dir, fmt.Sprintf("app-%s.log", day)),
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND,
+		0666,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	if d.file != nil {
+		d.file.Close()
+	}
+	d.file = file
+	d.day = day
+
+	d.removeExpired(now)
+	return nil
+}
+
+// removeExpired deletes daily log files whose date is older than maxAge
+func (d *dailyLogFile) removeExpired(now time.Time) {
+	if d.maxAge <= 0 {
+		return
+	}
+
+	matches, err := filepath.Glob(filepath.Join(d.dir, "app-*.log"))
+	if err != nil {
+		return
+	}
+
+	cutoff := now.Add(-d.maxAge)
+	for _, path := range matches {
+		date := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "app-"), ".log")
+		day, err := time.ParseInLocation(logFileDateFormat, date, now.Location())
+		if err != nil {
+			continue // Not one of ours
+		}
+		// A file holds a whole day, so it expires once that day has fully passed the cutoff
+		if day.AddDate(0, 0, 1).Before(cutoff) {
+			os.Remove(path)
+		}
+	}
+}
@@ -8,11 +8,20 @@ import (
 	"time"
 	"context"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// SetRequestAttributes registers fn to derive extra attributes from each
+// request's context. HTTPMiddleware adds them to the request span and the
+// request log line.
+func (m *Monitor) SetRequestAttributes(fn func(context.Context) []attribute.KeyValue) {
+	m.requestAttrs = fn
+}
+
 func (m *Monitor) HTTPMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -24,24 +33,39 @@ func (m *Monitor) HTTPMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 		var span trace.Span
 		ctx := r.Context()
+
+		var extraAttrs []attribute.KeyValue
+		if m.requestAttrs != nil {
+			extraAttrs = m.requestAttrs(ctx)
+		}
+		if requestID, ok := RequestIDFromContext(ctx); ok {
+			extraAttrs = append(extraAttrs, attribute.String("request_id", requestID))
+		}
 		
 		if m.Tracer != nil {
 			ctx = trace.ContextWithRemoteSpanContext(ctx, trace.SpanContextFromContext(r.Context()))
 			
+			spanAttrs := []attribute.KeyValue{
+				attribute.String("http.method", r.Method),
+				attribute.String("http.url", r.URL.String()),
+				attribute.String("http.target", r.URL.Path),
+				attribute.String("http.host", r.Host),
+				attribute.String("http.scheme", r.URL.Scheme),
+			}
+			if ua, ok := m.fields.userAgent(r); ok {
+				spanAttrs = append(spanAttrs, attribute.String("http.user_agent", ua))
+			}
+			if addr, ok := m.fields.remoteAddr(r); ok {
+				spanAttrs = append(spanAttrs, attribute.String("http.remote_addr", addr))
+			}
+
 			ctx, span = m.Tracer.Start(ctx, 
 				fmt.Sprintf("%s %s", r.Method, r.URL.Path),
 				trace.WithSpanKind(trace.SpanKindServer),
-				trace.WithAttributes(
-					attribute.String("http.method", r.Method),
-					attribute.String("http.url", r.URL.String()),
-					attribute.String("http.target", r.URL.Path),
-					attribute.String("http.host", r.Host),
-					attribute.String("http.scheme", r.URL.Scheme),
-					attribute.String("http.user_agent", r.UserAgent()),
-					attribute.String("http.remote_addr", r.RemoteAddr),
-				),
+				trace.WithAttributes(spanAttrs...),
 			)
 			defer span.End()
+			span.SetAttributes(extraAttrs...)
 		}
 
 		rw := &responseWriter{
@@ -60,10 +84,18 @@ func (m *Monitor) HTTPMiddleware(next http.HandlerFunc) http.HandlerFunc {
 				strconv.Itoa(rw.statusCode),
 			).Inc()
 
-			m.Metrics.HTTPRequestDuration.WithLabelValues(
+			observer := m.Metrics.HTTPRequestDuration.WithLabelValues(
 				r.Method,
 				r.URL.Path,
-			).Observe(duration.Seconds())
+			)
+			// Attach the trace ID as an exemplar so a latency spike links to its trace
+			if exemplar, ok := observer.(prometheus.ExemplarObserver); ok && span != nil && span.SpanContext().IsSampled() {
+				exemplar.ObserveWithExemplar(duration.Seconds(), prometheus.Labels{
+					"trace_id": span.SpanContext().TraceID().String(),
+				})
+			} else {
+				observer.Observe(duration.Seconds())
+			}
 		}
 
 		if span != nil {
@@ -87,14 +119,10 @@ func (m *Monitor) HTTPMiddleware(next http.HandlerFunc) http.HandlerFunc {
 				level = slog.LevelWarn
 			}
 
-			logAttrs := []slog.Attr{
-				slog.String("method", r.Method),
-				slog.String("path", r.URL.Path),
-				slog.Int("status", rw.statusCode),
-				slog.Duration("duration", duration),
-				slog.Int("bytes", rw.bytesWritten),
-				slog.String("user_agent", r.UserAgent()),
-				slog.String("remote_addr", r.RemoteAddr),
+			logAttrs := m.requestLogAttrs(r, rw, duration)
+
+			for _, kv := range extraAttrs {
+				logAttrs = append(logAttrs, slog.String(string(kv.Key), kv.Value.Emit()))
 			}
 
 			if span != nil && span.SpanContext().HasTraceID() {
@@ -109,23 +137,70 @@ func (m *Monitor) HTTPMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// requestLogAttrs builds the request log line from the configured field
+// allowlist. Status and duration are always included.
+func (m *Monitor) requestLogAttrs(r *http.Request, rw *responseWriter, duration time.Duration) []slog.Attr {
+	attrs := make([]slog.Attr, 0, 7)
+	if m.fields.method != fieldDropped {
+		attrs = append(attrs, slog.String("method", r.Method))
+	}
+	if m.fields.path != fieldDropped {
+		attrs = append(attrs, slog.String("path", r.URL.Path))
+	}
+	attrs = append(attrs,
+		slog.Int("status", rw.statusCode),
+		slog.Duration("duration", duration),
+	)
+	if m.fields.bytes != fieldDropped {
+		attrs = append(attrs, slog.Int("bytes", rw.bytesWritten))
+	}
+	if ua, ok := m.fields.userAgent(r); ok {
+		attrs = append(attrs, slog.String("user_agent", ua))
+	}
+	if addr, ok := m.fields.remoteAddr(r); ok {
+		attrs = append(attrs, slog.String("remote_addr", addr))
+	}
+	return attrs
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode   int
 	bytesWritten int
+	wroteHeader  bool
+	capture      *cappedBuffer // Records the body for DebugBodies; nil otherwise
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
 
 func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
 	n, err := rw.ResponseWriter.Write(b)
 	rw.bytesWritten += n
+	if rw.capture != nil {
+		rw.capture.record(b[:n])
+	}
 	return n, err
 }
 
+// Written reports whether the status line has been sent, after which the
+// response can no longer be replaced with an error
+func (rw *responseWriter) Written() bool {
+	return rw.wroteHeader
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 func (m *Monitor) TraceSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) func() {
 	if m.Tracer == nil {
 		return func() {}
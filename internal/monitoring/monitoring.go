@@ -2,6 +2,7 @@ package monitoring
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -9,8 +10,8 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -24,13 +25,18 @@ type Monitor struct {
 	TracerProvider *sdktrace.TracerProvider
 	Tracer        trace.Tracer
 	Metrics       *Metrics
-	logFile       *os.File
+	logFile       *dailyLogFile
+	push          pushConfig
+	requestAttrs  func(context.Context) []attribute.KeyValue
+	fields        requestFields
+	otlpEndpoint  string // Trace exporter endpoint, set once tracing is running
 }
 
 type Metrics struct {
 	HTTPRequestsTotal   *prometheus.CounterVec
 	HTTPRequestDuration *prometheus.HistogramVec
 	HTTPRequestsInFlight prometheus.Gauge
+	HTTPRequestsShed    prometheus.Counter
 
 	LoginAttempts        *prometheus.CounterVec
 	LoginSuccesses       prometheus.Counter
@@ -38,10 +44,16 @@ type Metrics struct {
 	RegistrationAttempts prometheus.Counter
 	TokenGenerations     prometheus.Counter
 	TokenValidations     *prometheus.CounterVec
+	PasswordResetSuppressed *prometheus.CounterVec
+	AuthHeaderErrors     *prometheus.CounterVec
+	TokenBindingFailures prometheus.Counter
 
 	DBQueriesTotal    *prometheus.CounterVec
 	DBQueryDuration   *prometheus.HistogramVec
 	DBConnectionsOpen prometheus.Gauge
+	DBPoolUtilization prometheus.Gauge
+	DBRetriesTotal    *prometheus.CounterVec
+	DBTransactionsTotal *prometheus.CounterVec
 
 	UsersTotal        prometheus.Gauge
 	UsersActive       prometheus.Gauge
@@ -54,14 +66,30 @@ type Config struct {
 	Environment    string
 	LogLevel       slog.Level
 	LogFormat      string // "json" or "text"
+	LogMaxAge      time.Duration // Delete daily log files older than this; zero keeps them
 	OTLPEndpoint   string // e.g., "localhost:4318" for Jaeger
 	EnableMetrics  bool
 	EnableTracing  bool
 	EnableLogging  bool
+	TracingRequired bool // Fail startup if tracing cannot be initialized instead of running without it
+	PushGatewayURL string // Pushgateway for short-lived commands; empty disables PushMetrics
+	PushJob        string // Job label for pushed metrics; defaults to ServiceName
+	RequestLogFields  string // Comma-separated optional request fields to log; empty uses DefaultRequestLogFields
+	RequestLogHashKey string // HMAC key for request fields logged with a ":hash" suffix
 }
 
 func NewMonitor(cfg Config) (*Monitor, error) {
-	m := &Monitor{}
+	m := &Monitor{push: newPushConfig(cfg)}
+
+	fieldList := cfg.RequestLogFields
+	if fieldList == "" {
+		fieldList = DefaultRequestLogFields
+	}
+	fields, err := parseRequestFields(fieldList, cfg.RequestLogHashKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request log fields: %w", err)
+	}
+	m.fields = fields
 
 	if cfg.EnableLogging {
 		if err := m.initLogger(cfg); err != nil {
@@ -75,7 +103,20 @@ func NewMonitor(cfg Config) (*Monitor, error) {
 
 	if cfg.EnableTracing {
 		if err := m.initTracing(cfg); err != nil {
-			return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+			if cfg.TracingRequired {
+				return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+			}
+			// Tracing is optional: keep serving with metrics and logs only
+			m.Tracer = nil
+			m.TracerProvider = nil
+			logger := m.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			logger.Warn("Tracing unavailable, continuing without it",
+				slog.String("endpoint", cfg.OTLPEndpoint),
+				slog.String("error", err.Error()),
+			)
 		}
 	}
 
@@ -83,17 +124,9 @@ func NewMonitor(cfg Config) (*Monitor, error) {
 }
 
 func (m *Monitor) initLogger(cfg Config) error {
-	if err := os.MkdirAll("logs", 0755); err != nil {
-		return fmt.Errorf("failed to create logs directory: %w", err)
-	}
-
-	logFile, err := os.OpenFile(
-		fmt.Sprintf("logs/app-%s.log", time.Now().Format("2006-01-02")),
-		os.O_CREATE|os.O_WRONLY|os.O_APPEND,
-		0666,
-	)
+	logFile, err := openDailyLogFile("logs", cfg.LogMaxAge)
 	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
+		return err
 	}
 	m.logFile = logFile
 
@@ -122,120 +155,188 @@ func (m *Monitor) initLogger(cfg Config) error {
 	return nil
 }
 
+// register adds c to the default registry. If an identical collector is
+// already registered, as when a second Monitor is created in the same
+// process, the existing one is returned so both monitors share it.
+func register[T prometheus.Collector](c T) T {
+	if err := prometheus.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
 func (m *Monitor) initMetrics() {
 	m.Metrics = &Metrics{
-		HTTPRequestsTotal: promauto.NewCounterVec(
+		HTTPRequestsTotal: register(prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "http_requests_total",
 				Help: "Total number of HTTP requests by method, endpoint, and status",
 			},
 			[]string{"method", "endpoint", "status"},
-		),
-		HTTPRequestDuration: promauto.NewHistogramVec(
+		)),
+		HTTPRequestDuration: register(prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "http_request_duration_seconds",
 				Help:    "HTTP request duration in seconds",
 				Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
 			},
 			[]string{"method", "endpoint"},
-		),
-		HTTPRequestsInFlight: promauto.NewGauge(
+		)),
+		HTTPRequestsInFlight: register(prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "http_requests_in_flight",
 				Help: "Current number of HTTP requests being processed",
 			},
-		),
+		)),
+		HTTPRequestsShed: register(prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "http_requests_shed_total",
+				Help: "Total number of HTTP requests rejected because the concurrency limit was reached",
+			},
+		)),
 
-		LoginAttempts: promauto.NewCounterVec(
+		LoginAttempts: register(prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "auth_login_attempts_total",
 				Help: "Total number of login attempts by result",
 			},
 			[]string{"result"}, // "success" or "failure"
-		),
-		LoginSuccesses: promauto.NewCounter(
+		)),
+		LoginSuccesses: register(prometheus.NewCounter(
 			prometheus.CounterOpts{
 				Name: "auth_login_successes_total",
 				Help: "Total number of successful logins",
 			},
-		),
-		LoginFailures: promauto.NewCounterVec(
+		)),
+		LoginFailures: register(prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "auth_login_failures_total",
 				Help: "Total number of failed logins by reason",
 			},
 			[]string{"reason"}, // "invalid_credentials", "user_not_found", etc.
-		),
-		RegistrationAttempts: promauto.NewCounter(
+		)),
+		RegistrationAttempts: register(prometheus.NewCounter(
 			prometheus.CounterOpts{
 				Name: "auth_registration_attempts_total",
 				Help: "Total number of user registration attempts",
 			},
-		),
-		TokenGenerations: promauto.NewCounter(
+		)),
+		TokenGenerations: register(prometheus.NewCounter(
 			prometheus.CounterOpts{
 				Name: "auth_token_generations_total",
 				Help: "Total number of JWT tokens generated",
 			},
-		),
-		TokenValidations: promauto.NewCounterVec(
+		)),
+		TokenValidations: register(prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "auth_token_validations_total",
 				Help: "Total number of token validations by result",
 			},
-			[]string{"result"}, // "valid", "invalid", "expired"
-		),
+			[]string{"result"}, // "valid", "expired", "malformed", "bad_signature", "wrong_issuer", "revoked", "invalid"
+		)),
+		PasswordResetSuppressed: register(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "auth_password_reset_suppressed_total",
+				Help: "Total number of password reset emails suppressed by throttling",
+			},
+			[]string{"reason"}, // "email" or "ip"
+		)),
+		AuthHeaderErrors: register(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "auth_header_errors_total",
+				Help: "Total number of rejected Authorization headers by cause",
+			},
+			[]string{"cause"}, // "missing", "too_large", "wrong_scheme", "empty_token", "parse_failure", "wrong_issuer"
+		)),
+		TokenBindingFailures: register(prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "auth_token_binding_failures_total",
+				Help: "Total number of bound tokens rejected because the client fingerprint did not match",
+			},
+		)),
 
-		DBQueriesTotal: promauto.NewCounterVec(
+		DBQueriesTotal: register(prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "db_queries_total",
 				Help: "Total number of database queries by operation and status",
 			},
 			[]string{"operation", "status"},
-		),
-		DBQueryDuration: promauto.NewHistogramVec(
+		)),
+		DBQueryDuration: register(prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "db_query_duration_seconds",
 				Help:    "Database query duration in seconds",
 				Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1},
 			},
 			[]string{"operation"},
-		),
-		DBConnectionsOpen: promauto.NewGauge(
+		)),
+		DBConnectionsOpen: register(prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "db_connections_open",
 				Help: "Current number of open database connections",
 			},
-		),
+		)),
+		DBPoolUtilization: register(prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "db_pool_utilization",
+				Help: "Fraction of the maximum open database connections currently in use",
+			},
+		)),
+		DBRetriesTotal: register(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "db_query_retries_total",
+				Help: "Total number of database operations retried after a transient error",
+			},
+			[]string{"operation"},
+		)),
+		DBTransactionsTotal: register(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "db_transactions_total",
+				Help: "Total number of database transactions ended, by outcome (commit or rollback) and status",
+			},
+			[]string{"outcome", "status"},
+		)),
 
-		UsersTotal: promauto.NewGauge(
+		UsersTotal: register(prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "auth_app_users_total",
 				Help: "Total number of registered users",
 			},
-		),
-		UsersActive: promauto.NewGauge(
+		)),
+		UsersActive: register(prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "auth_app_users_active_total",
 				Help: "Number of active users (logged in last 24 hours)",
 			},
-		),
-		ProductsTotal: promauto.NewGauge(
+		)),
+		ProductsTotal: register(prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "auth_app_products_total",
 				Help: "Total number of products in the system",
 			},
-		),
+		)),
 	}
 }
 
+// newTraceExporter creates the OTLP span exporter; tests replace it to
+// simulate a collector that cannot be reached
+var newTraceExporter = func(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	return otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpoint(endpoint),
+		otlptracehttp.WithInsecure(), // Use HTTP (not HTTPS) for local development
+	)
+}
+
 func (m *Monitor) initTracing(cfg Config) error {
 	ctx := context.Background()
 
-	exporter, err := otlptracehttp.New(ctx,
-		otlptracehttp.WithEndpoint(cfg.OTLPEndpoint),
-		otlptracehttp.WithInsecure(), // Use HTTP (not HTTPS) for local development
-	)
+	exporter, err := newTraceExporter(ctx, cfg.OTLPEndpoint)
 	if err != nil {
 		return fmt.Errorf("failed to create trace exporter: %w", err)
 	}
@@ -248,6 +349,7 @@ func (m *Monitor) initTracing(cfg Config) error {
 		),
 	)
 	if err != nil {
+		_ = exporter.Shutdown(ctx)
 		return fmt.Errorf("failed to create resource: %w", err)
 	}
 
@@ -267,6 +369,7 @@ func (m *Monitor) initTracing(cfg Config) error {
 	)
 
 	m.Tracer = m.TracerProvider.Tracer(cfg.ServiceName)
+	m.otlpEndpoint = cfg.OTLPEndpoint
 
 	return nil
 }
@@ -0,0 +1,72 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestNewMonitorTwice(t *testing.T) {
+	first, err := NewMonitor(Config{EnableMetrics: true})
+	if err != nil {
+		t.Fatalf("first NewMonitor() error = %v", err)
+	}
+	second, err := NewMonitor(Config{EnableMetrics: true})
+	if err != nil {
+		t.Fatalf("second NewMonitor() error = %v", err)
+	}
+
+	// Both monitors record into the collectors registered first
+	before := testutil.ToFloat64(second.Metrics.HTTPRequestsShed)
+	first.Metrics.HTTPRequestsShed.Inc()
+	if got := testutil.ToFloat64(second.Metrics.HTTPRequestsShed); got != before+1 {
+		t.Errorf("second monitor's counter = %v, want %v", got, before+1)
+	}
+}
+
+func TestNewMonitorTracingFailure(t *testing.T) {
+	exporterErr := errors.New("collector unreachable")
+	original := newTraceExporter
+	newTraceExporter = func(context.Context, string) (sdktrace.SpanExporter, error) {
+		return nil, exporterErr
+	}
+	t.Cleanup(func() { newTraceExporter = original })
+
+	tests := []struct {
+		name            string
+		tracingRequired bool
+		wantErr         bool
+	}{
+		{name: "optional tracing degrades", tracingRequired: false},
+		{name: "required tracing fails startup", tracingRequired: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor, err := NewMonitor(Config{
+				ServiceName:     "test",
+				EnableMetrics:   true,
+				EnableTracing:   true,
+				TracingRequired: tt.tracingRequired,
+			})
+			if tt.wantErr {
+				if !errors.Is(err, exporterErr) {
+					t.Errorf("NewMonitor() error = %v, want %v", err, exporterErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewMonitor() error = %v", err)
+			}
+			if monitor.Tracer != nil || monitor.TracerProvider != nil {
+				t.Error("tracing is set up although the exporter failed")
+			}
+			if monitor.Metrics == nil {
+				t.Error("metrics are not set up")
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// pushConfig holds the Pushgateway settings of a monitor
+type pushConfig struct {
+	url      string
+	job      string
+	instance string
+}
+
+func newPushConfig(cfg Config) pushConfig {
+	job := cfg.PushJob
+	if job == "" {
+		job = cfg.ServiceName
+	}
+	return pushConfig{url: cfg.PushGatewayURL, job: job, instance: cfg.Environment}
+}
+
+// PushMetrics pushes every registered metric to the configured Pushgateway.
+// Short-lived commands (migrations, imports) call it before exiting, since
+// they are usually gone before Prometheus would scrape them. The long-running
+// server is scraped through /metrics and never needs it. It is a no-op when
+// no Pushgateway is configured.
+func (m *Monitor) PushMetrics(ctx context.Context) error {
+	if m.push.url == "" {
+		return nil
+	}
+
+	pusher := push.New(m.push.url, m.push.job).Gatherer(prometheus.DefaultGatherer)
+	if m.push.instance != "" {
+		pusher = pusher.Grouping("instance", m.push.instance)
+	}
+	if err := pusher.PushContext(ctx); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", m.push.url, err)
+	}
+	return nil
+}
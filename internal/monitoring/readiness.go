@@ -0,0 +1,23 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// CheckTracing reports whether the trace exporter's collector is reachable
+// by opening a TCP connection to it. It returns nil when tracing is not
+// running, since the service then works without it.
+func (m *Monitor) CheckTracing(ctx context.Context) error {
+	if m.TracerProvider == nil || m.otlpEndpoint == "" {
+		return nil
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.otlpEndpoint)
+	if err != nil {
+		return fmt.Errorf("trace collector unreachable: %w", err)
+	}
+	return conn.Close()
+}
@@ -0,0 +1,54 @@
+package monitoring
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request's correlation ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs
+const maxRequestIDLength = 128
+
+// requestIDKey stores the request ID. It is the only context key declared
+// outside the auth package; see auth.ContextKey.
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the correlation ID stored by RequestID
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok
+}
+
+// RequestID gives every request a correlation ID: the incoming
+// X-Request-ID when it is well formed, otherwise a fresh UUID. The ID is
+// stored in the context, echoed in the response header, and added to the
+// request's span and log line by HTTPMiddleware.
+func (m *Monitor) RequestID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		next(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, requestID)))
+	}
+}
+
+// validRequestID accepts short IDs of printable ASCII without spaces, so a
+// client-supplied value cannot inject anything into logs or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
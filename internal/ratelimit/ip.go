@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// IPLimiter throttles requests per client IP with token buckets: each IP
+// may make burst requests at once, refilled at the configured rate.
+// Buckets idle long enough to have refilled completely are swept, so the
+// map does not grow without bound.
+type IPLimiter struct {
+	limit    rate.Limit
+	burst    int
+	clientIP func(*http.Request) string
+
+	mu        sync.Mutex
+	clients   map[string]*ipBucket
+	idleAfter time.Duration
+	lastSweep time.Time
+}
+
+// ipBucket is one client's token bucket and when it was last used
+type ipBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewIPLimiter creates a limiter allowing perMinute requests per client IP
+// with bursts of up to burst requests. clientIP resolves the IP a request
+// is counted against. A perMinute of zero or less disables limiting.
+func NewIPLimiter(perMinute, burst int, clientIP func(*http.Request) string) *IPLimiter {
+	l := &IPLimiter{
+		burst:     max(burst, 1),
+		clientIP:  clientIP,
+		clients:   make(map[string]*ipBucket),
+		lastSweep: time.Now(),
+	}
+	if perMinute > 0 {
+		l.limit = rate.Limit(float64(perMinute) / 60)
+		// An idle bucket is full again after this long, which is the same
+		// as starting a new one
+		l.idleAfter = time.Duration(float64(l.burst) / float64(l.limit) * float64(time.Second))
+	}
+	return l
+}
+
+// Handler wraps next, answering 429 with Retry-After once the client IP
+// has used up its bucket
+func (l *IPLimiter) Handler(next http.HandlerFunc) http.HandlerFunc {
+	if l.limit <= 0 {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if wait := l.reserve(l.clientIP(r)); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// reserve takes a token from ip's bucket, returning how long the client
+// must wait if none is available (in which case nothing is taken)
+func (l *IPLimiter) reserve(ip string) time.Duration {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > l.idleAfter {
+		for key, bucket := range l.clients {
+			if now.Sub(bucket.lastSeen) > l.idleAfter {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	bucket, ok := l.clients[ip]
+	if !ok {
+		bucket = &ipBucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[ip] = bucket
+	}
+	bucket.lastSeen = now
+
+	reservation := bucket.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay
+	}
+	return 0
+}
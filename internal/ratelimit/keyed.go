@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// KeyedLimiter allows at most a fixed number of events per key within each
+// time window. Windows are tracked independently per key and expired keys
+// are swept periodically so the map does not grow without bound.
+type KeyedLimiter struct {
+	limit     int
+	window    time.Duration
+	mu        sync.Mutex
+	windows   map[string]*keyWindow
+	lastSweep time.Time
+}
+
+// keyWindow tracks the events recorded for one key in the current window
+type keyWindow struct {
+	start time.Time
+	count int
+}
+
+// NewKeyedLimiter creates a limiter allowing limit events per key per window.
+// A limit of zero or less disables limiting.
+func NewKeyedLimiter(limit int, window time.Duration) *KeyedLimiter {
+	return &KeyedLimiter{
+		limit:     limit,
+		window:    window,
+		windows:   make(map[string]*keyWindow),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow records an event for key and reports whether it is within the limit
+func (l *KeyedLimiter) Allow(key string) bool {
+	if l.limit <= 0 {
+		return true
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > l.window {
+		l.sweep(now)
+	}
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &keyWindow{start: now}
+		l.windows[key] = w
+	}
+
+	if w.count >= l.limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// sweep removes keys whose window has expired; callers must hold the lock
+func (l *KeyedLimiter) sweep(now time.Time) {
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.window {
+			delete(l.windows, key)
+		}
+	}
+	l.lastSweep = now
+}
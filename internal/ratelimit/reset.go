@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"strings"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
+)
+
+// ResetThrottle limits how many password reset emails are sent per email
+// address and per client IP. Callers should still respond with 200 when a
+// send is suppressed so the throttle does not reveal which emails exist.
+type ResetThrottle struct {
+	perEmail *KeyedLimiter
+	perIP    *KeyedLimiter
+}
+
+// NewResetThrottle creates a password reset throttle from configuration
+func NewResetThrottle(cfg config.PasswordResetConfig) *ResetThrottle {
+	return &ResetThrottle{
+		perEmail: NewKeyedLimiter(cfg.MaxPerEmail, cfg.ThrottleWindow),
+		perIP:    NewKeyedLimiter(cfg.MaxPerIP, cfg.ThrottleWindow),
+	}
+}
+
+// Allow reports whether a reset email may be sent. When it may not, reason
+// names the limit that was hit ("ip" or "email") for metrics.
+func (t *ResetThrottle) Allow(email, ip string) (allowed bool, reason string) {
+	if !t.perIP.Allow(ip) {
+		return false, "ip"
+	}
+	if !t.perEmail.Allow(strings.ToLower(strings.TrimSpace(email))) {
+		return false, "email"
+	}
+	return true, ""
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/handlers"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+)
+
+// handleRoles registers an RBAC route. The roles come from the ROUTE_ROLES
+// policy when it names the route, otherwise from the code-level defaults.
+func (s *Server) handleRoles(pattern string, authHandler *handlers.AuthHandler, handler http.HandlerFunc, defaults ...string) {
+	s.handle(pattern, authHandler.RequireAnyRole(handler, s.routeRoles(pattern, defaults...)...))
+}
+
+// routeRoles records pattern as role-protected and returns its roles: those
+// the ROUTE_ROLES policy names for it, otherwise the code-level defaults
+func (s *Server) routeRoles(pattern string, defaults ...string) []string {
+	roles := defaults
+	if override, ok := s.config.Authz.RouteRoles[pattern]; ok {
+		roles = override
+	}
+	s.rbacRoutes[pattern] = roles
+	return roles
+}
+
+// byMethod dispatches a route to the handler registered for the request
+// method, answering 405 for any other method
+func byMethod(handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[r.Method]
+		if !ok {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// validateRoutePolicy rejects a ROUTE_ROLES policy that names routes which
+// are not role-protected or roles which do not exist in the database
+func (s *Server) validateRoutePolicy() error {
+	policy := s.config.Authz.RouteRoles
+	if len(policy) == 0 {
+		return nil
+	}
+
+	known, err := models.NewRoleRepository(s.db).GetNames()
+	if err != nil {
+		return fmt.Errorf("failed to load roles for route policy: %w", err)
+	}
+
+	patterns := make([]string, 0, len(policy))
+	for pattern := range policy {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		if _, ok := s.rbacRoutes[pattern]; !ok {
+			return fmt.Errorf("ROUTE_ROLES names unknown route %q", pattern)
+		}
+		for _, role := range policy[pattern] {
+			if !slices.Contains(known, role) {
+				return fmt.Errorf("ROUTE_ROLES assigns unknown role %q to %s", role, pattern)
+			}
+		}
+		if s.monitor != nil {
+			s.monitor.Logger.Info("Route role policy override",
+				slog.String("route", pattern),
+				slog.Any("roles", policy[pattern]),
+			)
+		}
+	}
+
+	return nil
+}
+
+// authorizer builds the access resolver selected by AUTHZ_PROVIDER
+func (s *Server) authorizer() auth.Authorizer {
+	if s.config.Authz.Provider == config.AuthzProviderHTTP {
+		return auth.NewHTTPAuthorizer(s.config.Authz.ServiceURL, s.config.Authz.ServiceTimeout, s.config.Authz.CacheTTL)
+	}
+	return auth.NewDBAuthorizer(models.NewUserRepository(s.db))
+}
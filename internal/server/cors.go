@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
+)
+
+// CORS applies a cross-origin resource sharing policy. With credentials
+// enabled the request Origin must be on the allowlist and is echoed back
+// exactly, since browsers refuse a wildcard origin on credentialed requests.
+type CORS struct {
+	origins     []string
+	credentials bool
+	wildcard    bool
+	methods     string
+	headers     string
+	maxAge      string
+}
+
+// NewCORS builds the policy described by cfg
+func NewCORS(cfg config.CORSConfig) *CORS {
+	return &CORS{
+		origins:     cfg.AllowedOrigins,
+		credentials: cfg.AllowCredentials,
+		wildcard:    !cfg.AllowCredentials && slices.Contains(cfg.AllowedOrigins, "*"),
+		methods:     strings.Join(cfg.AllowedMethods, ", "),
+		headers:     strings.Join(cfg.AllowedHeaders, ", "),
+		maxAge:      strconv.Itoa(int(cfg.MaxAge.Seconds())),
+	}
+}
+
+// Handler wraps next with the policy. Preflight requests are answered
+// directly: 200 for allowed origins and 403 for any other.
+func (c *CORS) Handler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := c.wildcard || (origin != "" && slices.Contains(c.origins, origin))
+
+		if !c.wildcard {
+			// The allowed origin is echoed from the request, so shared
+			// caches must key on it
+			w.Header().Add("Vary", "Origin")
+		}
+
+		if allowed {
+			if c.wildcard {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if c.credentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			w.Header().Set("Access-Control-Allow-Methods", c.methods)
+			w.Header().Set("Access-Control-Allow-Headers", c.headers)
+			w.Header().Set("Access-Control-Max-Age", c.maxAge)
+			// Let browser clients read listing totals and request IDs
+			w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-Request-ID")
+		}
+
+		if r.Method == http.MethodOptions {
+			if origin != "" && !allowed {
+				http.Error(w, "Origin not allowed", http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next(w, r)
+	}
+}
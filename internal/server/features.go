@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+)
+
+// featureHeaderPrefix marks headers carrying request-scoped feature context
+const featureHeaderPrefix = "X-Feature-"
+
+// Bounds on the feature context accepted from a single request
+const (
+	maxFeatures        = 8
+	maxFeatureValueLen = 64
+)
+
+// parseFeatureAllowlist normalizes the configured feature names to the
+// canonical header names they are read from
+func parseFeatureAllowlist(names []string) map[string]string {
+	headers := make(map[string]string, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			headers[name] = http.CanonicalHeaderKey(featureHeaderPrefix + name)
+		}
+	}
+	return headers
+}
+
+// featureContext copies allowlisted X-Feature-* headers into the request
+// context. Values that are too long or contain control characters are
+// dropped, and at most maxFeatures are kept. It must run outside the
+// instrumented handler, which reads the features back for spans and logs.
+func (s *Server) featureContext(next http.HandlerFunc) http.HandlerFunc {
+	if len(s.featureHeaders) == 0 {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var features map[string]string
+		for name, header := range s.featureHeaders {
+			if len(features) == maxFeatures {
+				break
+			}
+			value := strings.TrimSpace(r.Header.Get(header))
+			if value == "" || len(value) > maxFeatureValueLen || strings.ContainsFunc(value, isControl) {
+				continue
+			}
+			if features == nil {
+				features = make(map[string]string)
+			}
+			features[name] = value
+		}
+
+		if features != nil {
+			r = r.WithContext(auth.WithFeatures(r.Context(), features))
+		}
+		next(w, r)
+	}
+}
+
+// featureAttributes exposes the request's feature context as feature.<name>
+// span attributes and log fields
+func featureAttributes(ctx context.Context) []attribute.KeyValue {
+	features, ok := auth.GetFeaturesFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	attrs := make([]attribute.KeyValue, 0, len(features))
+	for name, value := range features {
+		attrs = append(attrs, attribute.String("feature."+name, value))
+	}
+	return attrs
+}
+
+// isControl reports whether r is an ASCII control character
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
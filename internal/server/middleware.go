@@ -0,0 +1,149 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+)
+
+// requireHTTPS rejects or redirects plaintext requests when HTTPS enforcement
+// is enabled. It should sit inside s.corsMiddleware so rejections still carry
+// the CORS headers browsers need to surface the error.
+func (s *Server) requireHTTPS(next http.HandlerFunc) http.HandlerFunc {
+	if !s.config.Security.EnforceHTTPS {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.isHTTPS(r) {
+			w.Header().Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+			next(w, r)
+			return
+		}
+
+		// Only redirect safe methods; redirecting a POST would resend the body in plaintext
+		if s.config.Security.RedirectHTTP && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			http.Redirect(w, r, "https://"+r.Host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+			return
+		}
+
+		http.Error(w, "HTTPS required", http.StatusBadRequest)
+	}
+}
+
+// limitConcurrency sheds load with 503 once Server.MaxInFlight requests are
+// being served. Shed requests never reach the instrumented handler, so
+// http_requests_in_flight only counts admitted ones; they are counted in
+// http_requests_shed_total instead. Health, ping and metrics routes are
+// registered without it so probes keep working under load.
+func (s *Server) limitConcurrency(next http.HandlerFunc) http.HandlerFunc {
+	if s.inFlight == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case s.inFlight <- struct{}{}:
+			defer func() { <-s.inFlight }()
+			next(w, r)
+		default:
+			if s.monitor != nil && s.monitor.Metrics != nil {
+				s.monitor.Metrics.HTTPRequestsShed.Inc()
+			}
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Server is busy, please retry", http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// isHTTPS reports whether the request arrived over TLS, either directly or
+// via a trusted TLS-terminating proxy
+func (s *Server) isHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if !s.fromTrustedProxy(r) {
+		return false
+	}
+	return strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+// fromTrustedProxy reports whether the request's immediate peer is a configured proxy
+func (s *Server) fromTrustedProxy(r *http.Request) bool {
+	return s.isTrustedProxy(net.ParseIP(peerHost(r)))
+}
+
+// peerHost returns the host part of the request's immediate peer address
+func peerHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// clientIP returns the IP rate limits count a request against. With
+// RATE_LIMIT_TRUST_FORWARDED_FOR, requests from trusted proxies are counted
+// against the nearest X-Forwarded-For hop that is not itself a trusted
+// proxy; hops further left are client-supplied and cannot be trusted.
+func (s *Server) clientIP(r *http.Request) string {
+	peer := peerHost(r)
+	if !s.config.RateLimit.TrustForwardedFor || !s.fromTrustedProxy(r) {
+		return peer
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		if !s.isTrustedProxy(ip) {
+			return ip.String()
+		}
+	}
+	return peer
+}
+
+// clientContext stores the client IP in the request context so handlers
+// record the same address rate limits count against
+func (s *Server) clientContext(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(auth.WithClientIP(r.Context(), s.clientIP(r))))
+	}
+}
+
+// isTrustedProxy reports whether ip belongs to a configured proxy
+func (s *Server) isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range s.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies converts the configured proxy IPs and CIDRs into networks
+func parseTrustedProxies(proxies []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			networks = append(networks, network)
+			continue
+		}
+		if ip := net.ParseIP(proxy); ip != nil {
+			bits := 8 * len(ip.To4())
+			if bits == 0 {
+				bits = 8 * net.IPv6len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	return networks
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+)
+
+// readinessTimeout bounds each dependency check of a readiness probe
+const readinessTimeout = 3 * time.Second
+
+// readyHandler answers readiness probes by checking the database and, when
+// tracing is running, the trace collector. Unlike /health and /ping it
+// touches dependencies, so it answers 503 listing the failed ones while any
+// is down, and the orchestrator stops routing traffic here until they recover.
+func (s *Server) readyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.ShuttingDown() {
+		writeShuttingDown(w)
+		return
+	}
+
+	checks := map[string]string{}
+	failed := []string{}
+	check := func(name string, err error) {
+		if err != nil {
+			checks[name] = err.Error()
+			failed = append(failed, name)
+			s.monitor.Logger.Warn("Readiness check failed",
+				slog.String("dependency", name),
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+		checks[name] = "ok"
+	}
+
+	check("database", database.HealthCheck(s.db))
+	if s.monitor.TracerProvider != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		check("tracing", s.monitor.CheckTracing(ctx))
+		cancel()
+	}
+
+	status, state := http.StatusOK, "ready"
+	if len(failed) > 0 {
+		status, state = http.StatusServiceUnavailable, "not_ready"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": state,
+		"checks": checks,
+		"failed": failed,
+	}); err != nil {
+		s.monitor.Logger.Error("Failed to write readiness response",
+			slog.String("error", err.Error()),
+		)
+	}
+}
@@ -1,23 +1,42 @@
 package server
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"log/slog"
+	"slices"
+	"sync/atomic"
 
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/handlers"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/monitoring"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/models"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/ratelimit"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/storage"
 	"github.com/amillerrr/jwt-rbac-cors-app/internal/database"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/webhook"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Server struct {
-	config  *config.Config
-	db      database.DB
-	router  *http.ServeMux
-	monitor *monitoring.Monitor
+	config         *config.Config
+	db             database.DB
+	router         *http.ServeMux
+	monitor        *monitoring.Monitor
+	trustedProxies []*net.IPNet
+	rbacRoutes     map[string][]string // Effective roles of each role-protected route
+	inFlight       chan struct{}       // Concurrency limit semaphore; nil when unlimited
+	featureHeaders map[string]string   // Allowlisted feature name to its X-Feature-* header
+	shuttingDown   atomic.Bool         // Set by BeginShutdown; new requests get 503
+	webhooks       *webhook.Registry   // Verifies and dispatches inbound provider callbacks
+	cors           *CORS               // The API's cross-origin policy
+	authHandler    *handlers.AuthHandler // Kept so integrations such as email delivery can be attached after setup
+	httpServer     *http.Server        // Serves the router; see Start and Shutdown
 }
 
 func New(cfg *config.Config, db database.DB) *Server {
@@ -26,18 +45,61 @@ func New(cfg *config.Config, db database.DB) *Server {
 
 func NewWithMonitoring(cfg *config.Config, db database.DB, monitor *monitoring.Monitor) *Server {
 	s := &Server{
-		config:  cfg,
-		db:      db,
-		router:  http.NewServeMux(),
-		monitor: monitor,
+		config:         cfg,
+		db:             db,
+		router:         http.NewServeMux(),
+		monitor:        monitor,
+		trustedProxies: parseTrustedProxies(cfg.Security.TrustedProxies),
+		rbacRoutes:     make(map[string][]string),
+		featureHeaders: parseFeatureAllowlist(cfg.Server.FeatureHeaders),
+		cors:           NewCORS(cfg.CORS),
+	}
+	if cfg.Server.MaxInFlight > 0 {
+		s.inFlight = make(chan struct{}, cfg.Server.MaxInFlight)
+	}
+
+	if monitor != nil && len(s.featureHeaders) > 0 {
+		monitor.SetRequestAttributes(featureAttributes)
+	}
+	if monitor != nil && len(cfg.Server.DebugBodyRoutes) > 0 {
+		monitor.Logger.Warn("Request and response bodies are logged at debug level for some routes",
+			slog.Any("routes", cfg.Server.DebugBodyRoutes),
+		)
 	}
 
 	s.setupRoutes()
+	s.httpServer = &http.Server{
+		Addr:           ":" + cfg.Server.Port,
+		Handler:        s.router,
+		MaxHeaderBytes: cfg.Server.MaxHeaderBytes,
+	}
 
 	return s
 }
 
+// SetVerificationSender enables email verification, delivering tokens
+// through sender; see handlers.VerificationSender
+func (s *Server) SetVerificationSender(sender handlers.VerificationSender) {
+	s.authHandler.SetVerificationSender(sender)
+}
+
+// SetPasswordResetSender enables the forgot-password flow, delivering
+// tokens through sender; see handlers.PasswordResetSender
+func (s *Server) SetPasswordResetSender(sender handlers.PasswordResetSender) {
+	s.authHandler.SetPasswordResetSender(sender)
+}
+
+// Webhooks returns the registry provider integrations register their
+// callback handlers with; they are served at /webhooks/{provider}
+func (s *Server) Webhooks() *webhook.Registry {
+	return s.webhooks
+}
+
 func (s *Server) Start() error {
+	if err := s.validateRoutePolicy(); err != nil {
+		return err
+	}
+
 	if s.monitor != nil {
 		s.monitor.Logger.Info("Server starting",
 			"port", s.config.Server.Port,
@@ -50,34 +112,127 @@ func (s *Server) Start() error {
 	fmt.Println("CORS enabled - frontend can communicate with this backend")
 	fmt.Println("Metrics endpoint: http://localhost:" + s.config.Server.Port + "/metrics")
 	
-	return http.ListenAndServe(":"+s.config.Server.Port, s.router)
+	// Shutdown makes ListenAndServe return ErrServerClosed at once; that is
+	// a clean stop, not a failure
+	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 func (s *Server) setupRoutes() {
-	authHandler := handlers.NewAuthHandler(s.db, s.config.JWT.Secret, s.monitor.Logger, s.monitor.Metrics)
-	productHandler := handlers.NewProductHandler(s.db, s.monitor.Logger)
+	authHandler := handlers.NewAuthHandler(s.db, s.config.JWT, s.monitor.Logger, s.monitor.Metrics)
+	authHandler.SetPasswordMaxAge(s.config.Security.PasswordMaxAge, "/profile", "/profile/logout-all", "/profile/export", "/change-password")
+	authHandler.SetFormLogin(s.config.Frontend.LoginRedirect, s.isHTTPS)
+	authHandler.SetAuthorizer(s.authorizer())
+	authHandler.SetRoleHierarchy(s.config.Authz.RoleHierarchy)
+	authHandler.SetRehashCost(s.config.Security.BcryptCost)
+	authHandler.SetPasswordReset(s.config.PasswordReset)
+	s.authHandler = authHandler
+	productImages := storage.NewLocalStorage(s.config.Products.ImageDir)
+	productHandler := handlers.NewProductHandler(s.db, s.config.Products, productImages, s.monitor.Logger)
 	adminHandler := handlers.NewAdminHandler(s.db, s.monitor.Logger)
+	adminHandler.SetSecureRequestCheck(s.isHTTPS)
+	adminHandler.SetStatsCacheTTL(s.config.Server.StatsCacheTTL)
+
+	s.router.HandleFunc("/", s.corsMiddleware(s.rejectDuringShutdown(s.limitConcurrency(s.requireHTTPS(s.serveStaticFiles)))))
+	s.router.Handle("/css/", s.requireHTTPS(s.serveAssets("/css/", "frontend/css/")))
+	s.router.Handle("/js/", s.requireHTTPS(s.serveAssets("/js/", "frontend/js/")))
+
+	// Metrics and health are polled directly by Prometheus and load balancers over
+	// plain HTTP, so they are deliberately exempt from HTTPS enforcement
+	// OpenMetrics is negotiated so scrapers that ask for it receive trace exemplars
+	// Utility endpoints get their own read-only CORS policy (CORS_UTILITY_ORIGINS)
+	// rather than the API's, and none at all unless origins are configured
+	s.router.Handle("/metrics", s.utilityCORS(promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)))
+
+	s.router.HandleFunc("/health", s.corsMiddleware(s.instrumentHandler("/health", s.healthHandler)))
 
-	s.router.HandleFunc("/", corsMiddleware(s.serveStaticFiles))
-	s.router.Handle("/css/", http.StripPrefix("/css/", http.FileServer(http.Dir("frontend/css/"))))
-	s.router.Handle("/js/", http.StripPrefix("/js/", http.FileServer(http.Dir("frontend/js/"))))
+	// Readiness probe: unlike /health, checks the database and trace collector
+	s.router.HandleFunc("/ready", s.corsMiddleware(s.instrumentHandler("/ready", s.readyHandler)))
 
-	s.router.Handle("/metrics", promhttp.Handler())
+	// Load balancer liveness probe: registered bare, outside CORS, logging and
+	// metrics, so probe traffic never shows up in http_requests_total
+	s.router.HandleFunc("/ping", pingHandler)
+
+	// Brute-force protection: login, register and password reset share one
+	// bucket per client IP
+	authLimiter := ratelimit.NewIPLimiter(s.config.RateLimit.AuthPerMinute, s.config.RateLimit.AuthBurst, s.clientIP)
+	s.handle("/login", authLimiter.Handler(authHandler.Login))
+	s.handle("/register", authLimiter.Handler(authHandler.Register))
+
+	if s.config.OAuth.GoogleEnabled {
+		oauthHandler := handlers.NewOAuthHandler(s.db, s.config.OAuth, authHandler.JWTService(), s.monitor.Logger)
+		s.handle("/auth/google/login", oauthHandler.GoogleLogin)
+		s.handle("/auth/google/callback", oauthHandler.GoogleCallback)
+	}
+
+	s.handle("/refresh", authHandler.RefreshToken)
+	s.handle("/logout", authHandler.RequireAuth(authHandler.Logout))
+	s.handle("/profile", authHandler.RequireAuth(authHandler.GetProfile))
+	s.handle("/profile/logout-all", authHandler.RequireAuth(authHandler.LogoutAll))
+	s.handle("/profile/export", authHandler.RequireAuth(authHandler.ExportProfile))
+	s.handle("/change-password", authHandler.RequireAuth(authHandler.ChangePassword))
+	s.handle("/verify-email/request", authHandler.RequireAuth(authHandler.RequestVerification))
+	s.handle("/verify-email", authHandler.VerifyEmail)
+	s.handle("/password-reset/request", authLimiter.Handler(authHandler.RequestPasswordReset))
+	s.handle("/password-reset", authLimiter.Handler(authHandler.ResetPassword))
+
+	permissionsHandler := handlers.NewPermissionsHandler(s.rbacRoutes, s.monitor.Logger)
+	s.handle("/me/permissions", authHandler.RequireAuth(permissionsHandler.GetPermissions))
+
+	s.handle("/products", authHandler.RequireSameOrg(productHandler.HandleProducts))
+	s.handle("/products/", authHandler.RequireSameOrg(productHandler.HandleProductPath))
+	s.handle("/products/{id}/image", authHandler.RequireSameOrg(productHandler.HandleProductImage))
+	s.handle("/categories", authHandler.RequireAuth(productHandler.GetCategories))
+	s.handle("/my-products", authHandler.RequireSameOrg(productHandler.GetMyProducts))
+
+	// The bootstrap endpoint only exists while a bootstrap token is configured
+	if s.config.Security.BootstrapToken != "" {
+		s.monitor.Logger.Warn("Admin bootstrap endpoint enabled; it disables itself once an admin exists",
+			slog.String("endpoint", "/admin/bootstrap"),
+		)
+		bootstrapHandler := handlers.NewBootstrapHandler(s.db, s.config.Security.BootstrapToken, s.monitor.Logger)
+		s.handle("/admin/bootstrap", bootstrapHandler.Bootstrap)
+	}
 
-	s.router.HandleFunc("/health", corsMiddleware(s.instrumentHandler("/health", s.healthHandler)))
+	// Role requirements below are defaults; ROUTE_ROLES can override them per route
+	s.handleRoles("/admin", authHandler, adminHandler.GetAdminData, "admin")
+	s.handleRoles("/admin/stats", authHandler, adminHandler.GetSystemStats, "admin")
+	// Listing users only needs the users:read permission; deleting one keeps
+	// the route's role requirement
+	s.handle("/admin/users", byMethod(map[string]http.HandlerFunc{
+		http.MethodGet:    authHandler.RequirePermission("users:read", adminHandler.GetAllUsers),
+		http.MethodDelete: authHandler.RequireAnyRole(adminHandler.DeleteUser, s.routeRoles("/admin/users", "admin")...),
+	}))
+	s.handleRoles("/admin/users/import", authHandler, adminHandler.ImportUsers, "admin")
+	s.handleRoles("/admin/users/roles", authHandler, adminHandler.HandleUserRoles, "admin")
+	s.handleRoles("/admin/users/status", authHandler, adminHandler.SetUserActive, "admin")
+	s.handleRoles("/admin/users/{id}/reset-password", authHandler, adminHandler.ResetUserPassword, "admin")
+	s.handleRoles("/admin/audit", authHandler, adminHandler.GetAuditLogs, "admin")
+	s.handleRoles("/admin/token/introspect", authHandler, authHandler.IntrospectToken, "admin")
 
-	s.router.HandleFunc("/login", corsMiddleware(s.instrumentHandler("/login", authHandler.Login)))
-	s.router.HandleFunc("/register", corsMiddleware(s.instrumentHandler("/register", authHandler.Register)))
+	eventHandler := handlers.NewEventHandler(s.db, s.monitor.Logger)
+	s.handleRoles("/admin/events/since", authHandler, eventHandler.GetEventsSince, "admin")
 
-	s.router.HandleFunc("/refresh", corsMiddleware(s.instrumentHandler("/refresh", authHandler.RefreshToken)))
-	s.router.HandleFunc("/profile", corsMiddleware(s.instrumentHandler("/profile", authHandler.RequireAuth(authHandler.GetProfile))))
+	apiTokenHandler := handlers.NewAPITokenHandler(s.db, s.monitor.Logger)
+	s.handleRoles("/admin/api-tokens", authHandler, apiTokenHandler.HandleAPITokens, "admin")
 
-	s.router.HandleFunc("/products", corsMiddleware(s.instrumentHandler("/products", authHandler.RequireAuth(productHandler.GetProducts))))
-	s.router.HandleFunc("/my-products", corsMiddleware(s.instrumentHandler("/my-products", authHandler.RequireAuth(productHandler.GetMyProducts))))
+	// Service accounts authenticate with scoped X-API-Key tokens, never user JWTs
+	apiKeys := auth.NewAPIKeyMiddleware(models.NewAPITokenRepository(s.db))
+	s.handle("/service/products", apiKeys.RequireScope(models.ScopeProductsRead)(productHandler.GetProducts))
 
-	s.router.HandleFunc("/admin", corsMiddleware(s.instrumentHandler("/admin", authHandler.RequireRole("admin", adminHandler.GetAdminData))))
-	s.router.HandleFunc("/admin/stats", corsMiddleware(s.instrumentHandler("/admin/stats", authHandler.RequireRole("admin", adminHandler.GetSystemStats))))
-	s.router.HandleFunc("/admin/users", corsMiddleware(s.instrumentHandler("/admin/users", authHandler.RequireRole("admin", adminHandler.GetAllUsers))))
+	// Provider callbacks authenticate with an HMAC signature instead of a user token
+	s.webhooks = webhook.NewRegistry(s.config.Webhooks.Secrets, s.config.Webhooks.ReplayWindow, s.monitor.Logger)
+	s.handle("/webhooks/{provider}", s.webhooks.ServeHTTP)
+}
+
+// handle registers an API route wrapped in the standard middleware chain
+func (s *Server) handle(pattern string, handler http.HandlerFunc) {
+	s.router.HandleFunc(pattern, s.corsMiddleware(s.rejectDuringShutdown(s.limitConcurrency(s.requireHTTPS(s.clientContext(s.featureContext(s.instrumentHandler(pattern, handler))))))))
 }
 
 func (s *Server) instrumentHandler(endpoint string, handler http.HandlerFunc) http.HandlerFunc {
@@ -85,7 +240,12 @@ func (s *Server) instrumentHandler(endpoint string, handler http.HandlerFunc) ht
 		return handler
 	}
 
-	return s.monitor.HTTPMiddleware(handler)
+	// Routes listed in DEBUG_BODY_ROUTES also log their redacted bodies
+	if slices.Contains(s.config.Server.DebugBodyRoutes, endpoint) {
+		handler = s.monitor.DebugBodies(endpoint, handler)
+	}
+
+	return s.monitor.RequestID(s.monitor.HTTPMiddleware(handler))
 }
 
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
@@ -94,6 +254,12 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Fail health checks while draining so the load balancer routes elsewhere
+	if s.ShuttingDown() {
+		writeShuttingDown(w)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write([]byte(`{"status": "healthy", "message": "Backend server is running"}`)); err != nil {
@@ -106,6 +272,19 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// pingHandler answers liveness probes without touching the database
+func pingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("pong"))
+}
+
 func (s *Server) serveStaticFiles(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -113,7 +292,7 @@ func (s *Server) serveStaticFiles(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.URL.Path == "/" {
-		http.ServeFile(w, r, "frontend/index.html")
+		s.serveHTML(w, r, "frontend/index.html")
 		return
 	}
 
@@ -121,25 +300,61 @@ func (s *Server) serveStaticFiles(w http.ResponseWriter, r *http.Request) {
 	
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		// File doesn't exist, serve the main HTML file (for SPA routing)
-		http.ServeFile(w, r, "frontend/index.html")
+		s.serveHTML(w, r, "frontend/index.html")
+		return
+	}
+
+	if isHTML(filePath) {
+		s.serveHTML(w, r, filePath)
 		return
 	}
 
 	http.ServeFile(w, r, filePath)
 }
 
-func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Max-Age", "3600")
+// corsMiddleware applies the API's CORS policy; see CORS
+func (s *Server) corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return s.cors.Handler(next)
+}
+
+// utilityCORS applies the CORS policy for utility endpoints such as /metrics.
+// They are read-only and never take credentials, so only GET is allowed and
+// the allowlist is separate from the API's. With no origins configured the
+// handler is returned unchanged and browsers on other origins are refused.
+func (s *Server) utilityCORS(next http.Handler) http.Handler {
+	origins := s.config.CORS.UtilityOrigins
+	if len(origins) == 0 {
+		return next
+	}
+	wildcard := slices.Contains(origins, "*")
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := wildcard || (origin != "" && slices.Contains(origins, origin))
+
+		if !wildcard {
+			w.Header().Add("Vary", "Origin")
+		}
+
+		if allowed {
+			if wildcard {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Max-Age", "3600")
+		}
+
+		if r.Method == http.MethodOptions {
+			if origin != "" && !allowed {
+				http.Error(w, "Origin not allowed", http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 
-		next(w, r)
-	}
+		next.ServeHTTP(w, r)
+	})
 }
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/auth"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/config"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/database/dbtest"
+	"github.com/amillerrr/jwt-rbac-cors-app/internal/monitoring"
+)
+
+// newTestServer builds a server from the default configuration, changed by
+// configure, over a mocked database
+func newTestServer(t *testing.T, configure func(*config.Config)) *Server {
+	t.Helper()
+
+	t.Setenv("DB_PASSWORD", "unused")
+	t.Setenv("JWT_SECRET", "server-test-secret-at-least-32-bytes")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+
+	monitor, err := monitoring.NewMonitor(monitoring.Config{EnableMetrics: true})
+	if err != nil {
+		t.Fatalf("NewMonitor() error = %v", err)
+	}
+	monitor.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	db, _ := dbtest.New(t)
+	return NewWithMonitoring(cfg, db, monitor)
+}
+
+func TestCORS(t *testing.T) {
+	const allowedOrigin = "https://app.example.com"
+
+	credentialed := func(cfg *config.Config) {
+		cfg.CORS = config.CORSConfig{
+			AllowedOrigins:   []string{allowedOrigin},
+			AllowCredentials: true,
+			AllowedMethods:   []string{"GET", "POST"},
+			AllowedHeaders:   []string{"Authorization", "Content-Type"},
+			MaxAge:           time.Hour,
+		}
+	}
+	wildcard := func(cfg *config.Config) {
+		cfg.CORS = config.CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"GET"},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		configure       func(*config.Config)
+		method          string
+		origin          string
+		wantStatus      int
+		wantOrigin      string
+		wantCredentials string
+		wantMethods     string
+	}{
+		{name: "allowed origin", configure: credentialed, method: http.MethodGet, origin: allowedOrigin,
+			wantStatus: http.StatusOK, wantOrigin: allowedOrigin, wantCredentials: "true", wantMethods: "GET, POST"},
+		{name: "disallowed origin", configure: credentialed, method: http.MethodGet, origin: "https://evil.example.com",
+			wantStatus: http.StatusOK},
+		{name: "missing origin", configure: credentialed, method: http.MethodGet,
+			wantStatus: http.StatusOK},
+		{name: "preflight from allowed origin", configure: credentialed, method: http.MethodOptions, origin: allowedOrigin,
+			wantStatus: http.StatusOK, wantOrigin: allowedOrigin, wantCredentials: "true", wantMethods: "GET, POST"},
+		{name: "preflight from disallowed origin", configure: credentialed, method: http.MethodOptions, origin: "https://evil.example.com",
+			wantStatus: http.StatusForbidden},
+		{name: "wildcard without credentials", configure: wildcard, method: http.MethodGet, origin: "https://any.example.com",
+			wantStatus: http.StatusOK, wantOrigin: "*", wantMethods: "GET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, tt.configure)
+
+			req := httptest.NewRequest(tt.method, "/health", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rec := httptest.NewRecorder()
+			s.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			headers := map[string]string{
+				"Access-Control-Allow-Origin":      tt.wantOrigin,
+				"Access-Control-Allow-Credentials": tt.wantCredentials,
+				"Access-Control-Allow-Methods":     tt.wantMethods,
+			}
+			for header, want := range headers {
+				if got := rec.Header().Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	behindProxy := func(cfg *config.Config) {
+		cfg.Security.TrustedProxies = []string{"10.0.0.0/8"}
+		cfg.RateLimit.TrustForwardedFor = true
+	}
+
+	tests := []struct {
+		name         string
+		configure    func(*config.Config)
+		remoteAddr   string
+		forwardedFor string
+		wantClientIP string
+	}{
+		{name: "direct peer", configure: behindProxy, remoteAddr: "203.0.113.7:1234", wantClientIP: "203.0.113.7"},
+		{name: "forwarded by trusted proxy", configure: behindProxy, remoteAddr: "10.0.0.2:1234",
+			forwardedFor: "198.51.100.1, 203.0.113.9", wantClientIP: "203.0.113.9"},
+		{name: "forwarded by untrusted peer", configure: behindProxy, remoteAddr: "203.0.113.7:1234",
+			forwardedFor: "198.51.100.1", wantClientIP: "203.0.113.7"},
+		{name: "forwarded header not trusted", remoteAddr: "10.0.0.2:1234",
+			forwardedFor: "198.51.100.1", wantClientIP: "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, tt.configure)
+
+			var got string
+			handler := s.clientContext(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = auth.GetClientIPFromContext(r.Context())
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			handler(httptest.NewRecorder(), req)
+
+			if got != tt.wantClientIP {
+				t.Errorf("client IP = %q, want %q", got, tt.wantClientIP)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"net/http"
+)
+
+// BeginShutdown makes the server refuse new requests with 503 while those
+// already in flight finish. It is safe to call from a signal handler.
+func (s *Server) BeginShutdown() {
+	s.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether BeginShutdown has been called
+func (s *Server) ShuttingDown() bool {
+	return s.shuttingDown.Load()
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to
+// finish, or for ctx to end. It calls BeginShutdown first, so requests that
+// slip in on open keep-alive connections still get 503.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.BeginShutdown()
+	return s.httpServer.Shutdown(ctx)
+}
+
+// rejectDuringShutdown answers 503 once shutdown has begun. Connection: close
+// makes keep-alive clients reconnect, landing on another instance.
+func (s *Server) rejectDuringShutdown(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.ShuttingDown() {
+			writeShuttingDown(w)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// writeShuttingDown responds 503 to a request arriving during shutdown
+func writeShuttingDown(w http.ResponseWriter) {
+	w.Header().Set("Connection", "close")
+	w.Header().Set("Retry-After", "5")
+	http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+}
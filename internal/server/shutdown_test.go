@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBeginShutdownRejectsNewRequests(t *testing.T) {
+	tests := []struct {
+		path        string
+		wantBefore  int
+		wantRejects bool
+	}{
+		{path: "/profile", wantBefore: http.StatusUnauthorized, wantRejects: true},
+		{path: "/health", wantBefore: http.StatusOK, wantRejects: true},
+		// Liveness must keep passing so the instance is not killed mid-drain
+		{path: "/ping", wantBefore: http.StatusOK, wantRejects: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			s := newTestServer(t, nil)
+			serve := func() *httptest.ResponseRecorder {
+				rec := httptest.NewRecorder()
+				s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+				return rec
+			}
+
+			if rec := serve(); rec.Code != tt.wantBefore {
+				t.Fatalf("status before shutdown = %d, want %d", rec.Code, tt.wantBefore)
+			}
+
+			s.BeginShutdown()
+			if !s.ShuttingDown() {
+				t.Fatal("ShuttingDown() = false after BeginShutdown")
+			}
+
+			rec := serve()
+			if !tt.wantRejects {
+				if rec.Code != tt.wantBefore {
+					t.Errorf("status during shutdown = %d, want %d", rec.Code, tt.wantBefore)
+				}
+				return
+			}
+			if rec.Code != http.StatusServiceUnavailable {
+				t.Errorf("status during shutdown = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+			}
+			if got := rec.Header().Get("Connection"); got != "close" {
+				t.Errorf("Connection = %q, want %q", got, "close")
+			}
+			if got := rec.Header().Get("Retry-After"); got == "" {
+				t.Error("Retry-After not set")
+			}
+		})
+	}
+}
+
+func TestBeginShutdownLetsInFlightRequestsFinish(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := s.rejectDuringShutdown(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+
+	<-started
+	s.BeginShutdown()
+	close(release)
+	<-done
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("in-flight status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
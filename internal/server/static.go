@@ -0,0 +1,86 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fingerprintPattern matches asset names carrying a content hash, such as
+// app.3f9a2c1b.js or styles-3f9a2c1b.css. Their content never changes, so
+// they can be cached for as long as FingerprintMaxAge allows.
+var fingerprintPattern = regexp.MustCompile(`[.-][0-9a-fA-F]{8,}\.[A-Za-z0-9]+$`)
+
+// assetETag caches the content-hash ETag of a file until it changes on disk
+type assetETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// assetETags holds computed ETags by file path
+var assetETags sync.Map
+
+// serveAssets serves the files under dir at prefix with the configured
+// Cache-Control and, optionally, a content-hash ETag. http.FileServer honours
+// If-None-Match against that ETag, answering 304 when the file is unchanged.
+func (s *Server) serveAssets(prefix, dir string) http.HandlerFunc {
+	cfg := s.config.Frontend
+	files := http.StripPrefix(prefix, http.FileServer(http.Dir(dir)))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		cacheControl := "public, max-age=" + strconv.Itoa(int(cfg.AssetMaxAge.Seconds()))
+		if fingerprintPattern.MatchString(r.URL.Path) {
+			cacheControl = "public, max-age=" + strconv.Itoa(int(cfg.FingerprintMaxAge.Seconds())) + ", immutable"
+		}
+		w.Header().Set("Cache-Control", cacheControl)
+
+		if cfg.AssetETags {
+			name := path.Clean("/" + r.URL.Path[len(prefix)-1:])
+			if etag, ok := fileETag(filepath.Join(dir, filepath.FromSlash(name))); ok {
+				w.Header().Set("ETag", etag)
+			}
+		}
+
+		files.ServeHTTP(w, r)
+	}
+}
+
+// fileETag returns a strong ETag derived from the file's content, reusing
+// the cached value while the file's size and modification time are unchanged
+func fileETag(name string) (string, bool) {
+	info, err := os.Stat(name)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+
+	if cached, ok := assetETags.Load(name); ok {
+		entry := cached.(assetETag)
+		if entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+			return entry.etag, true
+		}
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", false
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+
+	assetETags.Store(name, assetETag{modTime: info.ModTime(), size: info.Size(), etag: etag})
+	return etag, true
+}
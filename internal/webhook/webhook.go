@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers carrying a callback's signature and the time it was signed.
+// The signature is "v1=" followed by the hex HMAC-SHA256, keyed with the
+// provider's secret, of the timestamp, a ".", and the raw request body.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	signaturePrefix = "v1="
+)
+
+// DefaultReplayWindow is how far a callback's timestamp may be from now
+// when the registry is given no window
+const DefaultReplayWindow = 5 * time.Minute
+
+// DefaultMaxBodyBytes bounds the callback body read for verification
+const DefaultMaxBodyBytes = 1 << 20
+
+var (
+	// ErrInvalidSignature is returned when the signature is missing or wrong
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrStaleTimestamp is returned when the timestamp is missing, malformed,
+	// or outside the replay window
+	ErrStaleTimestamp = errors.New("webhook timestamp outside replay window")
+)
+
+// Handler processes a verified callback body. Returning an error answers
+// 500 so the provider retries.
+type Handler func(ctx context.Context, body []byte) error
+
+type provider struct {
+	secret  []byte
+	handler Handler
+}
+
+// Registry verifies inbound provider callbacks and dispatches them to the
+// handler registered for the provider named in the path.
+type Registry struct {
+	mu           sync.RWMutex
+	secrets      map[string]string
+	providers    map[string]provider
+	replayWindow time.Duration
+	maxBodyBytes int64
+	logger       *slog.Logger
+	now          func() time.Time
+}
+
+// NewRegistry creates a registry that accepts callbacks signed with the
+// per-provider secrets and timestamped within replayWindow of now
+func NewRegistry(secrets map[string]string, replayWindow time.Duration, logger *slog.Logger) *Registry {
+	if replayWindow <= 0 {
+		replayWindow = DefaultReplayWindow
+	}
+	return &Registry{
+		secrets:      secrets,
+		providers:    make(map[string]provider),
+		replayWindow: replayWindow,
+		maxBodyBytes: DefaultMaxBodyBytes,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// Register installs the handler for a provider. It fails if the provider
+// has no configured signing secret, so an integration can never be mounted
+// unauthenticated, or if it is already registered.
+func (reg *Registry) Register(name string, handler Handler) error {
+	secret := reg.secrets[name]
+	if secret == "" {
+		return fmt.Errorf("no signing secret configured for webhook provider %q", name)
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, dup := reg.providers[name]; dup {
+		return fmt.Errorf("webhook provider %q is already registered", name)
+	}
+	reg.providers[name] = provider{secret: []byte(secret), handler: handler}
+	return nil
+}
+
+// Verify checks a callback's timestamp and signature against secret
+func (reg *Registry) Verify(secret []byte, timestamp, signature string, body []byte) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStaleTimestamp
+	}
+	if age := reg.now().Sub(time.Unix(unix, 0)); age > reg.replayWindow || age < -reg.replayWindow {
+		return ErrStaleTimestamp
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil || !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal(got, Sign(secret, timestamp, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Sign computes the raw signature of a callback, for providers and tests
+func Sign(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// ServeHTTP handles POST /webhooks/{provider}. Unknown providers get 404,
+// unverifiable callbacks 401, and handler failures 500.
+func (reg *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.PathValue("provider")
+	reg.mu.RLock()
+	p, ok := reg.providers[name]
+	reg.mu.RUnlock()
+	if !ok {
+		http.Error(w, "Unknown webhook provider", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, reg.maxBodyBytes))
+	if err != nil {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := reg.Verify(p.secret, r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body); err != nil {
+		reg.logger.Warn("Rejected webhook callback",
+			slog.String("provider", name),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Invalid webhook signature", http.StatusUnauthorized)
+		return
+	}
+
+	if err := p.handler(r.Context(), body); err != nil {
+		reg.logger.Error("Webhook handler failed",
+			slog.String("provider", name),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, "Failed to process webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,162 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hasher hashes and verifies passwords with one algorithm. Hashes carry a
+// scheme prefix ("$2a$"/"$2b$" for bcrypt, "$argon2id$" for Argon2id), so a
+// stored hash can always be verified by the algorithm that produced it.
+type Hasher interface {
+	// Hash returns the encoded hash of password, including its scheme prefix
+	Hash(password []byte) (string, error)
+	// Compare reports whether password matches the encoded hash
+	Compare(hash string, password []byte) bool
+}
+
+// Names of the supported hashing algorithms, as used in configuration
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// argon2idPrefix starts every encoded Argon2id hash
+const argon2idPrefix = "$argon2id$"
+
+// BcryptHasher hashes passwords with bcrypt at Cost
+type BcryptHasher struct {
+	Cost int
+}
+
+// Hash implements Hasher
+func (h BcryptHasher) Hash(password []byte) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(password, h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Compare implements Hasher
+func (h BcryptHasher) Compare(hash string, password []byte) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), password) == nil
+}
+
+// Argon2idHasher hashes passwords with Argon2id using the given parameters
+type Argon2idHasher struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2id follows the OWASP baseline recommendation for Argon2id
+var DefaultArgon2id = Argon2idHasher{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Hash implements Hasher, producing the standard encoding
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+func (h Argon2idHasher) Hash(password []byte) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey(password, salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Compare implements Hasher. The parameters are read from the hash, so
+// hashes made with other parameters still verify.
+func (h Argon2idHasher) Compare(hash string, password []byte) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+	candidate := argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(candidate, key) == 1
+}
+
+// decodeArgon2id parses an encoded Argon2id hash
+func decodeArgon2id(hash string) (Argon2idHasher, []byte, []byte, error) {
+	var params Argon2idHasher
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("invalid argon2id key")
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// defaultHasher hashes new passwords; see SetDefaultHasher
+var defaultHasher Hasher = BcryptHasher{Cost: DefaultCost}
+
+// NewHasher returns the hasher for a configured algorithm name. bcryptCost
+// applies to bcrypt only and must be within bcrypt's accepted range.
+func NewHasher(algorithm string, bcryptCost int) (Hasher, error) {
+	switch strings.ToLower(algorithm) {
+	case AlgorithmBcrypt:
+		if err := ValidateBcryptCost(bcryptCost); err != nil {
+			return nil, err
+		}
+		return BcryptHasher{Cost: bcryptCost}, nil
+	case AlgorithmArgon2id:
+		return DefaultArgon2id, nil
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", algorithm)
+	}
+}
+
+// SetDefaultHasher selects the algorithm HashPassword uses for new hashes.
+// Existing hashes keep verifying with the algorithm named by their prefix.
+func SetDefaultHasher(h Hasher) {
+	defaultHasher = h
+}
+
+// hasherFor picks the hasher able to verify an encoded hash from its scheme prefix
+func hasherFor(hash string) (Hasher, bool) {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return DefaultArgon2id, true
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return BcryptHasher{}, true
+	default:
+		return nil, false
+	}
+}
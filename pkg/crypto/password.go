@@ -1,7 +1,13 @@
 package crypto
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math/big"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -9,28 +15,118 @@ import (
 // DefaultCost is the default bcrypt cost to use for password hashing
 const DefaultCost = bcrypt.DefaultCost
 
-// HashPassword creates a bcrypt hash of the given password
+// pepper is a server-side secret mixed into every password before bcrypt,
+// so a database leak alone is not enough to crack the hashes
+var pepper []byte
+
+// SetPepper enables peppering with the given secret; an empty secret disables it.
+//
+// Migration note: hashes created with one pepper only verify with that same
+// pepper. Enabling, changing or removing the pepper invalidates every stored
+// hash, so users must reset their passwords (or be re-hashed on login under the
+// old pepper) before the switch.
+func SetPepper(secret string) {
+	pepper = []byte(secret)
+}
+
+// pepperPassword HMACs the password with the pepper when one is configured.
+// The hex digest stays within bcrypt's 72-byte input limit. The pepper
+// applies the same way whichever Hasher is in use.
+func pepperPassword(password string) []byte {
+	if len(pepper) == 0 {
+		return []byte(password)
+	}
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(password))
+	return []byte(hex.EncodeToString(mac.Sum(nil)))
+}
+
+// HashPassword hashes the given password with the default Hasher (bcrypt
+// unless changed with SetDefaultHasher)
 func HashPassword(password string) (string, error) {
 	if password == "" {
 		return "", fmt.Errorf("password cannot be empty")
 	}
 
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), DefaultCost)
+	hash, err := defaultHasher.Hash(pepperPassword(password))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return hash, nil
+}
+
+// HashPasswordWithCost hashes the given password with bcrypt at cost,
+// whatever the default Hasher. Lower costs keep tests fast; production
+// should use 12 or more.
+func HashPasswordWithCost(password string, cost int) (string, error) {
+	if password == "" {
+		return "", fmt.Errorf("password cannot be empty")
+	}
+	if err := ValidateBcryptCost(cost); err != nil {
+		return "", err
+	}
+
+	hash, err := BcryptHasher{Cost: cost}.Hash(pepperPassword(password))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return hash, nil
+}
+
+// HashPasswordArgon2 hashes the given password with Argon2id at the
+// DefaultArgon2id parameters, whatever the default Hasher, producing the
+// standard $argon2id$ encoding. CheckPasswordHash verifies it alongside
+// existing bcrypt hashes.
+func HashPasswordArgon2(password string) (string, error) {
+	if password == "" {
+		return "", fmt.Errorf("password cannot be empty")
+	}
+
+	hash, err := DefaultArgon2id.Hash(pepperPassword(password))
 	if err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	return string(bytes), nil
+	return hash, nil
+}
+
+// ValidateBcryptCost checks cost is within the range bcrypt accepts
+func ValidateBcryptCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, cost)
+	}
+	return nil
+}
+
+// NeedsRehash reports whether a stored hash should be replaced after the
+// next successful password check: bcrypt hashes below targetCost, and bcrypt
+// hashes once the default Hasher has moved to another algorithm. Argon2id
+// and unrecognized hashes are left alone.
+func NeedsRehash(hash string, targetCost int) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false
+	}
+	if _, ok := defaultHasher.(BcryptHasher); !ok {
+		return true
+	}
+	return cost < targetCost
 }
 
-// CheckPasswordHash compares a password with its hash
+// CheckPasswordHash compares a password with its hash, verifying it with
+// the algorithm named by the hash's scheme prefix
 func CheckPasswordHash(password, hash string) bool {
 	if password == "" || hash == "" {
 		return false
 	}
 
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	hasher, ok := hasherFor(hash)
+	if !ok {
+		return false
+	}
+	return hasher.Compare(hash, pepperPassword(password))
 }
 
 // ValidatePasswordStrength checks if a password meets minimum requirements
@@ -49,22 +145,58 @@ func ValidatePasswordStrength(password string) error {
 	return nil
 }
 
-// GenerateRandomPassword creates a random password (useful for temporary passwords)
-// This is a simple implementation - in production you'd want a more sophisticated approach
+// Character classes of generated passwords; every password gets at least
+// one of each, so it always passes the validator's complexity rules
+const (
+	upperChars   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	lowerChars   = "abcdefghijklmnopqrstuvwxyz"
+	digitChars   = "0123456789"
+	specialChars = "!@#$%^&*"
+)
+
+// GenerateRandomPassword creates a random password (useful for temporary
+// passwords). Characters are chosen uniformly with crypto/rand, and the
+// result contains at least one uppercase letter, lowercase letter, digit
+// and special character.
 func GenerateRandomPassword(length int) (string, error) {
 	if length < 8 {
 		return "", fmt.Errorf("password length must be at least 8 characters")
 	}
 
-	// Simple random password generation
-	// In production, use crypto/rand for better randomness
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*"
-	
+	classes := []string{upperChars, lowerChars, digitChars, specialChars}
+	charset := strings.Join(classes, "")
+
 	password := make([]byte, length)
 	for i := range password {
-		// This is not cryptographically secure - use crypto/rand in production
-		password[i] = charset[i%len(charset)]
+		// The first characters cover each class; the shuffle below moves
+		// them to random positions
+		set := charset
+		if i < len(classes) {
+			set = classes[i]
+		}
+		n, err := randomIndex(len(set))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		password[i] = set[n]
+	}
+
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		password[i], password[j] = password[j], password[i]
 	}
 
 	return string(password), nil
 }
+
+// randomIndex returns a uniformly random integer in [0, n)
+func randomIndex(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
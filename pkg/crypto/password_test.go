@@ -0,0 +1,99 @@
+package crypto
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+func TestGenerateRandomPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		length  int
+		wantErr bool
+	}{
+		{name: "too short", length: 7, wantErr: true},
+		{name: "minimum length", length: 8},
+		{name: "long", length: 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			password, err := GenerateRandomPassword(tt.length)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("GenerateRandomPassword(%d) error = nil, want error", tt.length)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateRandomPassword(%d) error = %v", tt.length, err)
+			}
+
+			if len(password) != tt.length {
+				t.Errorf("len(password) = %d, want %d", len(password), tt.length)
+			}
+			for _, class := range []string{upperChars, lowerChars, digitChars, specialChars} {
+				if !strings.ContainsAny(password, class) {
+					t.Errorf("password %q has no character from %q", password, class)
+				}
+			}
+			if err := validator.ValidatePassword(password); err != nil {
+				t.Errorf("ValidatePassword(%q) = %v", password, err)
+			}
+		})
+	}
+}
+
+func TestGenerateRandomPasswordIsUnpredictable(t *testing.T) {
+	first, err := GenerateRandomPassword(16)
+	if err != nil {
+		t.Fatalf("GenerateRandomPassword() error = %v", err)
+	}
+	second, err := GenerateRandomPassword(16)
+	if err != nil {
+		t.Fatalf("GenerateRandomPassword() error = %v", err)
+	}
+	if first == second {
+		t.Errorf("two calls both returned %q", first)
+	}
+}
+
+func TestPepper(t *testing.T) {
+	const password = "Passw0rd!"
+	t.Cleanup(func() { SetPepper("") })
+
+	tests := []struct {
+		name        string
+		hashPepper  string
+		checkPepper string
+		want        bool
+	}{
+		{name: "without pepper", want: true},
+		{name: "with pepper", hashPepper: "pepper-a", checkPepper: "pepper-a", want: true},
+		{name: "pepper removed", hashPepper: "pepper-a", want: false},
+		{name: "pepper added", checkPepper: "pepper-a", want: false},
+		{name: "pepper changed", hashPepper: "pepper-a", checkPepper: "pepper-b", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPepper(tt.hashPepper)
+			hash, err := HashPasswordWithCost(password, bcrypt.MinCost)
+			if err != nil {
+				t.Fatalf("HashPasswordWithCost() error = %v", err)
+			}
+
+			SetPepper(tt.checkPepper)
+			if got := CheckPasswordHash(password, hash); got != tt.want {
+				t.Errorf("CheckPasswordHash() = %v, want %v", got, tt.want)
+			}
+			if CheckPasswordHash("wrong"+password, hash) {
+				t.Error("CheckPasswordHash() accepted the wrong password")
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+// Bounds applied when a ListSpec leaves them unset
+const (
+	DefaultListLimit  = 50
+	MaxListLimit      = 100
+	MaxFilterValueLen = 100
+)
+
+// ListSpec describes the list parameters an endpoint accepts
+type ListSpec struct {
+	DefaultLimit int               // Page size when limit is omitted; defaults to DefaultListLimit
+	MaxLimit     int               // Largest accepted limit; defaults to MaxListLimit
+	SortFields   map[string]string // Allowlisted sort keys; nil rejects any sort parameter
+	Filters      []string          // Query parameters accepted as exact-match filters
+}
+
+// ListParams holds validated list parameters
+type ListParams struct {
+	Limit   int
+	Offset  int
+	Sort    string            // Comma-separated keys from ListSpec.SortFields, "-" prefixed for descending; empty for the default order
+	Filters map[string]string // Filter values by name; absent filters are omitted
+}
+
+// ParseListParams reads limit, offset, sort, and the spec's filters from the
+// query string. Every problem is reported as a field error so the client
+// can fix all of them at once.
+func ParseListParams(r *http.Request, spec ListSpec) (ListParams, validator.ValidationErrors) {
+	defaultLimit := spec.DefaultLimit
+	if defaultLimit <= 0 {
+		defaultLimit = DefaultListLimit
+	}
+	maxLimit := spec.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = MaxListLimit
+	}
+
+	query := r.URL.Query()
+	params := ListParams{Limit: defaultLimit}
+	var errs validator.ValidationErrors
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			errs.AddCode("limit", validator.CodeInvalidRange, fmt.Sprintf("limit must be an integer between 1 and %d", maxLimit))
+		} else {
+			params.Limit = limit
+		}
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		switch {
+		case err != nil:
+			errs.AddCode("offset", validator.CodeInvalidRange, "offset must be an integer")
+		case offset < 0:
+			errs.AddCode("offset", validator.CodeNegative, "offset cannot be negative")
+		default:
+			params.Offset = offset
+		}
+	}
+
+	if sort := strings.TrimSpace(query.Get("sort")); sort != "" {
+		if err := validateSort(sort, spec.SortFields); err != nil {
+			errs.AddCode("sort", validator.CodeNotAllowed, err.Error())
+		} else {
+			params.Sort = sort
+		}
+	}
+
+	for _, name := range spec.Filters {
+		v := strings.TrimSpace(query.Get(name))
+		if v == "" {
+			continue
+		}
+		if len(v) > MaxFilterValueLen {
+			errs.AddCode(name, validator.CodeTooLong, fmt.Sprintf("%s must be at most %d characters", name, MaxFilterValueLen))
+			continue
+		}
+		if params.Filters == nil {
+			params.Filters = make(map[string]string)
+		}
+		params.Filters[name] = v
+	}
+
+	return params, errs
+}
+
+// validateSort checks that every term of a sort parameter such as
+// "-created_at,name" names an allowlisted field at most once
+func validateSort(sort string, fields map[string]string) error {
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimPrefix(strings.TrimSpace(field), "-")
+		if _, ok := fields[field]; !ok {
+			return fmt.Errorf("cannot sort by %q", field)
+		}
+		if seen[field] {
+			return fmt.Errorf("%q appears more than once in sort", field)
+		}
+		seen[field] = true
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/amillerrr/jwt-rbac-cors-app/pkg/validator"
+)
+
+func TestParseListParams(t *testing.T) {
+	spec := ListSpec{
+		MaxLimit:   20,
+		SortFields: map[string]string{"name": "name", "created_at": "created_at"},
+		Filters:    []string{"category"},
+	}
+
+	tests := []struct {
+		name       string
+		spec       ListSpec
+		query      string
+		want       ListParams
+		wantErrors []string // field:code of each error, in order
+	}{
+		{name: "defaults", spec: spec, want: ListParams{Limit: DefaultListLimit}},
+		{name: "spec default limit", spec: ListSpec{DefaultLimit: 10}, want: ListParams{Limit: 10}},
+		{name: "all valid", spec: spec, query: "limit=5&offset=10&sort=-created_at,name&category=books",
+			want: ListParams{Limit: 5, Offset: 10, Sort: "-created_at,name", Filters: map[string]string{"category": "books"}}},
+
+		{name: "limit at minimum", spec: spec, query: "limit=1", want: ListParams{Limit: 1}},
+		{name: "limit at maximum", spec: spec, query: "limit=20", want: ListParams{Limit: 20}},
+		{name: "limit zero", spec: spec, query: "limit=0", want: ListParams{Limit: DefaultListLimit},
+			wantErrors: []string{"limit:" + validator.CodeInvalidRange}},
+		{name: "limit over maximum", spec: spec, query: "limit=21", want: ListParams{Limit: DefaultListLimit},
+			wantErrors: []string{"limit:" + validator.CodeInvalidRange}},
+		{name: "limit over package maximum", spec: ListSpec{}, query: fmt.Sprintf("limit=%d", MaxListLimit+1), want: ListParams{Limit: DefaultListLimit},
+			wantErrors: []string{"limit:" + validator.CodeInvalidRange}},
+		{name: "limit not a number", spec: spec, query: "limit=ten", want: ListParams{Limit: DefaultListLimit},
+			wantErrors: []string{"limit:" + validator.CodeInvalidRange}},
+
+		{name: "offset zero", spec: spec, query: "offset=0", want: ListParams{Limit: DefaultListLimit}},
+		{name: "offset negative", spec: spec, query: "offset=-1", want: ListParams{Limit: DefaultListLimit},
+			wantErrors: []string{"offset:" + validator.CodeNegative}},
+		{name: "offset not a number", spec: spec, query: "offset=1.5", want: ListParams{Limit: DefaultListLimit},
+			wantErrors: []string{"offset:" + validator.CodeInvalidRange}},
+
+		{name: "sort not allowlisted", spec: spec, query: "sort=password_hash", want: ListParams{Limit: DefaultListLimit},
+			wantErrors: []string{"sort:" + validator.CodeNotAllowed}},
+		{name: "sort repeated", spec: spec, query: "sort=name,-name", want: ListParams{Limit: DefaultListLimit},
+			wantErrors: []string{"sort:" + validator.CodeNotAllowed}},
+		{name: "sort without allowlist", spec: ListSpec{}, query: "sort=name", want: ListParams{Limit: DefaultListLimit},
+			wantErrors: []string{"sort:" + validator.CodeNotAllowed}},
+
+		{name: "filter at maximum length", spec: spec, query: "category=" + strings.Repeat("a", MaxFilterValueLen),
+			want: ListParams{Limit: DefaultListLimit, Filters: map[string]string{"category": strings.Repeat("a", MaxFilterValueLen)}}},
+		{name: "filter too long", spec: spec, query: "category=" + strings.Repeat("a", MaxFilterValueLen+1), want: ListParams{Limit: DefaultListLimit},
+			wantErrors: []string{"category:" + validator.CodeTooLong}},
+		{name: "unlisted filter ignored", spec: spec, query: "owner=7", want: ListParams{Limit: DefaultListLimit}},
+
+		{name: "every problem reported", spec: spec, query: "limit=-5&offset=-1&sort=secret",
+			want:       ListParams{Limit: DefaultListLimit},
+			wantErrors: []string{"limit:" + validator.CodeInvalidRange, "offset:" + validator.CodeNegative, "sort:" + validator.CodeNotAllowed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/items?"+tt.query, nil)
+			params, errs := ParseListParams(req, tt.spec)
+
+			var gotErrors []string
+			for _, err := range errs {
+				gotErrors = append(gotErrors, fmt.Sprintf("%s:%s", err.Field, err.Code))
+			}
+			if !slices.Equal(gotErrors, tt.wantErrors) {
+				t.Errorf("ParseListParams() errors = %v, want %v", gotErrors, tt.wantErrors)
+			}
+			if !reflect.DeepEqual(params, tt.want) {
+				t.Errorf("ParseListParams() = %+v, want %+v", params, tt.want)
+			}
+		})
+	}
+}
@@ -1,17 +1,55 @@
 package validator
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
+)
+
+// Error codes identify a validation failure independently of its message,
+// so clients and message catalogs can key on them
+const (
+	CodeRequired          = "required"
+	CodeInvalidEmail      = "invalid_email"
+	CodeTooShort          = "too_short"
+	CodeTooLong           = "too_long"
+	CodePasswordTooShort  = "password_too_short"
+	CodePasswordUppercase = "password_uppercase"
+	CodePasswordLowercase = "password_lowercase"
+	CodePasswordNumber    = "password_number"
+	CodeNegative          = "negative"
+	CodeTooLarge          = "too_large"
+	CodeNotAllowed        = "not_allowed"
+	CodeDuplicate         = "duplicate"
+	CodeAlreadyExists     = "already_exists"
+	CodeInvalidRange      = "invalid_range"
 )
 
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string `json:"field"`
+	Code    string `json:"code,omitempty"`
 	Message string `json:"message"`
 }
 
+// CodedError is a validation failure carrying a machine-readable code
+type CodedError struct {
+	Code    string
+	Message string
+}
+
+// Error implements the error interface
+func (e *CodedError) Error() string {
+	return e.Message
+}
+
+// newError creates a coded validation failure
+func newError(code, format string, args ...interface{}) error {
+	return &CodedError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
 // ValidationErrors is a collection of validation errors
 type ValidationErrors []ValidationError
 
@@ -29,21 +67,56 @@ func (ve *ValidationErrors) Add(field, message string) {
 	*ve = append(*ve, ValidationError{Field: field, Message: message})
 }
 
+// AddCode adds a validation error with a machine-readable code
+func (ve *ValidationErrors) AddCode(field, code, message string) {
+	*ve = append(*ve, ValidationError{Field: field, Code: code, Message: message})
+}
+
+// AddError adds err as a validation error, keeping its code if it has one
+func (ve *ValidationErrors) AddError(field string, err error) {
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		ve.AddCode(field, coded.Code, coded.Message)
+		return
+	}
+	ve.Add(field, err.Error())
+}
+
 // HasErrors returns true if there are validation errors
 func (ve ValidationErrors) HasErrors() bool {
 	return len(ve) > 0
 }
 
+// Validator is implemented by request types that check their own fields.
+// Each request type owns its rules; handlers call Validate and respond with
+// the returned errors, so every endpoint reports problems in the same shape.
+type Validator interface {
+	Validate() ValidationErrors
+}
+
+// Validate runs the validation rules of v
+func Validate(v Validator) ValidationErrors {
+	return v.Validate()
+}
+
+// ValidateRequired checks that a field is present and not just whitespace
+func ValidateRequired(field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return newError(CodeRequired, "%s is required", field)
+	}
+	return nil
+}
+
 // ValidateEmail validates email format
 func ValidateEmail(email string) error {
 	if email == "" {
-		return fmt.Errorf("email is required")
+		return newError(CodeRequired, "email is required")
 	}
 	
 	// Simple email regex - in production you might want a more sophisticated one
 	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	if !emailRegex.MatchString(email) {
-		return fmt.Errorf("invalid email format")
+		return newError(CodeInvalidEmail, "invalid email format")
 	}
 	
 	return nil
@@ -52,29 +125,29 @@ func ValidateEmail(email string) error {
 // ValidatePassword validates password strength
 func ValidatePassword(password string) error {
 	if password == "" {
-		return fmt.Errorf("password is required")
+		return newError(CodeRequired, "password is required")
 	}
 	
 	if len(password) < 8 {
-		return fmt.Errorf("password must be at least 8 characters long")
+		return newError(CodePasswordTooShort, "password must be at least 8 characters long")
 	}
 	
 	// Check for at least one uppercase letter
 	hasUpper := regexp.MustCompile(`[A-Z]`).MatchString(password)
 	if !hasUpper {
-		return fmt.Errorf("password must contain at least one uppercase letter")
+		return newError(CodePasswordUppercase, "password must contain at least one uppercase letter")
 	}
 	
 	// Check for at least one lowercase letter
 	hasLower := regexp.MustCompile(`[a-z]`).MatchString(password)
 	if !hasLower {
-		return fmt.Errorf("password must contain at least one lowercase letter")
+		return newError(CodePasswordLowercase, "password must contain at least one lowercase letter")
 	}
 	
 	// Check for at least one number
 	hasNumber := regexp.MustCompile(`[0-9]`).MatchString(password)
 	if !hasNumber {
-		return fmt.Errorf("password must contain at least one number")
+		return newError(CodePasswordNumber, "password must contain at least one number")
 	}
 	
 	return nil
@@ -83,15 +156,15 @@ func ValidatePassword(password string) error {
 // ValidateName validates user name
 func ValidateName(name string) error {
 	if name == "" {
-		return fmt.Errorf("name is required")
+		return newError(CodeRequired, "name is required")
 	}
 	
 	if len(strings.TrimSpace(name)) < 2 {
-		return fmt.Errorf("name must be at least 2 characters long")
+		return newError(CodeTooShort, "name must be at least 2 characters long")
 	}
 	
 	if len(name) > 100 {
-		return fmt.Errorf("name must be less than 100 characters")
+		return newError(CodeTooLong, "name must be less than 100 characters")
 	}
 	
 	return nil
@@ -102,15 +175,65 @@ func ValidateUserRegistration(name, email, password string) ValidationErrors {
 	var errors ValidationErrors
 	
 	if err := ValidateName(name); err != nil {
-		errors.Add("name", err.Error())
+		errors.AddError("name", err)
 	}
 	
 	if err := ValidateEmail(email); err != nil {
-		errors.Add("email", err.Error())
+		errors.AddError("email", err)
 	}
 	
 	if err := ValidatePassword(password); err != nil {
-		errors.Add("password", err.Error())
+		errors.AddError("password", err)
+	}
+	
+	return errors
+}
+
+// ValidateProductName validates a product name
+func ValidateProductName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return newError(CodeRequired, "name is required")
+	}
+	
+	if len(name) > 100 {
+		return newError(CodeTooLong, "name must be less than 100 characters")
+	}
+	
+	return nil
+}
+
+// ValidatePrice validates a product price
+func ValidatePrice(price float64) error {
+	if price < 0 {
+		return newError(CodeNegative, "price cannot be negative")
+	}
+	
+	if price >= 100000000 {
+		return newError(CodeTooLarge, "price must be less than 100000000")
+	}
+	
+	return nil
+}
+
+// ValidateAvailability checks that a publish window ends after it starts.
+// Either end may be nil, leaving that side of the window open.
+func ValidateAvailability(from, until *time.Time) error {
+	if from != nil && until != nil && !until.After(*from) {
+		return newError(CodeInvalidRange, "available_until must be later than available_from")
+	}
+	return nil
+}
+
+// ValidateProduct validates the fields of a product create or update request
+func ValidateProduct(name string, price float64) ValidationErrors {
+	var errors ValidationErrors
+	
+	if err := ValidateProductName(name); err != nil {
+		errors.AddError("name", err)
+	}
+	
+	if err := ValidatePrice(price); err != nil {
+		errors.AddError("price", err)
 	}
 	
 	return errors